@@ -3,24 +3,35 @@ package main
 import (
 	"archive/zip"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"cisco-plink/internal/audit"
+	"cisco-plink/internal/bundle"
 	"cisco-plink/internal/cisco"
 	"cisco-plink/internal/config"
+	"cisco-plink/internal/credential"
 	appCrypto "cisco-plink/internal/crypto"
+	"cisco-plink/internal/datadir"
 	"cisco-plink/internal/email"
+	"cisco-plink/internal/fsutil"
+	"cisco-plink/internal/inventory"
+	"cisco-plink/internal/redact"
 	"cisco-plink/internal/scheduler"
+	"cisco-plink/internal/secret"
 	"cisco-plink/internal/updater"
 
+	"github.com/google/uuid"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
 )
 
@@ -30,39 +41,99 @@ const (
 	GitHubRepo  = "AutoLogCollector"
 )
 
+// unredactedDir holds encrypted unredacted log copies inside a run's log directory
+const unredactedDir = "unredacted"
+
 // queueItem represents a pending execution in the queue
 type queueItem struct {
-	task            *scheduler.ScheduledTask // non-nil for scheduled tasks
-	servers         []cisco.Server
-	commands        []string
-	username        string
-	password        string
-	timeout         int
-	enableMode      bool
-	disablePaging   bool
-	autoExportExcel bool
-	enablePassword  string
-	scheduleName    string
-	isManual        bool
+	id                string
+	priority          string                   // scheduler.PriorityHigh, PriorityNormal or PriorityLow
+	task              *scheduler.ScheduledTask // non-nil for scheduled tasks
+	servers           []cisco.Server
+	commands          []string
+	username          string
+	password          string
+	timeout           int
+	enableMode        bool
+	disablePaging     bool
+	autoExportExcel   bool
+	enablePassword    string
+	scheduleName      string
+	credentialProfile string
+	isManual          bool
+	chain             *chainRun // logical run the item belongs to (scheduled tasks)
+	queuedAt          time.Time
+	interrupted       bool // restored run that was in progress when the app closed
+}
+
+// expiry returns when a queued run exceeds its schedule's max queue wait,
+// or zero if it may wait indefinitely
+func (item *queueItem) expiry() time.Time {
+	if item.task == nil || item.task.MaxQueueWait <= 0 {
+		return time.Time{}
+	}
+	return item.queuedAt.Add(time.Duration(item.task.MaxQueueWait) * time.Minute)
+}
+
+// chainRun groups a schedule run and the runs its completion triggers
+// (schedules of type "after") into one logical run. Later runs log below
+// the first run's log directory, each in its own directory.
+type chainRun struct {
+	id      string
+	root    string // schedule that started the chain
+	started time.Time
+	logDir  string                   // log directory of the first run
+	steps   []map[string]interface{} // completed runs
+	pending int                      // runs queued or running
+}
+
+// execution is a run started manually, by a schedule or from the queue.
+// Several runs execute at once, up to the SSH session cap; ended runs stay
+// addressable by ID so their results can still be exported.
+type execution struct {
+	id        string
+	item      queueItem // what was started; saved as interrupted with the queue
+	trigger   string
+	runner    *cisco.Runner // nil while the run is being set up
+	started   time.Time
+	stopped   bool
+	completed bool // every server finished; completeRun was called
+	done      bool // ended, completed or stopped; its session is free
 }
 
+// maxFinishedRuns is how many ended runs are kept for GetRuns and exports
+const maxFinishedRuns = 20
+
 // App struct
 type App struct {
-	ctx              context.Context
-	runner           *cisco.Runner
-	updater          *updater.Updater
-	scheduler        *scheduler.Scheduler
-	mu               sync.Mutex
-	servers          []cisco.Server
-	commands         []string
-	autoExportExcel  bool // Flag for auto Excel export on completion
-	pendingEmailTask *scheduler.ScheduledTask
-	queue            []queueItem
+	ctx               context.Context
+	updater           *updater.Updater
+	scheduler         *scheduler.Scheduler
+	mu                sync.Mutex
+	servers           []cisco.Server
+	commands          []string
+	queue             []queueItem
+	credentialProfile string                   // default credential profile for the next run
+	priority          string                   // queue priority of the next manual run
+	netBoxStop        chan struct{}            // closes the NetBox re-sync loop
+	stateLoaded       bool                     // saved schedules loaded and background jobs started
+	openedBundle      *bundle.Contents         // bundle opened by OpenBundle, awaiting ImportBundle
+	configLock        *fsutil.Lock             // nil if another instance owns the configuration
+	runs              map[string]*execution    // runs by ID, running and recently ended
+	runOrder          []string                 // run IDs in start order
+	maxSessions       int                      // cap on concurrent SSH sessions (0 = config.DefaultMaxSessions)
+	queueExpiry       *time.Timer              // fires when the next queued run expires
+	restored          []queueItem              // runs saved by the previous session, until the user decides
+	queueLoaded       bool                     // saved queue loaded; until then saving would drop it
+	queueSaveMu       sync.Mutex               // keeps queue saves in order
+	inventoryMu       sync.Mutex               // held while loading, changing and saving the inventory
+	missedRuns        []map[string]interface{} // missed runs found at load, until the frontend takes them
+	deferred          map[string]*time.Timer   // runs deferred past a blackout, by task ID
 }
 
 // NewApp creates a new App application struct
 func NewApp() *App {
-	return &App{}
+	return &App{runs: make(map[string]*execution)}
 }
 
 // startup is called when the app starts
@@ -70,57 +141,172 @@ func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
 	a.updater = updater.NewUpdater(GitHubOwner, GitHubRepo)
 
+	// A second instance must not overwrite the configuration or run the
+	// same schedules twice, so it opens everything read-only
+	lock, err := datadir.Lock()
+	if err != nil {
+		fsutil.SetReadOnly(true)
+		if err != fsutil.ErrLocked {
+			println("Failed to lock configuration:", err.Error())
+		}
+	}
+	a.configLock = lock
+
 	// Initialize scheduler
 	a.scheduler = scheduler.NewScheduler(a.executeScheduledTask)
-	a.scheduler.Start()
+	if !fsutil.ReadOnly() {
+		a.scheduler.Start()
+	}
+
+	// With a master password, saved schedules can only be decrypted after UnlockVault
+	if !appCrypto.IsLocked() {
+		a.loadSavedState()
+	}
+}
+
+// loadSavedState loads saved schedules and starts background jobs (once)
+func (a *App) loadSavedState() {
+	a.mu.Lock()
+	if a.stateLoaded {
+		a.mu.Unlock()
+		return
+	}
+	a.stateLoaded = true
+	a.mu.Unlock()
+
+	if !fsutil.ReadOnly() {
+		a.completeKeyRotation()
+	}
 
 	// Load saved schedules
 	cfg, err := config.Load()
-	if err == nil && len(cfg.Schedules) > 0 {
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load schedules: "+err.Error())
+	} else if len(cfg.Schedules) > 0 {
 		a.scheduler.LoadTasks(cfg.Schedules)
 	}
+
+	// Configure secret providers before anything resolves references
+	a.applySecretSettings()
+
+	// Start periodic inventory re-sync if configured
+	a.startNetBoxSync()
+
+	// Cap on concurrent SSH sessions, before any run starts
+	a.applyExecutionSettings()
+
+	// Runs queued or in progress when the app last closed
+	if !fsutil.ReadOnly() {
+		a.restoreQueue()
+	}
+
+	// Handle runs that were due while the app was closed
+	if !fsutil.ReadOnly() {
+		a.catchUpSchedules()
+	}
 }
 
 // shutdown is called when the app is closing
 func (a *App) shutdown(ctx context.Context) {
 	if a.scheduler != nil {
 		a.scheduler.Stop()
+		a.saveRunStates()
 	}
+	a.stopDeferredRuns()
+	a.stopNetBoxSync()
+	a.saveQueue()
+	a.configLock.Unlock()
+}
+
+// IsReadOnly reports whether another instance owns the configuration. A
+// read-only instance can run commands but does not save settings or run
+// schedules.
+func (a *App) IsReadOnly() bool {
+	return fsutil.ReadOnly()
 }
 
 // executeScheduledTask is called when a scheduled task triggers
 func (a *App) executeScheduledTask(task *scheduler.ScheduledTask) {
-	if task.Username == "" || task.Password == "" {
-		runtime.EventsEmit(a.ctx, "scheduleSkipped", map[string]interface{}{
-			"taskId":   task.ID,
-			"taskName": task.Name,
-			"reason":   "No credentials configured in schedule.",
-		})
+	// Persist that a one-time task has fired and disabled itself
+	if task.ScheduleType == scheduler.TypeOnce {
+		a.saveSchedules()
+	} else {
+		a.saveRunStates()
+	}
+	if a.blackedOut(task, audit.TriggerSchedule) {
 		return
 	}
+	a.executeTask(task, audit.TriggerSchedule, nil)
+}
 
-	// Check if another execution is running
-	if a.runner != nil && a.runner.IsRunning() {
-		a.mu.Lock()
-		a.queue = append(a.queue, queueItem{
-			task:            task,
-			servers:         append([]cisco.Server{}, task.Servers...),
-			commands:        append([]string{}, task.Commands...),
-			username:        task.Username,
-			password:        task.Password,
-			timeout:         task.Timeout,
-			enableMode:      task.EnableMode,
-			disablePaging:   task.DisablePaging,
-			autoExportExcel: task.AutoExportExcel,
-			enablePassword:  task.EnablePassword,
-			scheduleName:    task.Name,
-		})
-		a.mu.Unlock()
-		runtime.EventsEmit(a.ctx, "scheduleQueued", map[string]interface{}{
-			"taskId":   task.ID,
-			"taskName": task.Name,
-			"position": len(a.queue),
-		})
+// executeTask runs a scheduled task, or queues it if every SSH session is in
+// use or the task is already running. A task triggered by another task's
+// completion continues that task's chain; otherwise it starts a new one.
+func (a *App) executeTask(task *scheduler.ScheduledTask, trigger string, chain *chainRun) {
+	if chain == nil {
+		chain = &chainRun{id: uuid.New().String(), root: task.Name, started: time.Now()}
+	}
+
+	details := map[string]interface{}{
+		"id":      task.ID,
+		"name":    task.Name,
+		"trigger": trigger,
+	}
+
+	// Resolve targets against the current inventory
+	servers, err := a.resolveTaskServers(task)
+	if err != nil || len(servers) == 0 {
+		reason := "No servers matched the schedule target."
+		if err != nil {
+			reason = "Failed to resolve target: " + err.Error()
+		}
+		a.skipRun(task, reason, details)
+		return
+	}
+
+	// Without schedule credentials, every server needs a credential profile
+	// of its own, of one of its groups or of the schedule
+	if task.Username == "" || task.Password == "" {
+		resolved, err := resolveCredentials(servers, task.CredentialProfile)
+		reason := ""
+		if err != nil {
+			reason = "Failed to resolve credentials: " + err.Error()
+		} else if !allHaveCredentials(resolved) {
+			reason = "No credentials in schedule, and not every server has a credential profile."
+		}
+		if reason != "" {
+			a.skipRun(task, reason, details)
+			return
+		}
+	}
+
+	item := queueItem{
+		id:                uuid.New().String(),
+		priority:          task.Priority,
+		task:              task,
+		servers:           servers,
+		commands:          append([]string{}, task.Commands...),
+		username:          task.Username,
+		password:          task.Password,
+		timeout:           task.Timeout,
+		enableMode:        task.EnableMode,
+		disablePaging:     task.DisablePaging,
+		autoExportExcel:   task.AutoExportExcel,
+		enablePassword:    task.EnablePassword,
+		scheduleName:      task.Name,
+		credentialProfile: task.CredentialProfile,
+		chain:             chain,
+		queuedAt:          time.Now(),
+	}
+
+	a.mu.Lock()
+	run := a.admit(item)
+	if run != nil {
+		chain.pending++
+	}
+	a.mu.Unlock()
+	if run == nil {
+		a.queueTask(item, trigger)
 		return
 	}
 
@@ -128,17 +314,210 @@ func (a *App) executeScheduledTask(task *scheduler.ScheduledTask) {
 	runtime.EventsEmit(a.ctx, "scheduleStarted", map[string]interface{}{
 		"taskId":   task.ID,
 		"taskName": task.Name,
+		"runId":    run.id,
 	})
 
-	// Set servers and commands from task
+	if !a.startRun(run, trigger) {
+		a.endChainStep(chain)
+	}
+}
+
+// queueTask queues a run of a scheduled task according to the task's
+// overlap policy. Catch-up runs always queue: the missed run policy decided
+// how many of them run.
+func (a *App) queueTask(item queueItem, trigger string) {
+	task, chain := item.task, item.chain
+	details := map[string]interface{}{
+		"id":      task.ID,
+		"name":    task.Name,
+		"trigger": trigger,
+	}
+
+	a.mu.Lock()
+	queued := -1
+	for i, q := range a.queue {
+		if q.task != nil && q.task.ID == task.ID {
+			queued = i
+			break
+		}
+	}
+
+	var replaced *queueItem
+	catchUp := trigger == audit.TriggerCatchUp
+	switch {
+	case catchUp:
+		queued = a.enqueue(item)
+	case task.OverlapPolicy == scheduler.OverlapSkip && a.taskRunning(task.ID):
+		a.mu.Unlock()
+		a.skipRun(task, "The previous run of this schedule is still running", details)
+		return
+	case task.OverlapPolicy == scheduler.OverlapSkip && queued >= 0:
+		a.mu.Unlock()
+		a.skipRun(task, "A run of this schedule is already queued", details)
+		return
+	case task.OverlapPolicy == scheduler.OverlapReplace && queued >= 0:
+		// The new run takes the queued run's place in line
+		old := a.queue[queued]
+		replaced = &old
+		a.queue[queued] = item
+	default:
+		queued = a.enqueue(item)
+	}
+	chain.pending++
+	a.armQueueExpiry()
+	a.mu.Unlock()
+	a.saveQueue()
+
+	if replaced != nil {
+		details["queuedAt"] = replaced.queuedAt.Format(time.RFC3339)
+		a.skipRun(task, "Replaced by a newer run of this schedule", details)
+		a.emitQueueStatus(replaced, queueReplaced, nil)
+		if replaced.chain != nil {
+			a.endChainStep(replaced.chain)
+		}
+	}
+	a.emitQueueStatus(&item, queueQueued, map[string]interface{}{"position": queued + 1})
+	runtime.EventsEmit(a.ctx, "scheduleQueued", map[string]interface{}{
+		"queueId":  item.id,
+		"taskId":   task.ID,
+		"taskName": task.Name,
+		"position": queued + 1,
+	})
+
+	// A session may have been freed since admit was refused
+	go a.processQueue()
+}
+
+// enqueue inserts item behind the queued runs of the same or a higher
+// priority and returns its index (must be called with lock held)
+func (a *App) enqueue(item queueItem) int {
+	rank := scheduler.PriorityRank(item.priority)
+	i := len(a.queue)
+	for i > 0 && scheduler.PriorityRank(a.queue[i-1].priority) < rank {
+		i--
+	}
+	a.queue = append(a.queue, queueItem{})
+	copy(a.queue[i+1:], a.queue[i:])
+	a.queue[i] = item
+	return i
+}
+
+// queueIndex returns the index of the queued run with the given ID, or -1
+// (must be called with lock held)
+func (a *App) queueIndex(id string) int {
+	for i := range a.queue {
+		if a.queue[i].id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Statuses of a queued run, reported by the "queueItem" event
+const (
+	queueQueued    = "queued"
+	queueMoved     = "moved" // position or priority changed
+	queueStarted   = "started"
+	queueFailed    = "failed" // could not be started
+	queueCancelled = "cancelled"
+	queueExpired   = "expired"
+	queueReplaced  = "replaced" // by a newer run of the same schedule
+)
+
+// emitQueueStatus reports a change in the status of a queued run
+func (a *App) emitQueueStatus(item *queueItem, status string, extra map[string]interface{}) {
+	data := map[string]interface{}{
+		"id":           item.id,
+		"status":       status,
+		"scheduleName": item.scheduleName,
+		"isManual":     item.isManual,
+		"priority":     queuePriority(item.priority),
+	}
+	if item.task != nil {
+		data["taskId"] = item.task.ID
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+	runtime.EventsEmit(a.ctx, "queueItem", data)
+}
+
+// queuePriority returns the priority with the default filled in
+func queuePriority(priority string) string {
+	if priority == "" {
+		return scheduler.PriorityNormal
+	}
+	return priority
+}
+
+// armQueueExpiry sets the expiry timer to the earliest expiry of a queued
+// run (must be called with lock held)
+func (a *App) armQueueExpiry() {
+	if a.queueExpiry != nil {
+		a.queueExpiry.Stop()
+		a.queueExpiry = nil
+	}
+
+	var next time.Time
+	for i := range a.queue {
+		if at := a.queue[i].expiry(); !at.IsZero() && (next.IsZero() || at.Before(next)) {
+			next = at
+		}
+	}
+	if !next.IsZero() {
+		a.queueExpiry = time.AfterFunc(time.Until(next), a.expireQueue)
+	}
+}
+
+// expireQueue removes the queued runs that have waited longer than their
+// schedule's max queue wait
+func (a *App) expireQueue() {
+	now := time.Now()
+
 	a.mu.Lock()
-	a.servers = task.Servers
-	a.commands = task.Commands
-	a.pendingEmailTask = task
+	var expired, kept []queueItem
+	for _, item := range a.queue {
+		if at := item.expiry(); !at.IsZero() && !at.After(now) {
+			expired = append(expired, item)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	a.queue = kept
+	a.armQueueExpiry()
 	a.mu.Unlock()
 
-	// Start execution with task's credentials and options
-	a.StartExecution(task.Username, task.Password, task.Timeout, task.EnableMode, task.DisablePaging, task.AutoExportExcel, task.EnablePassword, task.Name)
+	if len(expired) > 0 {
+		a.saveQueue()
+	}
+	for _, item := range expired {
+		reason := fmt.Sprintf("Waited in the queue for more than %d minute(s)", item.task.MaxQueueWait)
+		a.skipRun(item.task, reason, map[string]interface{}{
+			"id":       item.task.ID,
+			"name":     item.task.Name,
+			"trigger":  audit.TriggerQueue,
+			"queuedAt": item.queuedAt.Format(time.RFC3339),
+		})
+		a.emitQueueStatus(&item, queueExpired, map[string]interface{}{"reason": reason})
+		if item.chain != nil {
+			a.endChainStep(item.chain)
+		}
+	}
+}
+
+// resolveTaskServers returns the servers a scheduled task runs against.
+// Tasks with a target are resolved against the saved inventory at run time,
+// so inventory changes apply to every schedule automatically.
+func (a *App) resolveTaskServers(task *scheduler.ScheduledTask) ([]cisco.Server, error) {
+	if task.Target.IsEmpty() {
+		return append([]cisco.Server{}, task.Servers...), nil
+	}
+
+	inv, err := inventory.Load()
+	if err != nil {
+		return nil, err
+	}
+	return task.Target.Resolve(inv.Servers)
 }
 
 // SetServers sets the server list from GUI input
@@ -148,23 +527,62 @@ func (a *App) SetServers(servers []map[string]string) {
 
 	a.servers = make([]cisco.Server, 0, len(servers))
 	for _, s := range servers {
-		ip := s["ip"]
-		hostname := s["hostname"]
-		if ip != "" {
-			if hostname == "" {
-				hostname = ip
-			}
-			a.servers = append(a.servers, cisco.Server{
-				IP:             ip,
-				Hostname:       hostname,
-				Username:       s["username"],
-				Password:       s["password"],
-				EnablePassword: s["enablePassword"],
-			})
+		if srv, ok := mapToServer(s); ok {
+			a.servers = append(a.servers, srv)
 		}
 	}
 }
 
+// SetTarget resolves a target against the saved inventory and uses the
+// matching servers for the next manual run. Returns the number of servers selected.
+func (a *App) SetTarget(targetData map[string]interface{}) int {
+	target := mapToTarget(targetData)
+	if target.IsEmpty() {
+		runtime.EventsEmit(a.ctx, "error", "Target is empty")
+		return 0
+	}
+
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+		return 0
+	}
+
+	servers, err := target.Resolve(inv.Servers)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Invalid target: "+err.Error())
+		return 0
+	}
+
+	a.mu.Lock()
+	a.servers = servers
+	a.mu.Unlock()
+	return len(servers)
+}
+
+// ResolveTarget returns the inventory servers matched by a target (for preview)
+func (a *App) ResolveTarget(targetData map[string]interface{}) []map[string]string {
+	target := mapToTarget(targetData)
+
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+		return nil
+	}
+
+	servers, err := target.Resolve(inv.Servers)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Invalid target: "+err.Error())
+		return nil
+	}
+
+	result := make([]map[string]string, len(servers))
+	for i, s := range servers {
+		result[i] = serverToMap(s)
+	}
+	return result
+}
+
 // SetCommands sets the command list from GUI input
 func (a *App) SetCommands(commands []string) {
 	a.mu.Lock()
@@ -179,666 +597,3665 @@ func (a *App) SetCommands(commands []string) {
 	}
 }
 
-// SaveServerList saves the current server list to config/servers.json (encrypted)
+// SaveServerList saves the current server list to config/servers.json (encrypted).
+// Inventory attributes not sent by the UI (groups, tags, port, ...) are kept from the saved entry.
 func (a *App) SaveServerList(servers []map[string]string) bool {
-	key, err := appCrypto.LoadOrGenerateKey()
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	inv, err := inventory.Load()
 	if err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to load encryption key: "+err.Error())
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
 		return false
 	}
 
 	serverList := make([]cisco.Server, 0, len(servers))
 	for _, s := range servers {
-		ip := s["ip"]
-		if ip == "" {
+		srv, ok := mapToServer(s)
+		if !ok {
 			continue
 		}
-		hostname := s["hostname"]
-		if hostname == "" {
-			hostname = ip
-		}
-		srv := cisco.Server{
-			IP:       ip,
-			Hostname: hostname,
-		}
-		// Encrypt per-server credentials
-		if s["username"] != "" {
-			srv.Username = s["username"]
-			encPwd, encEnPwd, err := appCrypto.EncryptFields(s["password"], s["enablePassword"], key)
-			if err == nil {
-				srv.Password = encPwd
-				srv.EnablePassword = encEnPwd
-			}
+		if existing := inv.FindServer(srv.IP); existing != nil {
+			keepInventoryAttrs(&srv, existing, s)
 		}
 		serverList = append(serverList, srv)
 	}
+	inv.Servers = serverList
 
-	data, err := json.MarshalIndent(serverList, "", "  ")
-	if err != nil {
-		return false
-	}
-
-	if err := os.MkdirAll("config", 0755); err != nil {
+	if err := inventory.Save(inv); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save server list: "+err.Error())
 		return false
 	}
-	return os.WriteFile(filepath.Join("config", "servers.json"), data, 0644) == nil
+	return true
 }
 
 // LoadServerList loads saved server list from config/servers.json (decrypted)
 func (a *App) LoadServerList() []map[string]string {
-	data, err := os.ReadFile(filepath.Join("config", "servers.json"))
+	inv, err := inventory.Load()
 	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load server list: "+err.Error())
 		return nil
 	}
 
-	var servers []cisco.Server
-	if err := json.Unmarshal(data, &servers); err != nil {
-		return nil
+	result := make([]map[string]string, len(inv.Servers))
+	for i, s := range inv.Servers {
+		result[i] = serverToMap(s)
 	}
+	return result
+}
 
-	key, _ := appCrypto.LoadOrGenerateKey()
+// ==================== Groups ====================
 
-	result := make([]map[string]string, len(servers))
-	for i, s := range servers {
-		// Decrypt per-server credentials
-		if key != nil && s.Password != "" {
-			s.Password, s.EnablePassword = appCrypto.DecryptFields(s.Password, s.EnablePassword, key)
+// GetGroups returns all groups with their descriptions and member counts
+func (a *App) GetGroups() []map[string]interface{} {
+	inv, err := inventory.Load()
+	if err != nil {
+		return nil
+	}
+
+	names := inv.GroupNames()
+	result := make([]map[string]interface{}, len(names))
+	for i, name := range names {
+		description, profile := "", ""
+		if g := inv.FindGroup(name); g != nil {
+			description = g.Description
+			profile = g.CredentialProfile
 		}
-		result[i] = map[string]string{
-			"ip":             s.IP,
-			"hostname":       s.Hostname,
-			"username":       s.Username,
-			"password":       s.Password,
-			"enablePassword": s.EnablePassword,
+		result[i] = map[string]interface{}{
+			"name":              name,
+			"description":       description,
+			"credentialProfile": profile,
+			"memberCount":       len(inv.Members(name)),
 		}
 	}
 	return result
 }
 
-// ExportServersToCSV exports server list to a CSV file
-func (a *App) ExportServersToCSV(servers []map[string]string) bool {
-	file, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
-		Title:           "Export Servers to CSV",
-		DefaultFilename: "servers.csv",
-		Filters: []runtime.FileFilter{
-			{DisplayName: "CSV Files (*.csv)", Pattern: "*.csv"},
-		},
-	})
-	if err != nil || file == "" {
+// SaveGroup creates or updates a group. If "members" (a list of IPs) is
+// present, group membership is set to exactly those servers.
+func (a *App) SaveGroup(data map[string]interface{}) bool {
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	name, _ := data["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		runtime.EventsEmit(a.ctx, "error", "Group name is required")
 		return false
 	}
 
-	var lines []string
-	lines = append(lines, "ip,hostname")
-	for _, s := range servers {
-		ip := s["ip"]
-		hostname := s["hostname"]
-		if ip != "" {
-			lines = append(lines, ip+","+hostname)
-		}
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+		return false
 	}
 
-	content := strings.Join(lines, "\n") + "\n"
-	return os.WriteFile(file, []byte(content), 0644) == nil
-}
+	description, _ := data["description"].(string)
+	profile, _ := data["credentialProfile"].(string)
+	inv.SetGroup(inventory.Group{Name: name, Description: description, CredentialProfile: strings.TrimSpace(profile)})
 
-// ImportCommandsFromTxt opens file dialog and returns commands as text
-func (a *App) ImportCommandsFromTxt() string {
-	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Import Commands from TXT",
-		Filters: []runtime.FileFilter{
-			{DisplayName: "Text Files (*.txt)", Pattern: "*.txt"},
-			{DisplayName: "All Files (*.*)", Pattern: "*.*"},
-		},
-	})
-	if err != nil || file == "" {
-		return ""
+	if members, ok := data["members"].([]interface{}); ok {
+		inv.SetMembers(name, toStringSlice(members))
 	}
 
-	data, err := os.ReadFile(file)
-	if err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to read file: "+err.Error())
-		return ""
+	if err := inventory.Save(inv); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save group: "+err.Error())
+		return false
 	}
-	return string(data)
+	return true
 }
 
-// ExportCommandsToTxt saves commands to a TXT file
-func (a *App) ExportCommandsToTxt(commands string) bool {
-	file, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
-		Title:           "Export Commands to TXT",
-		DefaultFilename: "commands.txt",
-		Filters: []runtime.FileFilter{
-			{DisplayName: "Text Files (*.txt)", Pattern: "*.txt"},
-		},
-	})
-	if err != nil || file == "" {
+// DeleteGroup removes a group and its membership from all servers
+func (a *App) DeleteGroup(name string) bool {
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
 		return false
 	}
-	return os.WriteFile(file, []byte(commands), 0644) == nil
-}
 
-// ImportServersFromCSV opens file dialog and returns parsed servers
-func (a *App) ImportServersFromCSV() []map[string]string {
-	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
-		Title: "Import Servers from CSV",
-		Filters: []runtime.FileFilter{
-			{DisplayName: "CSV Files (*.csv)", Pattern: "*.csv"},
-			{DisplayName: "All Files (*.*)", Pattern: "*.*"},
-		},
-	})
-	if err != nil || file == "" {
-		return nil
+	inv.DeleteGroup(name)
+
+	if err := inventory.Save(inv); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to delete group: "+err.Error())
+		return false
 	}
+	return true
+}
 
-	servers, err := cisco.LoadServers(file)
+// GetTags returns all distinct tags used in the inventory
+func (a *App) GetTags() []string {
+	inv, err := inventory.Load()
 	if err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to load CSV: "+err.Error())
 		return nil
 	}
 
-	result := make([]map[string]string, len(servers))
-	for i, s := range servers {
-		result[i] = map[string]string{
-			"ip":       s.IP,
-			"hostname": s.Hostname,
+	seen := make(map[string]bool)
+	tags := make([]string, 0)
+	for _, s := range inv.Servers {
+		for _, t := range s.Tags {
+			if !seen[strings.ToLower(t)] {
+				seen[strings.ToLower(t)] = true
+				tags = append(tags, t)
+			}
 		}
 	}
-	return result
+	sort.Strings(tags)
+	return tags
 }
 
-// StartExecution begins the command execution
-func (a *App) StartExecution(username, password string, timeout int, enableMode, disablePaging, autoExportExcel bool, enablePassword string, scheduleName string) bool {
-	a.mu.Lock()
+// ==================== Credential Profiles ====================
 
-	if a.runner != nil && a.runner.IsRunning() {
-		// Queue the manual execution
-		serversCopy := append([]cisco.Server{}, a.servers...)
-		commandsCopy := append([]string{}, a.commands...)
-		a.queue = append(a.queue, queueItem{
-			servers:         serversCopy,
-			commands:        commandsCopy,
-			username:        username,
-			password:        password,
-			timeout:         timeout,
-			enableMode:      enableMode,
-			disablePaging:   disablePaging,
-			autoExportExcel: autoExportExcel,
-			enablePassword:  enablePassword,
-			scheduleName:    scheduleName,
-			isManual:        true,
-		})
-		pos := len(a.queue)
-		a.mu.Unlock()
-		runtime.EventsEmit(a.ctx, "scheduleQueued", map[string]interface{}{
-			"taskName": scheduleName,
-			"position": pos,
-			"isManual": true,
-		})
-		return true
-	}
+// SetCredentialProfile sets the default credential profile for the next manual run.
+// Servers with their own credentials or profile are not affected.
+func (a *App) SetCredentialProfile(name string) {
+	a.mu.Lock()
 	defer a.mu.Unlock()
+	a.credentialProfile = strings.TrimSpace(name)
+}
 
-	if len(a.servers) == 0 {
-		runtime.EventsEmit(a.ctx, "error", "No servers loaded")
-		return false
+// GetCredentialProfiles returns all credential profiles without secrets
+func (a *App) GetCredentialProfiles() []map[string]interface{} {
+	store, err := credential.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load credential profiles: "+err.Error())
+		return nil
 	}
 
-	if len(a.commands) == 0 {
-		runtime.EventsEmit(a.ctx, "error", "No commands loaded")
-		return false
+	result := make([]map[string]interface{}, len(store.Profiles))
+	for i, p := range store.Profiles {
+		usage := a.credentialProfileUsage(p.Name)
+		result[i] = map[string]interface{}{
+			"name":              p.Name,
+			"description":       p.Description,
+			"username":          p.Username,
+			"hasPassword":       p.Password != "",
+			"hasEnablePassword": p.EnablePassword != "",
+			"hasPrivateKey":     p.PrivateKey != "",
+			"serverCount":       len(usage["servers"]),
+			"groupCount":        len(usage["groups"]),
+			"scheduleCount":     len(usage["schedules"]),
+		}
 	}
+	return result
+}
+
+// GetCredentialProfile returns a single profile including its secrets (for editing)
+func (a *App) GetCredentialProfile(name string) map[string]interface{} {
+	store, err := credential.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load credential profiles: "+err.Error())
+		return nil
+	}
+
+	p := store.Find(name)
+	if p == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":           p.Name,
+		"description":    p.Description,
+		"username":       p.Username,
+		"password":       p.Password,
+		"enablePassword": p.EnablePassword,
+		"privateKey":     p.PrivateKey,
+		"passphrase":     p.Passphrase,
+	}
+}
+
+// SaveCredentialProfile creates or updates a profile. If "originalName" differs
+// from "name", the profile is renamed and all references are updated.
+func (a *App) SaveCredentialProfile(data map[string]interface{}) bool {
+	str := func(key string) string {
+		v, _ := data[key].(string)
+		return v
+	}
+	profile := credential.Profile{
+		Name:           strings.TrimSpace(str("name")),
+		Description:    str("description"),
+		Username:       str("username"),
+		Password:       str("password"),
+		EnablePassword: str("enablePassword"),
+		PrivateKey:     strings.TrimSpace(str("privateKey")),
+		Passphrase:     str("passphrase"),
+	}
+	originalName := strings.TrimSpace(str("originalName"))
+
+	store, err := credential.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load credential profiles: "+err.Error())
+		return false
+	}
+
+	if err := store.Set(profile, originalName); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Invalid credential profile: "+err.Error())
+		return false
+	}
+
+	if err := credential.Save(store); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save credential profile: "+err.Error())
+		return false
+	}
+
+	if originalName != "" && originalName != profile.Name {
+		if err := a.renameCredentialProfile(originalName, profile.Name); err != nil {
+			runtime.EventsEmit(a.ctx, "error", "Failed to update profile references: "+err.Error())
+			return false
+		}
+	}
+
+	details := map[string]interface{}{"name": profile.Name, "username": profile.Username}
+	if originalName == "" {
+		a.recordAudit(audit.CredentialCreate, details)
+	} else {
+		if originalName != profile.Name {
+			details["renamedFrom"] = originalName
+		}
+		a.recordAudit(audit.CredentialUpdate, details)
+	}
+	return true
+}
+
+// DeleteCredentialProfile removes a profile. Profiles still referenced by
+// servers, groups or schedules cannot be deleted.
+func (a *App) DeleteCredentialProfile(name string) bool {
+	usage := a.credentialProfileUsage(name)
+	if n := len(usage["servers"]) + len(usage["groups"]) + len(usage["schedules"]); n > 0 {
+		runtime.EventsEmit(a.ctx, "error", fmt.Sprintf("Credential profile '%s' is still used by %d server(s), group(s) or schedule(s)", name, n))
+		return false
+	}
+
+	store, err := credential.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load credential profiles: "+err.Error())
+		return false
+	}
+
+	store.Delete(name)
+
+	if err := credential.Save(store); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to delete credential profile: "+err.Error())
+		return false
+	}
+
+	a.recordAudit(audit.CredentialDelete, map[string]interface{}{"name": name})
+	return true
+}
+
+// TestCredentialProfile logs in to a device with the profile and disconnects
+func (a *App) TestCredentialProfile(name, ip string, port int) map[string]interface{} {
+	store, err := credential.Load()
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+
+	p := store.Find(name)
+	if p == nil {
+		return map[string]interface{}{"success": false, "error": fmt.Sprintf("credential profile '%s' not found", name)}
+	}
+
+	creds, err := secret.NewResolver().Credentials(p.Credentials())
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+
+	server := cisco.Server{IP: strings.TrimSpace(ip), Port: port}
+	if err := cisco.TestConnection(server, creds); err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+	return map[string]interface{}{"success": true}
+}
+
+// GetCredentialProfileUsage returns the servers, groups and schedules that reference a profile
+func (a *App) GetCredentialProfileUsage(name string) map[string][]string {
+	return a.credentialProfileUsage(name)
+}
+
+// credentialProfileUsage lists references to a profile by kind
+func (a *App) credentialProfileUsage(name string) map[string][]string {
+	usage := map[string][]string{
+		"servers":   {},
+		"groups":    {},
+		"schedules": {},
+	}
+
+	if inv, err := inventory.Load(); err == nil {
+		for _, s := range inv.Servers {
+			if strings.EqualFold(s.CredentialProfile, name) {
+				usage["servers"] = append(usage["servers"], s.Hostname)
+			}
+		}
+		for _, g := range inv.Groups {
+			if strings.EqualFold(g.CredentialProfile, name) {
+				usage["groups"] = append(usage["groups"], g.Name)
+			}
+		}
+	}
+
+	for _, task := range a.scheduler.GetTasks() {
+		if taskUsesProfile(task, name) {
+			usage["schedules"] = append(usage["schedules"], task.Name)
+		}
+	}
+	sort.Strings(usage["schedules"])
+
+	return usage
+}
+
+// taskUsesProfile reports whether a schedule or one of its servers references a profile
+func taskUsesProfile(task *scheduler.ScheduledTask, name string) bool {
+	if strings.EqualFold(task.CredentialProfile, name) {
+		return true
+	}
+	for _, s := range task.Servers {
+		if strings.EqualFold(s.CredentialProfile, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// renameCredentialProfile updates all references from oldName to newName
+func (a *App) renameCredentialProfile(oldName, newName string) error {
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	inv, err := inventory.Load()
+	if err != nil {
+		return err
+	}
+	for i := range inv.Servers {
+		if strings.EqualFold(inv.Servers[i].CredentialProfile, oldName) {
+			inv.Servers[i].CredentialProfile = newName
+		}
+	}
+	for i := range inv.Groups {
+		if strings.EqualFold(inv.Groups[i].CredentialProfile, oldName) {
+			inv.Groups[i].CredentialProfile = newName
+		}
+	}
+	if err := inventory.Save(inv); err != nil {
+		return err
+	}
+
+	changed := false
+	for _, task := range a.scheduler.GetTasks() {
+		if taskUsesProfile(task, oldName) {
+			updated := *task
+			if strings.EqualFold(updated.CredentialProfile, oldName) {
+				updated.CredentialProfile = newName
+			}
+			updated.Servers = append([]cisco.Server(nil), task.Servers...)
+			for i := range updated.Servers {
+				if strings.EqualFold(updated.Servers[i].CredentialProfile, oldName) {
+					updated.Servers[i].CredentialProfile = newName
+				}
+			}
+			if err := a.scheduler.UpdateTask(&updated); err != nil {
+				return err
+			}
+			changed = true
+		}
+	}
+	if changed {
+		a.saveSchedules()
+	}
+	return nil
+}
+
+// resolveCredentials attaches credential profile credentials to servers.
+// Servers that only carry an IP (e.g. from the UI table) take their profile
+// and groups from the saved inventory.
+func resolveCredentials(servers []cisco.Server, defaultProfile string) ([]cisco.Server, error) {
+	store, err := credential.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := inventory.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	groupProfiles := make(map[string]string)
+	for _, g := range inv.Groups {
+		if g.CredentialProfile != "" {
+			groupProfiles[strings.ToLower(g.Name)] = g.CredentialProfile
+		}
+	}
+
+	enriched := make([]cisco.Server, len(servers))
+	for i, srv := range servers {
+		if saved := inv.FindServer(srv.IP); saved != nil {
+			if srv.CredentialProfile == "" {
+				srv.CredentialProfile = saved.CredentialProfile
+			}
+			if len(srv.Groups) == 0 {
+				srv.Groups = saved.Groups
+			}
+		}
+		enriched[i] = srv
+	}
+
+	return store.Resolve(enriched, groupProfiles, defaultProfile)
+}
+
+// resolveSecrets replaces secret references in the run's credentials with
+// their values. Inline server credentials are moved to server.Credentials so
+// the resolved copies are used; saved data is never modified.
+func resolveSecrets(servers []cisco.Server, creds *cisco.Credentials) ([]cisco.Server, *cisco.Credentials, error) {
+	resolver := secret.NewResolver()
+
+	globalCreds, err := resolver.Credentials(creds)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resolved := make([]cisco.Server, len(servers))
+	for i, srv := range servers {
+		serverCreds := srv.Credentials
+		if serverCreds == nil && srv.Username != "" && srv.Password != "" {
+			serverCreds = &cisco.Credentials{
+				User:           srv.Username,
+				Password:       srv.Password,
+				EnablePassword: srv.EnablePassword,
+			}
+		}
+		if serverCreds != nil {
+			if srv.Credentials, err = resolver.Credentials(serverCreds); err != nil {
+				return nil, nil, fmt.Errorf("%s: %w", srv.Hostname, err)
+			}
+		}
+		resolved[i] = srv
+	}
+
+	return resolved, globalCreds, nil
+}
+
+// allHaveCredentials reports whether every server can log in without the run's global credentials
+func allHaveCredentials(servers []cisco.Server) bool {
+	for _, s := range servers {
+		if s.Credentials == nil && (s.Username == "" || s.Password == "") {
+			return false
+		}
+	}
+	return true
+}
+
+// ExportServersToCSV exports the server list with all inventory attributes
+// to a CSV or Excel file. Attributes the UI doesn't hold are taken from the
+// saved inventory. Credentials are not exported.
+func (a *App) ExportServersToCSV(servers []map[string]string) bool {
+	inv, err := inventory.Load()
+	if err != nil {
+		inv = &inventory.Inventory{}
+	}
+
+	serverList := make([]cisco.Server, 0, len(servers))
+	for _, s := range servers {
+		srv, ok := mapToServer(s)
+		if !ok {
+			continue
+		}
+		if saved := inv.FindServer(srv.IP); saved != nil {
+			keepInventoryAttrs(&srv, saved, s)
+		}
+		serverList = append(serverList, srv)
+	}
+
+	return a.exportServers(serverList)
+}
+
+// exportServers asks for a destination file and writes the servers to it
+func (a *App) exportServers(servers []cisco.Server) bool {
+	file, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Servers",
+		DefaultFilename: "servers.csv",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV Files (*.csv)", Pattern: "*.csv"},
+			{DisplayName: "Excel Files (*.xlsx)", Pattern: "*.xlsx"},
+		},
+	})
+	if err != nil || file == "" {
+		return false
+	}
+
+	if err := inventory.Export(servers, file); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to export servers: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// ImportCommandsFromTxt opens file dialog and returns commands as text
+func (a *App) ImportCommandsFromTxt() string {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Commands from TXT",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Text Files (*.txt)", Pattern: "*.txt"},
+			{DisplayName: "All Files (*.*)", Pattern: "*.*"},
+		},
+	})
+	if err != nil || file == "" {
+		return ""
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to read file: "+err.Error())
+		return ""
+	}
+	return string(data)
+}
+
+// ExportCommandsToTxt saves commands to a TXT file
+func (a *App) ExportCommandsToTxt(commands string) bool {
+	file, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Commands to TXT",
+		DefaultFilename: "commands.txt",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Text Files (*.txt)", Pattern: "*.txt"},
+		},
+	})
+	if err != nil || file == "" {
+		return false
+	}
+	return os.WriteFile(file, []byte(commands), 0600) == nil
+}
+
+// ImportServersFromCSV opens a file dialog and returns servers parsed from a
+// CSV or Excel file. Columns are detected from the header row.
+func (a *App) ImportServersFromCSV() []map[string]string {
+	file := a.openInventoryFileDialog()
+	if file == "" {
+		return nil
+	}
+
+	rows, err := inventory.ReadTable(file)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load file: "+err.Error())
+		return nil
+	}
+
+	parsed := inventory.ParseRows(rows, nil)
+	if len(parsed.Errors) > 0 || len(parsed.DuplicateHostnames) > 0 {
+		runtime.EventsEmit(a.ctx, "warning", fmt.Sprintf("Import: %d row(s) skipped, %d duplicate hostname(s)", len(parsed.Errors), len(parsed.DuplicateHostnames)))
+	}
+
+	result := make([]map[string]string, len(parsed.Servers))
+	for i, s := range parsed.Servers {
+		result[i] = serverToMap(s)
+	}
+	return result
+}
+
+// PreviewServerImport opens a file dialog and parses the selected CSV or
+// Excel file without saving anything. The returned detected column mapping
+// can be adjusted and passed to ApplyServerImport.
+func (a *App) PreviewServerImport() map[string]interface{} {
+	file := a.openInventoryFileDialog()
+	if file == "" {
+		return nil
+	}
+	return a.ParseServerImport(file, nil)
+}
+
+// ParseServerImport parses an import file with the given column mapping
+// (header -> field). An empty mapping is detected from the header row.
+func (a *App) ParseServerImport(path string, mapping map[string]string) map[string]interface{} {
+	rows, err := inventory.ReadTable(path)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load file: "+err.Error())
+		return nil
+	}
+
+	parsed := inventory.ParseRows(rows, mapping)
+	servers := make([]map[string]string, len(parsed.Servers))
+	for i, s := range parsed.Servers {
+		servers[i] = serverToMap(s)
+	}
+
+	return map[string]interface{}{
+		"path":               path,
+		"fields":             inventory.Fields,
+		"headers":            parsed.Headers,
+		"mapping":            parsed.Mapping,
+		"servers":            servers,
+		"errors":             parsed.Errors,
+		"duplicateIPs":       parsed.DuplicateIPs,
+		"duplicateHostnames": parsed.DuplicateHostnames,
+	}
+}
+
+// ApplyServerImport imports a file into the saved inventory.
+// mode is "merge" (update by IP, add new) or "replace".
+func (a *App) ApplyServerImport(path string, mapping map[string]string, mode string) map[string]interface{} {
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	rows, err := inventory.ReadTable(path)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load file: "+err.Error())
+		return nil
+	}
+	parsed := inventory.ParseRows(rows, mapping)
+
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+		return nil
+	}
+
+	added, updated, err := inv.Apply(parsed.Servers, mode)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Import failed: "+err.Error())
+		return nil
+	}
+
+	if err := inventory.Save(inv); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save inventory: "+err.Error())
+		return nil
+	}
+
+	return map[string]interface{}{
+		"added":              added,
+		"updated":            updated,
+		"total":              len(inv.Servers),
+		"errors":             parsed.Errors,
+		"duplicateIPs":       parsed.DuplicateIPs,
+		"duplicateHostnames": parsed.DuplicateHostnames,
+	}
+}
+
+// ExportInventory exports the saved inventory to a CSV or Excel file
+func (a *App) ExportInventory() bool {
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+		return false
+	}
+	return a.exportServers(inv.Servers)
+}
+
+// ImportAnsibleInventory imports hosts and groups from an Ansible INI or YAML
+// inventory into the saved inventory. mode is "merge" or "replace".
+func (a *App) ImportAnsibleInventory(mode string) map[string]interface{} {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Ansible Inventory",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Ansible Inventory (*.ini, *.yml, *.yaml)", Pattern: "*.ini;*.yml;*.yaml;hosts"},
+			{DisplayName: "All Files (*.*)", Pattern: "*.*"},
+		},
+	})
+	if err != nil || file == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to read file: "+err.Error())
+		return nil
+	}
+
+	parsed, groups, err := inventory.ReadAnsible(file, data)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to parse Ansible inventory: "+err.Error())
+		return nil
+	}
+	if len(parsed.Errors) > 0 || len(parsed.DuplicateHostnames) > 0 {
+		runtime.EventsEmit(a.ctx, "warning", fmt.Sprintf("Import: %d host(s) skipped, %d duplicate hostname(s)", len(parsed.Errors), len(parsed.DuplicateHostnames)))
+	}
+
+	// Locked only after the dialog, so NetBox sync and other saves don't wait on the user
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+		return nil
+	}
+
+	added, updated, err := inv.Apply(parsed.Servers, mode)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Import failed: "+err.Error())
+		return nil
+	}
+	for _, g := range groups {
+		if inv.FindGroup(g) == nil {
+			inv.SetGroup(inventory.Group{Name: g})
+		}
+	}
+
+	if err := inventory.Save(inv); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save inventory: "+err.Error())
+		return nil
+	}
+
+	return map[string]interface{}{
+		"added":   added,
+		"updated": updated,
+		"groups":  len(groups),
+		"skipped": parsed.Errors,
+		"total":   len(inv.Servers),
+	}
+}
+
+// ExportAnsibleInventory exports the saved inventory as an Ansible INI or
+// YAML inventory (chosen by file extension). Passwords are not exported.
+func (a *App) ExportAnsibleInventory() bool {
+	inv, err := inventory.Load()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+		return false
+	}
+
+	file, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Ansible Inventory",
+		DefaultFilename: "inventory.yml",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "YAML Inventory (*.yml)", Pattern: "*.yml;*.yaml"},
+			{DisplayName: "INI Inventory (*.ini)", Pattern: "*.ini"},
+		},
+	})
+	if err != nil || file == "" {
+		return false
+	}
+
+	data, renamed, err := inventory.WriteAnsible(file, inv.Servers)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to export Ansible inventory: "+err.Error())
+		return false
+	}
+	if len(renamed) > 0 {
+		names := make([]string, 0, len(renamed))
+		for old, name := range renamed {
+			names = append(names, old+" -> "+name)
+		}
+		sort.Strings(names)
+		runtime.EventsEmit(a.ctx, "warning", "Export: group names changed for Ansible: "+strings.Join(names, ", "))
+	}
+	return os.WriteFile(file, data, 0600) == nil
+}
+
+// openInventoryFileDialog asks for a CSV or Excel inventory file
+func (a *App) openInventoryFileDialog() string {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Servers",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "CSV / Excel Files (*.csv, *.xlsx)", Pattern: "*.csv;*.xlsx"},
+			{DisplayName: "All Files (*.*)", Pattern: "*.*"},
+		},
+	})
+	if err != nil {
+		return ""
+	}
+	return file
+}
+
+// StartExecution starts a manual run of the loaded servers and commands, or
+// queues it if every SSH session is in use. Returns the run's "runId" and
+// "logDir", or the "queueId" and "position" of the queued run; nil if it
+// cannot start.
+func (a *App) StartExecution(username, password string, timeout int, enableMode, disablePaging, autoExportExcel bool, enablePassword string, scheduleName string) map[string]interface{} {
+	a.mu.Lock()
+	if len(a.servers) == 0 {
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "error", "No servers loaded")
+		return nil
+	}
+	if len(a.commands) == 0 {
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "error", "No commands loaded")
+		return nil
+	}
+
+	item := queueItem{
+		id:                uuid.New().String(),
+		priority:          a.priority,
+		servers:           append([]cisco.Server{}, a.servers...),
+		commands:          append([]string{}, a.commands...),
+		username:          username,
+		password:          password,
+		timeout:           timeout,
+		enableMode:        enableMode,
+		disablePaging:     disablePaging,
+		autoExportExcel:   autoExportExcel,
+		enablePassword:    enablePassword,
+		scheduleName:      scheduleName,
+		credentialProfile: a.credentialProfile,
+		isManual:          true,
+		queuedAt:          time.Now(),
+	}
+
+	run := a.admit(item)
+	if run == nil {
+		// Queue the manual execution
+		pos := a.enqueue(item) + 1
+		a.armQueueExpiry()
+		a.mu.Unlock()
+		a.saveQueue()
+		a.emitQueueStatus(&item, queueQueued, map[string]interface{}{"position": pos})
+		runtime.EventsEmit(a.ctx, "scheduleQueued", map[string]interface{}{
+			"queueId":  item.id,
+			"taskName": scheduleName,
+			"position": pos,
+			"isManual": true,
+		})
+		go a.processQueue()
+		return map[string]interface{}{"queueId": item.id, "position": pos}
+	}
+	a.mu.Unlock()
+
+	if !a.startRun(run, audit.TriggerManual) {
+		return nil
+	}
+	return map[string]interface{}{"runId": run.id, "logDir": run.runner.LogDir}
+}
+
+// admit reserves an SSH session for item and returns its run, or nil if
+// every session is in use or item's schedule is already running (must be
+// called with lock held)
+func (a *App) admit(item queueItem) *execution {
+	if a.sessionsInUse() >= a.sessionCap() {
+		return nil
+	}
+	if item.task != nil && a.taskRunning(item.task.ID) {
+		return nil
+	}
+
+	run := &execution{id: uuid.New().String(), item: item}
+	a.runs[run.id] = run
+	a.runOrder = append(a.runOrder, run.id)
+	return run
+}
+
+// sessionCap returns the maximum number of concurrent SSH sessions (must be
+// called with lock held)
+func (a *App) sessionCap() int {
+	if a.maxSessions > 0 {
+		return a.maxSessions
+	}
+	return config.DefaultMaxSessions
+}
+
+// sessionsInUse returns the number of SSH sessions held by runs that have
+// not ended. A runner connects to one server at a time, so each run holds
+// one session (must be called with lock held).
+func (a *App) sessionsInUse() int {
+	n := 0
+	for _, run := range a.runs {
+		if !run.done {
+			n++
+		}
+	}
+	return n
+}
+
+// taskRunning reports whether a run of the scheduled task has not ended
+// (must be called with lock held)
+func (a *App) taskRunning(id string) bool {
+	for _, run := range a.runs {
+		if !run.done && run.item.task != nil && run.item.task.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// forgetRun removes a run from the run list (must be called with lock held)
+func (a *App) forgetRun(id string) {
+	delete(a.runs, id)
+	for i, runID := range a.runOrder {
+		if runID == id {
+			a.runOrder = append(a.runOrder[:i], a.runOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+// trimRuns drops the oldest ended runs beyond maxFinishedRuns (must be
+// called with lock held)
+func (a *App) trimRuns() {
+	ended := 0
+	for _, id := range a.runOrder {
+		if a.runs[id].done {
+			ended++
+		}
+	}
+	for i := 0; ended > maxFinishedRuns && i < len(a.runOrder); {
+		id := a.runOrder[i]
+		if !a.runs[id].done {
+			i++
+			continue
+		}
+		a.forgetRun(id)
+		ended--
+	}
+}
+
+// uniqueLogDir returns dir, or dir with a numeric suffix if it exists or
+// another run uses it, e.g. two manual runs started in the same second (must
+// be called with lock held)
+func (a *App) uniqueLogDir(dir string) string {
+	used := func(d string) bool {
+		for _, run := range a.runs {
+			if run.runner != nil && run.runner.LogDir == d {
+				return true
+			}
+		}
+		_, err := os.Stat(d)
+		return err == nil
+	}
+
+	candidate := dir
+	for i := 2; used(candidate); i++ {
+		candidate = fmt.Sprintf("%s_%d", dir, i)
+	}
+	return candidate
+}
+
+// getRun returns the run with the given ID, or nil
+func (a *App) getRun(id string) *execution {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.runs[id]
+}
+
+// startRun sets up and starts a run reserved by admit. If it cannot start,
+// the error is reported, its session is released and false is returned.
+func (a *App) startRun(run *execution, trigger string) bool {
+	item := &run.item
+	abort := func(msg string) bool {
+		runtime.EventsEmit(a.ctx, "error", msg)
+		a.mu.Lock()
+		a.forgetRun(run.id)
+		a.mu.Unlock()
+		go a.processQueue()
+		return false
+	}
+
+	if len(item.servers) == 0 {
+		return abort("No servers loaded")
+	}
+
+	if len(item.commands) == 0 {
+		return abort("No commands loaded")
+	}
+
+	servers, err := resolveCredentials(item.servers, item.credentialProfile)
+	if err != nil {
+		return abort("Failed to resolve credentials: " + err.Error())
+	}
+
+	if (item.username == "" || item.password == "") && !allHaveCredentials(servers) {
+		return abort("Username and password are required")
+	}
+
+	timeout := item.timeout
+	if timeout <= 0 {
+		timeout = 1
+	}
+
+	creds := &cisco.Credentials{
+		User:           item.username,
+		Password:       item.password,
+		EnablePassword: item.enablePassword,
+	}
+
+	// Resolve secret references (env:, file:, exec:, vault:) for this run only
+	servers, creds, err = resolveSecrets(servers, creds)
+	if err != nil {
+		a.requestExecApproval(err, item.scheduleName)
+		return abort(err.Error())
+	}
+
+	// Later runs of a chain log below its first run
+	chain := item.chain
+	logsDir := datadir.LogsDir()
+	a.mu.Lock()
+	if chain != nil && chain.logDir != "" {
+		logsDir = chain.logDir
+	}
+	a.mu.Unlock()
+	runner := cisco.NewRunner(servers, item.commands, creds, timeout, item.enableMode, item.disablePaging, logsDir, item.scheduleName)
+
+	// Redact secrets in saved logs and Excel if configured
+	if err := configureRedaction(runner, item.task); err != nil {
+		return abort("Invalid redaction settings: " + err.Error())
+	}
+
+	// Encrypt saved logs if configured
+	if err := configureLogStorage(runner); err != nil {
+		return abort("Failed to set up encrypted logs: " + err.Error())
+	}
+
+	runner.OnProgress = func(current, total int, server cisco.Server, status string) {
+		runtime.EventsEmit(a.ctx, "progress", map[string]interface{}{
+			"runId":    run.id,
+			"current":  current,
+			"total":    total,
+			"hostname": server.Hostname,
+			"ip":       server.IP,
+			"status":   status,
+		})
+	}
+
+	runner.OnLog = func(serverIP, hostname, line string) {
+		runtime.EventsEmit(a.ctx, "log", map[string]interface{}{
+			"runId":    run.id,
+			"serverIP": serverIP,
+			"hostname": hostname,
+			"line":     line,
+		})
+	}
+
+	runner.OnResult = func(result cisco.ExecutionResult) {
+		logPath := strings.ReplaceAll(result.LogPath, "\\", "/")
+		runtime.EventsEmit(a.ctx, "result", map[string]interface{}{
+			"runId":    run.id,
+			"hostname": result.Server.Hostname,
+			"ip":       result.Server.IP,
+			"success":  result.Success,
+			"error":    result.Error,
+			"logPath":  logPath,
+			"duration": result.Duration,
+		})
+
+		success, fail, total := runner.GetSummary()
+		if success+fail == total {
+			a.completeRun(run, success, fail, total)
+		}
+	}
+
+	runner.OnDone = func() {
+		a.endRun(run)
+	}
+
+	a.mu.Lock()
+	runner.LogDir = a.uniqueLogDir(runner.LogDir)
+	run.runner = runner
+	run.trigger = trigger
+	run.started = time.Now()
+	a.mu.Unlock()
+
+	if err := runner.Start(); err != nil {
+		return abort("Failed to start: " + err.Error())
+	}
+
+	a.mu.Lock()
+	if chain != nil && chain.logDir == "" {
+		chain.logDir = runner.LogDir
+	}
+	a.mu.Unlock()
+	// Remember the run so it can be restored if the app closes before it ends
+	go a.saveQueue()
+
+	details := runAuditDetails(runner, trigger, item.scheduleName, item.credentialProfile)
+	details["run"] = run.id
+	if chain != nil {
+		details["chain"] = chain.id
+	}
+	a.recordAudit(audit.RunStart, details)
+
+	runtime.EventsEmit(a.ctx, "runStarted", a.runInfo(run))
+	return true
+}
+
+// completeRun records a run that finished every server, sends its email and
+// runs the schedules that run after it
+func (a *App) completeRun(run *execution, success, fail, total int) {
+	item := &run.item
+	logDir := run.runner.LogDir
+	a.mu.Lock()
+	run.completed = true
+	a.mu.Unlock()
+
+	details := map[string]interface{}{
+		"run":     run.id,
+		"logDir":  logDir,
+		"success": success,
+		"fail":    fail,
+		"total":   total,
+	}
+	if item.chain != nil {
+		details["chain"] = item.chain.id
+	}
+	a.recordAudit(audit.RunComplete, details)
+
+	runtime.EventsEmit(a.ctx, "completed", map[string]interface{}{
+		"runId":           run.id,
+		"success":         success,
+		"fail":            fail,
+		"total":           total,
+		"logDir":          logDir,
+		"autoExportExcel": item.autoExportExcel,
+	})
+
+	// Send email if configured
+	if task := item.task; task != nil && task.EmailEnabled && task.EmailTo != "" {
+		go a.sendScheduleResultEmail(task, run, success, fail, total)
+	}
+
+	// Queue the schedules that run after this one before the queue moves on
+	if item.chain != nil && item.task != nil {
+		a.chainStepDone(item.chain, item.task, logDir, success, fail, total, false)
+	}
+}
+
+// endRun releases the session of a run that has ended, completed or
+// stopped, and starts queued runs. A chain step stopped before it completed
+// counts as failed, so the schedules that run after a failure still run.
+func (a *App) endRun(run *execution) {
+	a.mu.Lock()
+	run.done = true
+	stopped := run.stopped
+	completed := run.completed
+	a.trimRuns()
+	a.mu.Unlock()
+	a.saveQueue()
+
+	if item := &run.item; !completed && item.chain != nil && item.task != nil {
+		success, fail, total := run.runner.GetSummary()
+		a.chainStepDone(item.chain, item.task, run.runner.LogDir, success, fail, total, true)
+	}
+
+	runtime.EventsEmit(a.ctx, "runEnded", map[string]interface{}{
+		"runId":   run.id,
+		"stopped": stopped,
+	})
+
+	// Process next item in queue
+	go a.processQueue()
+}
+
+// runInfo describes a run for the frontend
+func (a *App) runInfo(run *execution) map[string]interface{} {
+	a.mu.Lock()
+	info := map[string]interface{}{
+		"runId":        run.id,
+		"scheduleName": run.item.scheduleName,
+		"isManual":     run.item.isManual,
+		"trigger":      run.trigger,
+		"running":      !run.done,
+		"stopped":      run.stopped,
+		"started":      run.started.Format(time.RFC3339),
+	}
+	if run.item.task != nil {
+		info["taskId"] = run.item.task.ID
+	}
+	runner := run.runner
+	a.mu.Unlock()
+
+	success, fail, total := runner.GetSummary()
+	info["success"] = success
+	info["fail"] = fail
+	info["total"] = total
+	info["logDir"] = runner.LogDir
+	return info
+}
+
+// GetRuns returns the runs in progress and the recently ended runs, in
+// start order
+func (a *App) GetRuns() []map[string]interface{} {
+	a.mu.Lock()
+	var runs []*execution
+	for _, id := range a.runOrder {
+		if run := a.runs[id]; run.runner != nil {
+			runs = append(runs, run)
+		}
+	}
+	a.mu.Unlock()
+
+	result := make([]map[string]interface{}, len(runs))
+	for i, run := range runs {
+		result[i] = a.runInfo(run)
+	}
+	return result
+}
+
+// GetRunResults returns the results of a run so far
+func (a *App) GetRunResults(runID string) []map[string]interface{} {
+	run := a.getRun(runID)
+	if run == nil || run.runner == nil {
+		return []map[string]interface{}{}
+	}
+
+	results := run.runner.GetResults()
+	out := make([]map[string]interface{}, len(results))
+	for i, result := range results {
+		out[i] = map[string]interface{}{
+			"hostname": result.Server.Hostname,
+			"ip":       result.Server.IP,
+			"success":  result.Success,
+			"error":    result.Error,
+			"logPath":  strings.ReplaceAll(result.LogPath, "\\", "/"),
+			"duration": result.Duration,
+		}
+	}
+	return out
+}
+
+// chainStepDone records a completed or stopped run of a chain and runs the
+// schedules whose dependencies are now met as part of the same chain. A
+// stopped run is a failed one.
+func (a *App) chainStepDone(chain *chainRun, task *scheduler.ScheduledTask, logDir string, success, fail, total int, stopped bool) {
+	a.mu.Lock()
+	chain.steps = append(chain.steps, map[string]interface{}{
+		"schedule": task.Name,
+		"logDir":   logDir,
+		"success":  success,
+		"fail":     fail,
+		"total":    total,
+		"stopped":  stopped,
+	})
+	a.mu.Unlock()
+
+	due := a.scheduler.Completed(task.ID, fail == 0 && !stopped, time.Now())
+	if len(due) > 0 {
+		a.saveRunStates()
+	}
+	for _, next := range due {
+		if !a.blackedOut(next, audit.TriggerChain) {
+			a.executeTask(next, audit.TriggerChain, chain)
+		}
+	}
+
+	a.endChainStep(chain)
+}
+
+// endChainStep marks a run of a chain as finished, or as not started. When
+// the last run of a chain with several runs ends, the chain is recorded as
+// one logical run.
+func (a *App) endChainStep(chain *chainRun) {
+	a.mu.Lock()
+	chain.pending--
+	done := chain.pending == 0
+	steps := chain.steps
+	a.mu.Unlock()
+
+	if !done || len(steps) < 2 {
+		return
+	}
+
+	ok := true
+	for _, s := range steps {
+		if s["fail"].(int) > 0 || s["stopped"].(bool) {
+			ok = false
+		}
+	}
+	a.recordAudit(audit.ChainComplete, map[string]interface{}{
+		"chain":    chain.id,
+		"schedule": chain.root,
+		"started":  chain.started.Format(time.RFC3339),
+		"logDir":   chain.logDir,
+		"steps":    steps,
+		"success":  ok,
+	})
+	runtime.EventsEmit(a.ctx, "chainCompleted", map[string]interface{}{
+		"schedule": chain.root,
+		"runs":     len(steps),
+		"success":  ok,
+	})
+}
+
+// processQueue starts queued runs while SSH sessions are free. A run whose
+// schedule is already running waits without holding up the runs behind it.
+func (a *App) processQueue() {
+	a.expireQueue()
+
+	for {
+		a.mu.Lock()
+		var run *execution
+		for i := range a.queue {
+			if run = a.admit(a.queue[i]); run != nil {
+				a.queue = append(a.queue[:i], a.queue[i+1:]...)
+				break
+			}
+			if a.sessionsInUse() >= a.sessionCap() {
+				break
+			}
+		}
+		if run == nil {
+			a.mu.Unlock()
+			return
+		}
+		a.armQueueExpiry()
+		remaining := len(a.queue)
+		a.mu.Unlock()
+
+		item := &run.item
+		if item.task != nil {
+			runtime.EventsEmit(a.ctx, "scheduleStarted", map[string]interface{}{
+				"taskId":   item.task.ID,
+				"taskName": item.task.Name,
+				"runId":    run.id,
+			})
+		}
+
+		runtime.EventsEmit(a.ctx, "queueProcessing", map[string]interface{}{
+			"queueId":      item.id,
+			"runId":        run.id,
+			"scheduleName": item.scheduleName,
+			"remaining":    remaining,
+		})
+
+		if !a.startRun(run, audit.TriggerQueue) {
+			a.saveQueue()
+			a.emitQueueStatus(item, queueFailed, nil)
+			if item.chain != nil {
+				a.endChainStep(item.chain)
+			}
+			continue
+		}
+		a.emitQueueStatus(item, queueStarted, map[string]interface{}{"runId": run.id})
+	}
+}
+
+// GetQueue returns the current queue status
+func (a *App) GetQueue() []map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	result := make([]map[string]interface{}, len(a.queue))
+	for i, item := range a.queue {
+		entry := map[string]interface{}{
+			"id":           item.id,
+			"position":     i + 1,
+			"priority":     queuePriority(item.priority),
+			"scheduleName": item.scheduleName,
+			"isManual":     item.isManual,
+			"serverCount":  len(item.servers),
+			"commandCount": len(item.commands),
+		}
+		if item.task != nil {
+			entry["taskId"] = item.task.ID
+		}
+		if !item.queuedAt.IsZero() {
+			entry["queuedAt"] = item.queuedAt.Format(time.RFC3339)
+		}
+		if at := item.expiry(); !at.IsZero() {
+			entry["expires"] = at.Format(time.RFC3339)
+		}
+		result[i] = entry
+	}
+	return result
+}
+
+// ClearQueue removes all pending items from the queue
+func (a *App) ClearQueue() {
+	a.mu.Lock()
+	cleared := a.queue
+	a.queue = nil
+	a.armQueueExpiry()
+	a.mu.Unlock()
+	a.saveQueue()
+	a.cancelQueued(cleared)
+}
+
+// CancelQueueItem removes one queued run
+func (a *App) CancelQueueItem(id string) bool {
+	a.mu.Lock()
+	i := a.queueIndex(id)
+	if i < 0 {
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "error", "Queued run not found; it may have started already")
+		return false
+	}
+	item := a.queue[i]
+	a.queue = append(a.queue[:i], a.queue[i+1:]...)
+	a.armQueueExpiry()
+	a.mu.Unlock()
+	a.saveQueue()
+	a.cancelQueued([]queueItem{item})
+	return true
+}
+
+// cancelQueued reports removed queued runs as cancelled and ends their
+// chain steps
+func (a *App) cancelQueued(items []queueItem) {
+	for i := range items {
+		a.emitQueueStatus(&items[i], queueCancelled, nil)
+		if items[i].chain != nil {
+			a.endChainStep(items[i].chain)
+		}
+	}
+}
+
+// MoveQueueItem moves a queued run by offset places: negative moves it
+// toward the front of the queue. Its priority is not changed, so runs queued
+// later are still placed by priority.
+func (a *App) MoveQueueItem(id string, offset int) bool {
+	a.mu.Lock()
+	i := a.queueIndex(id)
+	if i < 0 {
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "error", "Queued run not found; it may have started already")
+		return false
+	}
+	j := max(0, min(len(a.queue)-1, i+offset))
+	item := a.queue[i]
+	if j < i {
+		copy(a.queue[j+1:i+1], a.queue[j:i])
+	} else {
+		copy(a.queue[i:j], a.queue[i+1:j+1])
+	}
+	a.queue[j] = item
+	a.mu.Unlock()
+
+	if j != i {
+		a.saveQueue()
+		a.emitQueueStatus(&item, queueMoved, map[string]interface{}{"position": j + 1})
+	}
+	return true
+}
+
+// SetQueuePriority changes the priority of a queued run and places it behind
+// the queued runs of the same or a higher priority
+func (a *App) SetQueuePriority(id, priority string) bool {
+	if !scheduler.ValidPriority(priority) {
+		runtime.EventsEmit(a.ctx, "error", "Unknown queue priority: "+priority)
+		return false
+	}
+
+	a.mu.Lock()
+	i := a.queueIndex(id)
+	if i < 0 {
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "error", "Queued run not found; it may have started already")
+		return false
+	}
+	item := a.queue[i]
+	item.priority = priority
+	a.queue = append(a.queue[:i], a.queue[i+1:]...)
+	pos := a.enqueue(item) + 1
+	a.mu.Unlock()
+
+	a.saveQueue()
+	a.emitQueueStatus(&item, queueMoved, map[string]interface{}{"position": pos})
+	return true
+}
+
+// SetRunPriority sets the queue priority of the next manual run, used if it
+// has to wait for another run
+func (a *App) SetRunPriority(priority string) {
+	if !scheduler.ValidPriority(priority) {
+		priority = ""
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.priority = priority
+}
+
+// StopExecution stops a run. The server in progress finishes first; other
+// runs and the queue are not affected.
+func (a *App) StopExecution(runID string) bool {
+	a.mu.Lock()
+	run := a.runs[runID]
+	if run == nil || run.runner == nil || run.done {
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "error", "Run not found; it may have ended already")
+		return false
+	}
+	run.stopped = true
+	runner := run.runner
+	a.mu.Unlock()
+
+	if runner.IsRunning() {
+		a.recordAudit(audit.RunStop, map[string]interface{}{
+			"run":    runID,
+			"logDir": runner.LogDir,
+		})
+	}
+	runner.Stop()
+	a.saveQueue()
+	return true
+}
+
+// IsRunning returns whether a run is in progress
+func (a *App) IsRunning(runID string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	run := a.runs[runID]
+	return run != nil && !run.done
+}
+
+// OpenLogsFolder opens the log directory of a run in file explorer, or the
+// logs folder if the run is unknown
+func (a *App) OpenLogsFolder(runID string) {
+	logsDir := datadir.LogsDir()
+	if dir := a.GetCurrentLogDir(runID); dir != "" {
+		logsDir = dir
+	}
+
+	os.MkdirAll(logsDir, 0755)
+
+	absPath, err := filepath.Abs(logsDir)
+	if err != nil {
+		absPath = logsDir
+	}
+
+	exec.Command("explorer.exe", absPath).Start()
+}
+
+// GetLogFiles returns list of log files in the logs directory
+func (a *App) GetLogFiles() []map[string]string {
+	logsDir := datadir.LogsDir()
+	var files []map[string]string
+
+	filepath.Walk(logsDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		encrypted := strings.HasSuffix(path, ".log"+appCrypto.EncryptedExt)
+		if filepath.Ext(path) != ".log" && !encrypted {
+			return nil
+		}
+
+		// Unredacted copies are listed next to the redacted logs
+		dir := filepath.Dir(path)
+		unredacted := filepath.Base(dir) == unredactedDir
+		if unredacted {
+			dir = filepath.Dir(dir)
+		}
+
+		normalizedPath := strings.ReplaceAll(path, "\\", "/")
+		file := map[string]string{
+			"name":    info.Name(),
+			"path":    normalizedPath,
+			"date":    filepath.Base(dir),
+			"modTime": info.ModTime().Format("2006-01-02 15:04:05"),
+		}
+		if encrypted {
+			file["encrypted"] = "true"
+		}
+		if unredacted {
+			file["unredacted"] = "true"
+		}
+		files = append(files, file)
+		return nil
+	})
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i]["modTime"] > files[j]["modTime"]
+	})
+
+	return files
+}
+
+// ReadLogFile reads and returns the content of a log file.
+// Encrypted logs (.enc) are decrypted.
+func (a *App) ReadLogFile(path string) string {
+	content, err := appCrypto.ReadFile(path)
+	if err != nil {
+		return "Error reading file: " + err.Error()
+	}
+	return string(content)
+}
+
+// ExportDecryptedCopy saves a decrypted copy of an encrypted log or Excel
+// report to a location chosen by the user. Returns the saved path.
+func (a *App) ExportDecryptedCopy(path string) string {
+	if filepath.Ext(path) != appCrypto.EncryptedExt {
+		runtime.EventsEmit(a.ctx, "error", "Not an encrypted file: "+path)
+		return ""
+	}
+
+	content, err := appCrypto.ReadEncryptedFile(path)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to decrypt file: "+err.Error())
+		return ""
+	}
+
+	name := strings.TrimSuffix(filepath.Base(path), appCrypto.EncryptedExt)
+	file, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Decrypted Copy",
+		DefaultFilename: name,
+	})
+	if err != nil || file == "" {
+		return ""
+	}
+
+	if err := os.WriteFile(file, content, 0600); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save file: "+err.Error())
+		return ""
+	}
+	return file
+}
+
+// GetCurrentLogDir returns the log directory of a run
+func (a *App) GetCurrentLogDir(runID string) string {
+	if run := a.getRun(runID); run != nil && run.runner != nil {
+		return run.runner.LogDir
+	}
+	return ""
+}
+
+// GetCurrentVersion returns the current application version
+func (a *App) GetCurrentVersion() string {
+	return a.updater.GetCurrentVersion()
+}
+
+// CheckForUpdates checks for available updates
+func (a *App) CheckForUpdates() *updater.UpdateInfo {
+	info, err := a.updater.CheckForUpdates()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "updateError", err.Error())
+		return nil
+	}
+	return info
+}
+
+// DownloadAndInstallUpdate downloads and installs the update
+func (a *App) DownloadAndInstallUpdate(downloadURL string) bool {
+	tempFile, err := a.updater.DownloadUpdate(downloadURL, func(downloaded, total int64) {
+		percent := float64(downloaded) / float64(total) * 100
+		runtime.EventsEmit(a.ctx, "updateProgress", map[string]interface{}{
+			"downloaded": downloaded,
+			"total":      total,
+			"percent":    percent,
+		})
+	})
+
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "updateError", "Download failed: "+err.Error())
+		return false
+	}
+
+	if err := a.updater.ApplyUpdate(tempFile); err != nil {
+		runtime.EventsEmit(a.ctx, "updateError", "Install failed: "+err.Error())
+		return false
+	}
+
+	a.recordAudit(audit.UpdateInstall, map[string]interface{}{
+		"fromVersion": a.updater.GetCurrentVersion(),
+		"url":         downloadURL,
+	})
+
+	runtime.EventsEmit(a.ctx, "updateComplete", "Update installed successfully. Please restart the application.")
+	return true
+}
+
+// RestartApp restarts the application
+func (a *App) RestartApp() {
+	exePath, err := os.Executable()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "updateError", "Failed to restart: "+err.Error())
+		return
+	}
+
+	// os.Exit skips shutdown, so save the queue and release the
+	// configuration here, before the new instance looks for them
+	a.shutdown(a.ctx)
+
+	cmd := exec.Command(exePath)
+	cmd.Start()
+	os.Exit(0)
+}
+
+// ExportResults exports the results of a run to an Excel file
+func (a *App) ExportResults(runID string) string {
+	run := a.getRun(runID)
+	if run == nil || run.runner == nil {
+		return ""
+	}
+
+	results := run.runner.GetResults()
+	if len(results) == 0 {
+		return ""
+	}
+
+	outputPath, err := exportExcel(results, run.item.commands, run.runner.LogDir)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to export Excel: "+err.Error())
+		return ""
+	}
+
+	return outputPath
+}
+
+// sendScheduleResultEmail zips the log directory and sends it via email
+func (a *App) sendScheduleResultEmail(task *scheduler.ScheduledTask, run *execution, success, fail, total int) {
+	logDir := run.runner.LogDir
+
+	// Export Excel first if needed
+	if findExcelReport(logDir) == "" {
+		if results := run.runner.GetResults(); len(results) > 0 {
+			exportExcel(results, run.item.commands, logDir)
+		}
+	}
+
+	// Attachments are decrypted, and redacted when the saved logs are not,
+	// in a temporary directory
+	srcDir, zipDir := logDir, logDir
+	redactAttachments := task.RedactEmail && !task.RedactLogs
+	if redactAttachments || hasEncryptedFiles(logDir) {
+		staged, err := a.stageAttachments(run, redactAttachments)
+		if err != nil {
+			runtime.EventsEmit(a.ctx, "error", "Failed to prepare attachments: "+err.Error())
+			return
+		}
+		defer os.RemoveAll(staged)
+		srcDir, zipDir = staged, staged
+	}
+
+	// Create ZIP
+	zipPath := filepath.Join(zipDir, fmt.Sprintf("%s_%s.zip", task.Name, time.Now().Format("2006-01-02_150405")))
+	if err := zipDirectory(srcDir, zipPath); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to create ZIP: "+err.Error())
+		return
+	}
+
+	// Load global SMTP settings
+	cfg, err := emailConfig(task.EmailTo)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", err.Error())
+		os.Remove(zipPath)
+		return
+	}
+
+	// Send email
+	summary := email.Summary{
+		Success: success,
+		Fail:    fail,
+		Total:   total,
+	}
+
+	if err := email.SendResultEmail(cfg, zipPath, task.Name, summary); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to send email: "+err.Error())
+		return
+	}
+
+	runtime.EventsEmit(a.ctx, "info", fmt.Sprintf("Email sent to %s", task.EmailTo))
+
+	// Clean up ZIP file
+	os.Remove(zipPath)
+}
+
+// sendMissedRunEmail notifies the schedule's recipients about missed runs
+func (a *App) sendMissedRunEmail(task *scheduler.ScheduledTask, missed scheduler.MissedRun, action string) {
+	cfg, err := emailConfig(task.EmailTo)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", err.Error())
+		return
+	}
+
+	if err := email.SendMissedRunEmail(cfg, task.Name, missed.Times, missed.Truncated, action); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to send email: "+err.Error())
+		return
+	}
+	runtime.EventsEmit(a.ctx, "info", fmt.Sprintf("Missed run email sent to %s", task.EmailTo))
+}
+
+// emailConfig returns the global SMTP settings for sending to "to"
+func emailConfig(to string) (email.Config, error) {
+	smtpCfg, err := config.LoadSmtp()
+	if err != nil {
+		return email.Config{}, fmt.Errorf("SMTP not configured: %v", err)
+	}
+
+	smtpPassword, err := secret.Resolve(smtpCfg.Password)
+	if err != nil {
+		return email.Config{}, fmt.Errorf("SMTP password: %v", err)
+	}
+
+	return email.Config{
+		SmtpServer: smtpCfg.Server,
+		SmtpPort:   smtpCfg.Port,
+		Username:   smtpCfg.Username,
+		Password:   smtpPassword,
+		To:         to,
+	}, nil
+}
+
+// stageAttachments writes decrypted (and, if redactAttachments is set,
+// redacted) copies of the run's logs and Excel report to a temporary
+// directory for emailing. The caller removes the directory.
+func (a *App) stageAttachments(run *execution, redactAttachments bool) (string, error) {
+	logDir := run.runner.LogDir
+
+	var redactor *redact.Redactor
+	if redactAttachments {
+		cfg, err := config.LoadRedaction()
+		if err != nil {
+			return "", err
+		}
+		if redactor, err = cfg.Redactor(); err != nil {
+			return "", err
+		}
+	}
+
+	staged, err := os.MkdirTemp("", "attachments-")
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		os.RemoveAll(staged)
+		return "", err
+	}
+	for _, e := range entries {
+		name := strings.TrimSuffix(e.Name(), appCrypto.EncryptedExt)
+		if e.IsDir() || filepath.Ext(name) != ".log" {
+			continue
+		}
+		data, err := appCrypto.ReadFile(filepath.Join(logDir, e.Name()))
+		if err != nil {
+			os.RemoveAll(staged)
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(staged, name), []byte(redactor.Redact(string(data))), 0600); err != nil {
+			os.RemoveAll(staged)
+			return "", err
+		}
+	}
+
+	results := run.runner.GetResults()
+	commands := run.item.commands
+
+	excelPath := filepath.Join(staged, "results.xlsx")
+	if redactor != nil && len(results) > 0 {
+		for i := range results {
+			results[i].Output = redactor.Redact(results[i].Output)
+		}
+		if err := cisco.ExportToExcel(results, commands, excelPath); err != nil {
+			os.RemoveAll(staged)
+			return "", err
+		}
+	} else if report := findExcelReport(logDir); report != "" {
+		data, err := appCrypto.ReadFile(report)
+		if err == nil {
+			err = os.WriteFile(excelPath, data, 0600)
+		}
+		if err != nil {
+			os.RemoveAll(staged)
+			return "", err
+		}
+	}
+
+	return staged, nil
+}
+
+// exportExcel writes the results workbook to logDir, encrypted
+// (results.xlsx.enc) if log encryption is enabled. Returns the path written.
+func exportExcel(results []cisco.ExecutionResult, commands []string, logDir string) (string, error) {
+	outputPath := filepath.Join(logDir, "results.xlsx")
+
+	cfg, err := config.LoadLogStorage()
+	if err != nil {
+		return "", err
+	}
+	if !cfg.Encrypt {
+		return outputPath, cisco.ExportToExcel(results, commands, outputPath)
+	}
+
+	data, err := cisco.ExportToExcelBytes(results, commands)
+	if err != nil {
+		return "", err
+	}
+	outputPath += appCrypto.EncryptedExt
+	return outputPath, appCrypto.WriteEncryptedFile(outputPath, data)
+}
+
+// findExcelReport returns the path of the Excel report in logDir
+// (plain or encrypted), or "" if there is none
+func findExcelReport(logDir string) string {
+	for _, name := range []string{"results.xlsx", "results.xlsx" + appCrypto.EncryptedExt} {
+		path := filepath.Join(logDir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// hasEncryptedFiles reports whether logDir directly contains encrypted files
+func hasEncryptedFiles(logDir string) bool {
+	entries, err := os.ReadDir(logDir)
+	if err != nil {
+		return false
+	}
+	for _, e := range entries {
+		if !e.IsDir() && filepath.Ext(e.Name()) == appCrypto.EncryptedExt {
+			return true
+		}
+	}
+	return false
+}
+
+// configureLogStorage makes the runner save encrypted logs (.log.enc)
+// if log encryption is enabled
+func configureLogStorage(runner *cisco.Runner) error {
+	cfg, err := config.LoadLogStorage()
+	if err != nil {
+		return err
+	}
+	if !cfg.Encrypt {
+		return nil
+	}
+
+	// Fail now rather than on every server if the key is unavailable
+	if _, err := appCrypto.FileKey(); err != nil {
+		return err
+	}
+	runner.WriteLog = func(logPath, output string) (string, error) {
+		logPath += appCrypto.EncryptedExt
+		return logPath, appCrypto.WriteEncryptedFile(logPath, []byte(output))
+	}
+	return nil
+}
+
+// configureRedaction sets up secret redaction on the runner from the
+// schedule's settings, or the manual-run settings if task is nil
+func configureRedaction(runner *cisco.Runner, task *scheduler.ScheduledTask) error {
+	cfg, err := config.LoadRedaction()
+	if err != nil {
+		return err
+	}
+
+	redactLogs, keepUnredacted := cfg.RedactManualRuns, cfg.KeepUnredactedManual
+	if task != nil {
+		redactLogs, keepUnredacted = task.RedactLogs, task.KeepUnredacted
+	}
+	if !redactLogs {
+		return nil
+	}
+
+	redactor, err := cfg.Redactor()
+	if err != nil {
+		return err
+	}
+	runner.Redact = redactor.Redact
+	if keepUnredacted {
+		runner.SaveOriginal = saveUnredactedCopy
+	}
+	return nil
+}
+
+// saveUnredactedCopy stores the original output encrypted in the
+// "unredacted" folder next to the log
+func saveUnredactedCopy(logPath, output string) error {
+	dir := filepath.Join(filepath.Dir(logPath), unredactedDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	return appCrypto.WriteEncryptedFile(filepath.Join(dir, filepath.Base(logPath)+appCrypto.EncryptedExt), []byte(output))
+}
+
+// zipDirectory creates a ZIP file from the files of the directory, excluding
+// .zip files. Subdirectories are not included: they hold encrypted
+// unredacted copies, or the logs of later runs of a chain.
+func zipDirectory(srcDir, zipPath string) error {
+	entries, err := os.ReadDir(srcDir)
+	if err != nil {
+		return err
+	}
+
+	zipFile, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer zipFile.Close()
+
+	w := zip.NewWriter(zipFile)
+	defer w.Close()
+
+	for _, e := range entries {
+		// Skip the zip file itself
+		if !e.Type().IsRegular() || filepath.Ext(e.Name()) == ".zip" {
+			continue
+		}
+		if err := addZipFile(w, filepath.Join(srcDir, e.Name()), e.Name()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addZipFile copies the file at path into the ZIP as name
+func addZipFile(w *zip.Writer, path, name string) error {
+	f, err := w.Create(name)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(f, src)
+	return err
+}
+
+// ==================== SMTP Settings ====================
+
+// SaveSmtpSettings saves SMTP configuration (encrypted)
+func (a *App) SaveSmtpSettings(data map[string]interface{}) bool {
+	cfg := &config.SmtpConfig{
+		Port: 587,
+	}
+	if server, ok := data["server"].(string); ok {
+		cfg.Server = server
+	}
+	if port, ok := data["port"].(float64); ok {
+		cfg.Port = int(port)
+	}
+	if username, ok := data["username"].(string); ok {
+		cfg.Username = username
+	}
+	if password, ok := data["password"].(string); ok {
+		cfg.Password = password
+	}
+
+	if err := config.SaveSmtp(cfg); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save SMTP settings: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// LoadSmtpSettings loads SMTP configuration (decrypted) for UI display
+func (a *App) LoadSmtpSettings() map[string]interface{} {
+	cfg, err := config.LoadSmtp()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load SMTP settings: "+err.Error())
+		}
+		return map[string]interface{}{
+			"server":   "",
+			"port":     587,
+			"username": "",
+			"password": "",
+		}
+	}
+	return map[string]interface{}{
+		"server":   cfg.Server,
+		"port":     cfg.Port,
+		"username": cfg.Username,
+		"password": cfg.Password,
+	}
+}
+
+// ==================== NetBox Inventory Source ====================
+
+// LoadNetBoxSettings returns the NetBox source settings for UI display
+func (a *App) LoadNetBoxSettings() map[string]interface{} {
+	cfg, err := config.LoadNetBox()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load NetBox settings: "+err.Error())
+		}
+		cfg = &config.NetBoxConfig{}
+	}
+
+	result := map[string]interface{}{
+		"url":                 cfg.URL,
+		"token":               cfg.Token,
+		"sites":               cfg.Sites,
+		"roles":               cfg.Roles,
+		"tags":                cfg.Tags,
+		"statuses":            cfg.Statuses,
+		"syncIntervalMinutes": cfg.SyncIntervalMinutes,
+	}
+	if cfg.LastSync != nil {
+		result["lastSync"] = cfg.LastSync.Format(time.RFC3339)
+	}
+	return result
+}
+
+// SaveNetBoxSettings saves the NetBox source settings and restarts the re-sync loop
+func (a *App) SaveNetBoxSettings(data map[string]interface{}) bool {
+	cfg := mapToNetBoxConfig(data)
+	if old, err := config.LoadNetBox(); err == nil {
+		cfg.LastSync = old.LastSync
+	}
+
+	if err := config.SaveNetBox(cfg); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save NetBox settings: "+err.Error())
+		return false
+	}
+
+	a.startNetBoxSync()
+	return true
+}
+
+// TestNetBoxConnection fetches devices with the given settings without saving anything
+func (a *App) TestNetBoxConnection(data map[string]interface{}) map[string]interface{} {
+	source, err := netBoxSource(mapToNetBoxConfig(data))
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		}
+	}
+
+	servers, skipped, err := source.Fetch()
+	if err != nil {
+		return map[string]interface{}{
+			"success": false,
+			"error":   err.Error(),
+		}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"count":   len(servers),
+		"skipped": skipped,
+	}
+}
+
+// SyncNetBox pulls devices from NetBox into the saved inventory now
+func (a *App) SyncNetBox() map[string]interface{} {
+	result, err := a.syncNetBox()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "NetBox sync failed: "+err.Error())
+		return nil
+	}
+	return result
+}
+
+// syncNetBox replaces the NetBox-sourced servers in the inventory with the current device set
+func (a *App) syncNetBox() (map[string]interface{}, error) {
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	cfg, err := config.LoadNetBox()
+	if err != nil {
+		return nil, fmt.Errorf("NetBox is not configured: %v", err)
+	}
+
+	source, err := netBoxSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	servers, skipped, err := source.Fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	inv, err := inventory.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	added, updated, removed := inv.SyncSource(inventory.SourceNetBox, servers)
+	if err := inventory.Save(inv); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	cfg.LastSync = &now
+	config.SaveNetBox(cfg)
+
+	result := map[string]interface{}{
+		"added":    added,
+		"updated":  updated,
+		"removed":  removed,
+		"skipped":  skipped,
+		"lastSync": now.Format(time.RFC3339),
+	}
+	runtime.EventsEmit(a.ctx, "inventorySynced", result)
+	return result, nil
+}
+
+// startNetBoxSync (re)starts the periodic NetBox re-sync loop
+func (a *App) startNetBoxSync() {
+	a.stopNetBoxSync()
+
+	if fsutil.ReadOnly() {
+		return
+	}
+	cfg, err := config.LoadNetBox()
+	if err != nil || cfg.URL == "" || cfg.SyncIntervalMinutes <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	a.mu.Lock()
+	a.netBoxStop = stop
+	a.mu.Unlock()
+
+	interval := time.Duration(cfg.SyncIntervalMinutes) * time.Minute
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := a.syncNetBox(); err != nil {
+					runtime.EventsEmit(a.ctx, "error", "NetBox sync failed: "+err.Error())
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// stopNetBoxSync stops the periodic NetBox re-sync loop if running
+func (a *App) stopNetBoxSync() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.netBoxStop != nil {
+		close(a.netBoxStop)
+		a.netBoxStop = nil
+	}
+}
+
+// netBoxSource builds a NetBox client from settings
+func netBoxSource(cfg *config.NetBoxConfig) (*inventory.NetBoxSource, error) {
+	token, err := secret.Resolve(cfg.Token)
+	if err != nil {
+		return nil, fmt.Errorf("NetBox token: %v", err)
+	}
+	return &inventory.NetBoxSource{
+		URL:      cfg.URL,
+		Token:    token,
+		Sites:    cfg.Sites,
+		Roles:    cfg.Roles,
+		Tags:     cfg.Tags,
+		Statuses: cfg.Statuses,
+	}, nil
+}
+
+// mapToNetBoxConfig converts a map from the UI to NetBox settings
+func mapToNetBoxConfig(data map[string]interface{}) *config.NetBoxConfig {
+	cfg := &config.NetBoxConfig{}
+	if u, ok := data["url"].(string); ok {
+		cfg.URL = strings.TrimSpace(u)
+	}
+	if token, ok := data["token"].(string); ok {
+		cfg.Token = strings.TrimSpace(token)
+	}
+	if sites, ok := data["sites"].([]interface{}); ok {
+		cfg.Sites = toStringSlice(sites)
+	}
+	if roles, ok := data["roles"].([]interface{}); ok {
+		cfg.Roles = toStringSlice(roles)
+	}
+	if tags, ok := data["tags"].([]interface{}); ok {
+		cfg.Tags = toStringSlice(tags)
+	}
+	if statuses, ok := data["statuses"].([]interface{}); ok {
+		cfg.Statuses = toStringSlice(statuses)
+	}
+	if interval, ok := data["syncIntervalMinutes"].(float64); ok && interval > 0 {
+		cfg.SyncIntervalMinutes = int(interval)
+	}
+	return cfg
+}
+
+// ==================== Audit Log ====================
+
+// recordAudit appends to the audit log. A failure is reported but never
+// blocks the action being audited.
+func (a *App) recordAudit(typ string, details map[string]interface{}) {
+	if err := audit.Append(typ, details); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to write audit log: "+err.Error())
+	}
+}
+
+// runAuditDetails describes a started run: what was run against which
+// devices, as whom and why. Commands are redacted like the logs.
+func runAuditDetails(runner *cisco.Runner, trigger, scheduleName, credentialProfile string) map[string]interface{} {
+	targets := make([]string, len(runner.Servers))
+	for i, s := range runner.Servers {
+		targets[i] = s.IP
+		if s.Hostname != "" && s.Hostname != s.IP {
+			targets[i] = s.Hostname + " (" + s.IP + ")"
+		}
+	}
+
+	commands := append([]string{}, runner.Commands...)
+	if runner.Redact != nil {
+		for i, c := range commands {
+			commands[i] = runner.Redact(c)
+		}
+	}
+
+	details := map[string]interface{}{
+		"trigger":  trigger,
+		"logDir":   runner.LogDir,
+		"targets":  targets,
+		"commands": commands,
+	}
+	if scheduleName != "" {
+		details["schedule"] = scheduleName
+	}
+	if runner.Credentials != nil && runner.Credentials.User != "" {
+		details["sshUser"] = runner.Credentials.User
+	}
+	if credentialProfile != "" {
+		details["credentialProfile"] = credentialProfile
+	}
+	return details
+}
+
+// scheduleAuditDetails describes a created or updated schedule (without secrets)
+func scheduleAuditDetails(task *scheduler.ScheduledTask) map[string]interface{} {
+	details := map[string]interface{}{
+		"id":       task.ID,
+		"name":     task.Name,
+		"enabled":  task.Enabled,
+		"schedule": task.Describe(),
+		"commands": task.Commands,
+	}
+	if !task.Target.IsEmpty() {
+		details["target"] = task.Target
+	} else {
+		servers := make([]string, len(task.Servers))
+		for i, s := range task.Servers {
+			servers[i] = s.IP
+		}
+		details["servers"] = servers
+	}
+	return details
+}
+
+// scheduleNames returns the names of tasks
+func scheduleNames(tasks []*scheduler.ScheduledTask) []string {
+	names := make([]string, len(tasks))
+	for i, t := range tasks {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// QueryAudit returns audit entries, newest first. filter: type (exact or
+// prefix ending in "."), text, since and until (RFC 3339 or YYYY-MM-DD), limit.
+func (a *App) QueryAudit(filter map[string]interface{}) map[string]interface{} {
+	str := func(key string) string {
+		v, _ := filter[key].(string)
+		return strings.TrimSpace(v)
+	}
+
+	f := audit.Filter{Type: str("type"), Text: str("text")}
+	if v, ok := filter["limit"].(float64); ok {
+		f.Limit = int(v)
+	}
+	var err error
+	if f.Since, err = parseAuditTime(str("since"), false); err != nil {
+		return map[string]interface{}{"success": false, "error": "Invalid start date: " + err.Error()}
+	}
+	if f.Until, err = parseAuditTime(str("until"), true); err != nil {
+		return map[string]interface{}{"success": false, "error": "Invalid end date: " + err.Error()}
+	}
+
+	entries, err := audit.Query(f)
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"entries": entries,
+		"path":    audit.Path(),
+	}
+}
+
+// parseAuditTime parses RFC 3339 or a local date; a date used as an upper
+// bound covers the whole day
+func parseAuditTime(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// VerifyAudit checks the audit log's hash chain
+func (a *App) VerifyAudit() map[string]interface{} {
+	res, err := audit.Verify()
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+	return map[string]interface{}{
+		"success": true,
+		"valid":   res.Valid,
+		"entries": res.Entries,
+		"line":    res.Line,
+		"reason":  res.Error,
+	}
+}
+
+// ==================== Workspaces ====================
 
-	if username == "" || password == "" {
-		runtime.EventsEmit(a.ctx, "error", "Username and password are required")
+// GetWorkspaces returns the data directory, the workspace names and the active workspace
+func (a *App) GetWorkspaces() map[string]interface{} {
+	names, err := datadir.List()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to list workspaces: "+err.Error())
+		names = []string{datadir.Current()}
+	}
+	return map[string]interface{}{
+		"dataDir":    datadir.Root(),
+		"current":    datadir.Current(),
+		"workspaces": names,
+	}
+}
+
+// CreateWorkspace adds an empty workspace
+func (a *App) CreateWorkspace(name string) bool {
+	if err := datadir.Create(strings.TrimSpace(name)); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to create workspace: "+err.Error())
 		return false
 	}
+	return true
+}
 
-	if timeout <= 0 {
-		timeout = 1
+// SwitchWorkspace makes name the active workspace: schedules, inventory,
+// credential profiles, settings and logs are all taken from it. Not allowed
+// while a run is in progress or queued.
+func (a *App) SwitchWorkspace(name string) bool {
+	if name == datadir.Current() {
+		return true
 	}
 
-	// Store autoExportExcel flag for completion event
-	a.autoExportExcel = autoExportExcel
+	a.mu.Lock()
+	busy := len(a.queue) > 0 || a.sessionsInUse() > 0
+	a.mu.Unlock()
+	if busy {
+		runtime.EventsEmit(a.ctx, "error", "Cannot switch workspace while an execution is running or queued")
+		return false
+	}
 
-	creds := &cisco.Credentials{
-		User:           username,
-		Password:       password,
-		EnablePassword: enablePassword,
+	// Runs of the previous workspace are accounted for up to now
+	a.saveRunStates()
+
+	if err := datadir.Switch(name); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to switch workspace: "+err.Error())
+		return false
 	}
 
-	a.runner = cisco.NewRunner(a.servers, a.commands, creds, timeout, enableMode, disablePaging, scheduleName)
+	// Drop everything loaded from the previous workspace
+	a.stopNetBoxSync()
+	a.stopDeferredRuns()
+	a.scheduler.Clear()
+	appCrypto.Relock()
+	a.mu.Lock()
+	a.stateLoaded = false
+	a.queueLoaded = false
+	a.restored = nil
+	a.openedBundle = nil
+	a.runs = make(map[string]*execution)
+	a.runOrder = nil
+	a.credentialProfile = ""
+	a.mu.Unlock()
 
-	a.runner.OnProgress = func(current, total int, server cisco.Server, status string) {
-		runtime.EventsEmit(a.ctx, "progress", map[string]interface{}{
-			"current":  current,
-			"total":    total,
-			"hostname": server.Hostname,
-			"ip":       server.IP,
-			"status":   status,
-		})
+	// Each workspace has its own key; with a master password the frontend
+	// asks to unlock, which loads the saved state
+	if !appCrypto.IsLocked() {
+		a.loadSavedState()
 	}
 
-	a.runner.OnLog = func(serverIP, hostname, line string) {
-		runtime.EventsEmit(a.ctx, "log", map[string]interface{}{
-			"serverIP": serverIP,
-			"hostname": hostname,
-			"line":     line,
-		})
+	runtime.EventsEmit(a.ctx, "workspaceChanged", name)
+	return true
+}
+
+// DeleteWorkspace removes a workspace with all its configuration and logs
+func (a *App) DeleteWorkspace(name string) bool {
+	if err := datadir.Delete(name); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to delete workspace: "+err.Error())
+		return false
 	}
+	return true
+}
 
-	a.runner.OnResult = func(result cisco.ExecutionResult) {
-		logPath := strings.ReplaceAll(result.LogPath, "\\", "/")
-		runtime.EventsEmit(a.ctx, "result", map[string]interface{}{
-			"hostname": result.Server.Hostname,
-			"ip":       result.Server.IP,
-			"success":  result.Success,
-			"error":    result.Error,
-			"logPath":  logPath,
-			"duration": result.Duration,
+// ==================== Master Password ====================
+
+// GetVaultStatus reports whether master-password mode is enabled and locked
+func (a *App) GetVaultStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"enabled": appCrypto.VaultEnabled(),
+		"locked":  appCrypto.IsLocked(),
+	}
+}
+
+// UnlockVault enters the master password, then loads saved schedules so
+// scheduled tasks run for the rest of the session
+func (a *App) UnlockVault(password string) bool {
+	if err := appCrypto.Unlock(password); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to unlock: "+err.Error())
+		return false
+	}
+	a.loadSavedState()
+	return true
+}
+
+// EnableMasterPassword protects the encryption key with a master password.
+// Existing encrypted data stays readable; the plain key file is removed.
+func (a *App) EnableMasterPassword(password string) bool {
+	if err := appCrypto.EnableVault(password); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to enable master password: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// ChangeMasterPassword replaces the master password
+func (a *App) ChangeMasterPassword(oldPassword, newPassword string) bool {
+	if err := appCrypto.ChangeMasterPassword(oldPassword, newPassword); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to change master password: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// DisableMasterPassword goes back to storing the encryption key in config/encryption.key
+func (a *App) DisableMasterPassword(password string) bool {
+	if err := appCrypto.DisableVault(password); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to disable master password: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// RotateEncryptionKey replaces the encryption key and re-encrypts every
+// stored secret with it. All stores are decrypted first, so nothing is
+// changed if any of them cannot be read. In master-password mode the current
+// master password is required.
+func (a *App) RotateEncryptionKey(masterPassword string) bool {
+	err := appCrypto.RotateKey(masterPassword, keyStores()...)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to rotate encryption key: "+err.Error())
+		return false
+	}
+
+	a.recordAudit(audit.KeyRotate, map[string]interface{}{"masterPassword": appCrypto.VaultEnabled()})
+	return true
+}
+
+// keyStores returns every store encrypted with the encryption key
+func keyStores() []appCrypto.Reencoder {
+	return []appCrypto.Reencoder{
+		config.ReencryptSchedules,
+		config.ReencryptSmtp,
+		config.ReencryptNetBox,
+		config.ReencryptSecrets,
+		config.ReencryptQueue,
+		inventory.Reencrypt,
+		credential.Reencrypt,
+		appCrypto.ReencryptFileKey,
+	}
+}
+
+// completeKeyRotation finishes a key rotation the app stopped in the middle
+// of, before anything reads the stores
+func (a *App) completeKeyRotation() {
+	completed, err := appCrypto.CompleteRotation(keyStores()...)
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to complete the interrupted key rotation: "+err.Error())
+		return
+	}
+	if completed {
+		a.recordAudit(audit.KeyRotate, map[string]interface{}{
+			"masterPassword": appCrypto.VaultEnabled(),
+			"resumed":        true,
 		})
+		runtime.EventsEmit(a.ctx, "info", "Completed the encryption key rotation that was interrupted")
+	}
+}
+
+// ==================== Configuration Bundle ====================
+
+// ExportBundle exports the selected configuration into a password-protected
+// bundle file. options: password, schedules, inventory, credentialProfiles, smtp.
+// Returns the saved path.
+func (a *App) ExportBundle(options map[string]interface{}) string {
+	password, _ := options["password"].(string)
+	if len(password) < bundle.MinPasswordLength {
+		runtime.EventsEmit(a.ctx, "error", fmt.Sprintf("Bundle password must be at least %d characters", bundle.MinPasswordLength))
+		return ""
+	}
+	include := func(name string) bool {
+		v, _ := options[name].(bool)
+		return v
+	}
+
+	contents := &bundle.Contents{}
+	if include("schedules") {
+		contents.Schedules = a.scheduler.GetTasks()
+	}
+	if include("inventory") {
+		inv, err := inventory.Load()
+		if err != nil {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load inventory: "+err.Error())
+			return ""
+		}
+		contents.Servers, contents.Groups = inv.Servers, inv.Groups
+	}
+	if include("credentialProfiles") {
+		store, err := credential.Load()
+		if err != nil {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load credential profiles: "+err.Error())
+			return ""
+		}
+		contents.CredentialProfiles = store.Profiles
+	}
+	if include("smtp") {
+		smtpCfg, err := config.LoadSmtp()
+		if err != nil && !os.IsNotExist(err) {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load SMTP settings: "+err.Error())
+			return ""
+		}
+		contents.Smtp = smtpCfg
+	}
+
+	file, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Configuration Bundle",
+		DefaultFilename: "cisco-plink-" + time.Now().Format("2006-01-02") + bundle.Extension,
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Configuration Bundle (*" + bundle.Extension + ")", Pattern: "*" + bundle.Extension},
+		},
+	})
+	if err != nil || file == "" {
+		return ""
+	}
+
+	if err := bundle.Write(file, contents, password); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to export bundle: "+err.Error())
+		return ""
+	}
+	return file
+}
+
+// OpenBundle asks for a bundle file, decrypts it with password and returns
+// its items with conflicts against the local configuration. The bundle is
+// kept in memory until ImportBundle or CloseBundle.
+func (a *App) OpenBundle(password string) map[string]interface{} {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Configuration Bundle",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Configuration Bundle (*" + bundle.Extension + ")", Pattern: "*" + bundle.Extension},
+			{DisplayName: "All Files (*.*)", Pattern: "*.*"},
+		},
+	})
+	if err != nil || file == "" {
+		return nil
+	}
+
+	contents, created, err := bundle.Read(file, password)
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+
+	local, err := a.loadBundleLocal()
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+
+	a.mu.Lock()
+	a.openedBundle = contents
+	a.mu.Unlock()
+
+	return map[string]interface{}{
+		"success": true,
+		"file":    filepath.Base(file),
+		"created": created.Local().Format("2006-01-02 15:04"),
+		"preview": contents.Preview(local),
+	}
+}
+
+// CloseBundle discards the bundle opened by OpenBundle
+func (a *App) CloseBundle() {
+	a.mu.Lock()
+	a.openedBundle = nil
+	a.mu.Unlock()
+}
+
+// ImportBundle imports the selected items of the opened bundle. Secrets are
+// re-encrypted with this machine's key when saved; exec: and file:
+// references are dropped and returned in "stripped" to be re-entered. selection maps each
+// category (schedules, servers, groups, credentialProfiles) to
+// {key: "add"|"replace"|"merge"|"rename"|"skip"}, and smtp to a resolution.
+func (a *App) ImportBundle(selection map[string]interface{}) map[string]interface{} {
+	a.inventoryMu.Lock()
+	defer a.inventoryMu.Unlock()
+
+	a.mu.Lock()
+	contents := a.openedBundle
+	a.mu.Unlock()
+	if contents == nil {
+		return map[string]interface{}{"success": false, "error": "no bundle is open"}
+	}
+
+	local, err := a.loadBundleLocal()
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+
+	sel := mapToBundleSelection(selection)
+	result, err := contents.Apply(local, sel)
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+
+	if err := credential.Save(local.Credentials); err != nil {
+		return map[string]interface{}{"success": false, "error": "Failed to save credential profiles: " + err.Error()}
+	}
+	if err := inventory.Save(local.Inventory); err != nil {
+		return map[string]interface{}{"success": false, "error": "Failed to save inventory: " + err.Error()}
+	}
+	if sel.Smtp != "" && sel.Smtp != bundle.Skip && local.Smtp != nil {
+		if err := config.SaveSmtp(local.Smtp); err != nil {
+			return map[string]interface{}{"success": false, "error": "Failed to save SMTP settings: " + err.Error()}
+		}
+	}
+
+	// Schedules can only be added after the schedules they run after, so
+	// retry the failed ones while others are being added
+	var scheduleErrors []string
+	pending := result.AddSchedules
+	for len(pending) > 0 {
+		var failed []*scheduler.ScheduledTask
+		var errs []string
+		for _, task := range pending {
+			if err := a.scheduler.AddTask(task); err != nil {
+				failed = append(failed, task)
+				errs = append(errs, task.Name+": "+err.Error())
+			}
+		}
+		if len(failed) == len(pending) {
+			scheduleErrors = append(scheduleErrors, errs...)
+			break
+		}
+		pending = failed
+	}
+	for _, task := range result.UpdateSchedules {
+		if err := a.scheduler.UpdateTask(task); err != nil {
+			scheduleErrors = append(scheduleErrors, task.Name+": "+err.Error())
+		}
+	}
+	if len(result.AddSchedules)+len(result.UpdateSchedules) > 0 {
+		a.saveSchedules()
+	}
+
+	a.CloseBundle()
+
+	a.recordAudit(audit.BundleImport, map[string]interface{}{
+		"added":            result.Added,
+		"replaced":         result.Replaced,
+		"merged":           result.Merged,
+		"renamed":          result.Renamed,
+		"stripped":         result.Stripped,
+		"addedSchedules":   scheduleNames(result.AddSchedules),
+		"updatedSchedules": scheduleNames(result.UpdateSchedules),
+	})
+
+	return map[string]interface{}{
+		"success":        true,
+		"added":          result.Added,
+		"replaced":       result.Replaced,
+		"merged":         result.Merged,
+		"renamed":        result.Renamed,
+		"skipped":        result.Skipped,
+		"stripped":       result.Stripped,
+		"scheduleErrors": scheduleErrors,
+	}
+}
+
+// loadBundleLocal loads the local configuration a bundle is merged into
+func (a *App) loadBundleLocal() (*bundle.Local, error) {
+	inv, err := inventory.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inventory: %v", err)
+	}
+	store, err := credential.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load credential profiles: %v", err)
+	}
+	smtpCfg, err := config.LoadSmtp()
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to load SMTP settings: %v", err)
+	}
+
+	return &bundle.Local{
+		Schedules:   a.scheduler.GetTasks(),
+		Inventory:   inv,
+		Credentials: store,
+		Smtp:        smtpCfg,
+	}, nil
+}
+
+// mapToBundleSelection converts the import selection from the UI
+func mapToBundleSelection(data map[string]interface{}) *bundle.Selection {
+	category := func(name string) map[string]bundle.Resolution {
+		result := make(map[string]bundle.Resolution)
+		if items, ok := data[name].(map[string]interface{}); ok {
+			for key, v := range items {
+				if res, ok := v.(string); ok {
+					result[key] = bundle.Resolution(res)
+				}
+			}
+		}
+		return result
+	}
+
+	sel := &bundle.Selection{
+		Schedules:          category("schedules"),
+		Servers:            category("servers"),
+		Groups:             category("groups"),
+		CredentialProfiles: category("credentialProfiles"),
+	}
+	if res, ok := data["smtp"].(string); ok {
+		sel.Smtp = bundle.Resolution(res)
+	}
+	return sel
+}
+
+// ==================== Redaction ====================
+
+// LoadRedactionSettings returns the redaction settings and the built-in rules
+func (a *App) LoadRedactionSettings() map[string]interface{} {
+	cfg, err := config.LoadRedaction()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load redaction settings: "+err.Error())
+		cfg = &config.RedactionConfig{}
+	}
+
+	return map[string]interface{}{
+		"disableBuiltin":       cfg.DisableBuiltin,
+		"rules":                redactionRulesToMaps(cfg.Rules),
+		"builtinRules":         redactionRulesToMaps(redact.Builtin),
+		"redactManualRuns":     cfg.RedactManualRuns,
+		"keepUnredactedManual": cfg.KeepUnredactedManual,
+	}
+}
+
+// SaveRedactionSettings validates and saves the redaction settings
+func (a *App) SaveRedactionSettings(data map[string]interface{}) bool {
+	if err := config.SaveRedaction(mapToRedactionConfig(data)); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save redaction settings: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// PreviewRedaction applies the given (unsaved) settings to sample text
+func (a *App) PreviewRedaction(data map[string]interface{}, sample string) map[string]interface{} {
+	redactor, err := mapToRedactionConfig(data).Redactor()
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+	return map[string]interface{}{"success": true, "text": redactor.Redact(sample)}
+}
+
+// mapToRedactionConfig converts a map from the UI to redaction settings
+func mapToRedactionConfig(data map[string]interface{}) *config.RedactionConfig {
+	cfg := &config.RedactionConfig{Rules: make([]redact.Rule, 0)}
+	if v, ok := data["disableBuiltin"].(bool); ok {
+		cfg.DisableBuiltin = v
+	}
+	if v, ok := data["redactManualRuns"].(bool); ok {
+		cfg.RedactManualRuns = v
+	}
+	if v, ok := data["keepUnredactedManual"].(bool); ok {
+		cfg.KeepUnredactedManual = v
+	}
+	if rules, ok := data["rules"].([]interface{}); ok {
+		for _, r := range rules {
+			ruleMap, ok := r.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			name, _ := ruleMap["name"].(string)
+			pattern, _ := ruleMap["pattern"].(string)
+			if strings.TrimSpace(pattern) == "" {
+				continue
+			}
+			cfg.Rules = append(cfg.Rules, redact.Rule{Name: strings.TrimSpace(name), Pattern: pattern})
+		}
+	}
+	return cfg
+}
 
-		success, fail, total := a.runner.GetSummary()
-		if success+fail == total {
-			logDir := a.runner.LogDir
-
-			runtime.EventsEmit(a.ctx, "completed", map[string]interface{}{
-				"success":         success,
-				"fail":            fail,
-				"total":           total,
-				"logDir":          logDir,
-				"autoExportExcel": a.autoExportExcel,
-			})
+// redactionRulesToMaps converts rules to maps for the UI
+func redactionRulesToMaps(rules []redact.Rule) []map[string]string {
+	result := make([]map[string]string, len(rules))
+	for i, r := range rules {
+		result[i] = map[string]string{"name": r.Name, "pattern": r.Pattern}
+	}
+	return result
+}
 
-			// Send email if configured
-			a.mu.Lock()
-			emailTask := a.pendingEmailTask
-			a.pendingEmailTask = nil
-			a.mu.Unlock()
+// ==================== Log Storage ====================
 
-			if emailTask != nil && emailTask.EmailEnabled && emailTask.EmailTo != "" {
-				go a.sendScheduleResultEmail(emailTask, logDir, success, fail, total)
-			}
+// LoadLogStorageSettings returns the log storage settings
+func (a *App) LoadLogStorageSettings() map[string]interface{} {
+	cfg, err := config.LoadLogStorage()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load log storage settings: "+err.Error())
+		cfg = &config.LogStorageConfig{}
+	}
+	return map[string]interface{}{
+		"encrypt": cfg.Encrypt,
+	}
+}
+
+// SaveLogStorageSettings saves the log storage settings. Enabling
+// encryption creates the file key, so a locked vault is reported here.
+func (a *App) SaveLogStorageSettings(data map[string]interface{}) bool {
+	cfg := &config.LogStorageConfig{}
+	if v, ok := data["encrypt"].(bool); ok {
+		cfg.Encrypt = v
+	}
 
-			// Process next item in queue
-			go a.processQueue()
+	if cfg.Encrypt {
+		if _, err := appCrypto.FileKey(); err != nil {
+			runtime.EventsEmit(a.ctx, "error", "Failed to create log encryption key: "+err.Error())
+			return false
 		}
 	}
 
-	if err := a.runner.Start(); err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to start: "+err.Error())
+	if err := config.SaveLogStorage(cfg); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save log storage settings: "+err.Error())
 		return false
 	}
-
 	return true
 }
 
-// processQueue executes the next item in the queue
-func (a *App) processQueue() {
-	a.mu.Lock()
-	if len(a.queue) == 0 {
-		a.mu.Unlock()
-		return
+// ==================== Execution Settings ====================
+
+// LoadExecutionSettings returns the execution settings
+func (a *App) LoadExecutionSettings() map[string]interface{} {
+	cfg, err := config.LoadExecution()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load execution settings: "+err.Error())
+		cfg = &config.ExecutionConfig{}
+	}
+	return map[string]interface{}{
+		"maxSessions": cfg.Sessions(),
+		"limit":       config.MaxSessionsLimit,
 	}
+}
 
-	item := a.queue[0]
-	a.queue = a.queue[1:]
-	a.servers = item.servers
-	a.commands = item.commands
-	if item.task != nil {
-		a.pendingEmailTask = item.task
+// SaveExecutionSettings saves the execution settings and applies them.
+// Lowering the cap does not stop runs in progress; queued runs wait until
+// enough of them have ended.
+func (a *App) SaveExecutionSettings(data map[string]interface{}) bool {
+	cfg := &config.ExecutionConfig{}
+	if v, ok := data["maxSessions"].(float64); ok {
+		cfg.MaxSessions = int(v)
+	}
+	if cfg.MaxSessions < 1 || cfg.MaxSessions > config.MaxSessionsLimit {
+		runtime.EventsEmit(a.ctx, "error", fmt.Sprintf("Max concurrent sessions must be between 1 and %d", config.MaxSessionsLimit))
+		return false
 	}
-	a.mu.Unlock()
 
-	if item.task != nil {
-		runtime.EventsEmit(a.ctx, "scheduleStarted", map[string]interface{}{
-			"taskId":   item.task.ID,
-			"taskName": item.task.Name,
-		})
+	if err := config.SaveExecution(cfg); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save execution settings: "+err.Error())
+		return false
 	}
 
-	runtime.EventsEmit(a.ctx, "queueProcessing", map[string]interface{}{
-		"scheduleName": item.scheduleName,
-		"remaining":    len(a.queue),
-	})
+	a.mu.Lock()
+	a.maxSessions = cfg.Sessions()
+	a.mu.Unlock()
 
-	a.StartExecution(item.username, item.password, item.timeout, item.enableMode, item.disablePaging, item.autoExportExcel, item.enablePassword, item.scheduleName)
+	// A higher cap may let queued runs start
+	go a.processQueue()
+	return true
 }
 
-// GetQueue returns the current queue status
-func (a *App) GetQueue() []map[string]interface{} {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	result := make([]map[string]interface{}, len(a.queue))
-	for i, item := range a.queue {
-		entry := map[string]interface{}{
-			"position":     i + 1,
-			"scheduleName": item.scheduleName,
-			"isManual":     item.isManual,
-			"serverCount":  len(item.servers),
-			"commandCount": len(item.commands),
-		}
-		if item.task != nil {
-			entry["taskId"] = item.task.ID
-		}
-		result[i] = entry
+// applyExecutionSettings loads the session cap
+func (a *App) applyExecutionSettings() {
+	cfg, err := config.LoadExecution()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load execution settings: "+err.Error())
+		cfg = &config.ExecutionConfig{}
 	}
-	return result
-}
 
-// ClearQueue removes all pending items from the queue
-func (a *App) ClearQueue() {
 	a.mu.Lock()
 	defer a.mu.Unlock()
-	a.queue = nil
+	a.maxSessions = cfg.Sessions()
 }
 
-// StopExecution stops the running execution and clears the queue
-func (a *App) StopExecution() {
-	a.mu.Lock()
-	defer a.mu.Unlock()
+// ==================== Secret Providers ====================
 
-	a.queue = nil
-	if a.runner != nil {
-		a.runner.Stop()
+// LoadSecretSettings returns the secret provider settings for the UI
+func (a *App) LoadSecretSettings() map[string]interface{} {
+	cfg, err := config.LoadSecrets()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load secret provider settings: "+err.Error())
+		}
+		cfg = &config.SecretsConfig{}
+	}
+	return map[string]interface{}{
+		"vaultAddress":     cfg.VaultAddress,
+		"vaultToken":       cfg.VaultToken,
+		"vaultNamespace":   cfg.VaultNamespace,
+		"schemes":          secret.Schemes(),
+		"approvedCommands": len(cfg.ApprovedCommands),
 	}
 }
 
-// IsRunning returns whether execution is in progress
-func (a *App) IsRunning() bool {
-	a.mu.Lock()
-	defer a.mu.Unlock()
-
-	if a.runner == nil {
+// SaveSecretSettings saves the secret provider settings and applies them
+func (a *App) SaveSecretSettings(data map[string]interface{}) bool {
+	cfg := mapToSecretsConfig(data)
+	if saved, err := config.LoadSecrets(); err == nil {
+		cfg.ApprovedCommands = saved.ApprovedCommands
+	}
+	if err := config.SaveSecrets(cfg); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save secret provider settings: "+err.Error())
 		return false
 	}
-	return a.runner.IsRunning()
+	a.applySecretSettings()
+	return true
 }
 
-// OpenLogsFolder opens the logs folder in file explorer
-func (a *App) OpenLogsFolder() {
-	logsDir := "logs"
-	if a.runner != nil && a.runner.LogDir != "" {
-		logsDir = a.runner.LogDir
+// TestSecretReference resolves a reference with the given settings and reports
+// whether it worked. The secret itself is never returned to the UI.
+func (a *App) TestSecretReference(data map[string]interface{}, ref string) map[string]interface{} {
+	ref = strings.TrimSpace(ref)
+	if !secret.IsReference(ref) {
+		return map[string]interface{}{
+			"success": false,
+			"error":   "not a secret reference (expected scheme:reference, e.g. env:NAME)",
+		}
 	}
 
-	os.MkdirAll(logsDir, 0755)
-
-	absPath, err := filepath.Abs(logsDir)
+	// Test vault references against the unsaved settings from the form
+	var value string
+	var err error
+	if scheme, vaultRef, _ := strings.Cut(ref, ":"); strings.EqualFold(scheme, "vault") {
+		value, err = vaultProvider(mapToSecretsConfig(data)).Resolve(vaultRef)
+	} else {
+		value, err = secret.Resolve(ref)
+	}
 	if err != nil {
-		absPath = logsDir
+		result := map[string]interface{}{"success": false, "error": err.Error()}
+		var notApproved *secret.ExecNotApprovedError
+		if errors.As(err, &notApproved) {
+			result["command"] = notApproved.Command
+		}
+		return result
 	}
+	return map[string]interface{}{"success": true, "empty": value == ""}
+}
 
-	exec.Command("explorer.exe", absPath).Start()
+// requestExecApproval asks the frontend to confirm an exec: command that
+// stopped a run because it has not been allowed yet
+func (a *App) requestExecApproval(err error, runName string) {
+	var notApproved *secret.ExecNotApprovedError
+	if errors.As(err, &notApproved) {
+		runtime.EventsEmit(a.ctx, "execApproval", map[string]interface{}{
+			"command": notApproved.Command,
+			"runName": runName,
+		})
+	}
 }
 
-// GetLogFiles returns list of log files in the logs directory
-func (a *App) GetLogFiles() []map[string]string {
-	logsDir := "logs"
-	var files []map[string]string
+// ApproveExecCommand allows an exec: command to run on this computer. The
+// frontend shows the command and asks before calling this.
+func (a *App) ApproveExecCommand(command string) bool {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return false
+	}
 
-	filepath.Walk(logsDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if !info.IsDir() && filepath.Ext(path) == ".log" {
-			normalizedPath := strings.ReplaceAll(path, "\\", "/")
-			files = append(files, map[string]string{
-				"name":    info.Name(),
-				"path":    normalizedPath,
-				"date":    filepath.Base(filepath.Dir(path)),
-				"modTime": info.ModTime().Format("2006-01-02 15:04:05"),
-			})
+	cfg, err := config.LoadSecrets()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load secret provider settings: "+err.Error())
+			return false
 		}
-		return nil
-	})
-
-	sort.Slice(files, func(i, j int) bool {
-		return files[i]["modTime"] > files[j]["modTime"]
-	})
+		cfg = &config.SecretsConfig{}
+	}
+	hash := secret.CommandHash(command)
+	if !slices.Contains(cfg.ApprovedCommands, hash) {
+		cfg.ApprovedCommands = append(cfg.ApprovedCommands, hash)
+	}
+	if err := config.SaveSecrets(cfg); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save the allowed command: "+err.Error())
+		return false
+	}
+	a.applySecretSettings()
 
-	return files
+	a.recordAudit(audit.SecretApprove, map[string]interface{}{"command": command})
+	return true
 }
 
-// ReadLogFile reads and returns the content of a log file
-func (a *App) ReadLogFile(path string) string {
-	content, err := os.ReadFile(path)
+// RevokeExecCommands withdraws every exec: command allowed so far; each asks
+// again before it next runs
+func (a *App) RevokeExecCommands() bool {
+	cfg, err := config.LoadSecrets()
 	if err != nil {
-		return "Error reading file: " + err.Error()
+		if os.IsNotExist(err) {
+			return true
+		}
+		runtime.EventsEmit(a.ctx, "error", "Failed to load secret provider settings: "+err.Error())
+		return false
 	}
-	return string(content)
+	revoked := len(cfg.ApprovedCommands)
+	cfg.ApprovedCommands = nil
+	if err := config.SaveSecrets(cfg); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save secret provider settings: "+err.Error())
+		return false
+	}
+	a.applySecretSettings()
+
+	a.recordAudit(audit.SecretApprove, map[string]interface{}{"revoked": revoked})
+	return true
 }
 
-// GetCurrentLogDir returns the current log directory
-func (a *App) GetCurrentLogDir() string {
-	if a.runner != nil {
-		return a.runner.LogDir
+// applySecretSettings registers providers from the saved settings.
+// Without settings, the vault provider uses VAULT_ADDR / VAULT_TOKEN.
+func (a *App) applySecretSettings() {
+	cfg, err := config.LoadSecrets()
+	if err != nil {
+		if !os.IsNotExist(err) {
+			runtime.EventsEmit(a.ctx, "error", "Failed to load secret provider settings: "+err.Error())
+		}
+		cfg = &config.SecretsConfig{}
 	}
-	return ""
+	secret.Register("vault", vaultProvider(cfg))
+	secret.SetApprovedCommands(cfg.ApprovedCommands)
 }
 
-// GetCurrentVersion returns the current application version
-func (a *App) GetCurrentVersion() string {
-	return a.updater.GetCurrentVersion()
+// vaultProvider creates the vault provider; empty settings fall back to the environment
+func vaultProvider(cfg *config.SecretsConfig) *secret.VaultProvider {
+	vault := secret.NewVaultProviderFromEnv()
+	if cfg.VaultAddress != "" {
+		vault.Address = cfg.VaultAddress
+	}
+	if cfg.VaultToken != "" {
+		vault.Token = cfg.VaultToken
+	}
+	if cfg.VaultNamespace != "" {
+		vault.Namespace = cfg.VaultNamespace
+	}
+	return vault
 }
 
-// CheckForUpdates checks for available updates
-func (a *App) CheckForUpdates() *updater.UpdateInfo {
-	info, err := a.updater.CheckForUpdates()
-	if err != nil {
-		runtime.EventsEmit(a.ctx, "updateError", err.Error())
-		return nil
+// mapToSecretsConfig converts a map from the UI to secret provider settings
+func mapToSecretsConfig(data map[string]interface{}) *config.SecretsConfig {
+	cfg := &config.SecretsConfig{}
+	if v, ok := data["vaultAddress"].(string); ok {
+		cfg.VaultAddress = strings.TrimSpace(v)
 	}
-	return info
+	if v, ok := data["vaultToken"].(string); ok {
+		cfg.VaultToken = strings.TrimSpace(v)
+	}
+	if v, ok := data["vaultNamespace"].(string); ok {
+		cfg.VaultNamespace = strings.TrimSpace(v)
+	}
+	return cfg
 }
 
-// DownloadAndInstallUpdate downloads and installs the update
-func (a *App) DownloadAndInstallUpdate(downloadURL string) bool {
-	tempFile, err := a.updater.DownloadUpdate(downloadURL, func(downloaded, total int64) {
-		percent := float64(downloaded) / float64(total) * 100
-		runtime.EventsEmit(a.ctx, "updateProgress", map[string]interface{}{
-			"downloaded": downloaded,
-			"total":      total,
-			"percent":    percent,
-		})
-	})
+// ==================== Queue Persistence ====================
 
-	if err != nil {
-		runtime.EventsEmit(a.ctx, "updateError", "Download failed: "+err.Error())
-		return false
+// Decisions on a restored run (see ResolveRestoredQueue)
+const (
+	restoreResume  = "resume"  // run before the runs queued since the start
+	restoreRequeue = "requeue" // run after them
+	restoreDiscard = "discard"
+)
+
+// toQueuedRun converts a queue item to its saved form
+func (item *queueItem) toQueuedRun() config.QueuedRun {
+	run := config.QueuedRun{
+		ID:                item.id,
+		Priority:          item.priority,
+		ScheduleName:      item.scheduleName,
+		Servers:           item.servers,
+		Commands:          item.commands,
+		Username:          item.username,
+		Password:          item.password,
+		EnablePassword:    item.enablePassword,
+		Timeout:           item.timeout,
+		EnableMode:        item.enableMode,
+		DisablePaging:     item.disablePaging,
+		AutoExportExcel:   item.autoExportExcel,
+		CredentialProfile: item.credentialProfile,
+		IsManual:          item.isManual,
+		QueuedAt:          item.queuedAt,
+		Interrupted:       item.interrupted,
 	}
+	if item.task != nil {
+		run.TaskID = item.task.ID
+	}
+	return run
+}
 
-	if err := a.updater.ApplyUpdate(tempFile); err != nil {
-		runtime.EventsEmit(a.ctx, "updateError", "Install failed: "+err.Error())
-		return false
+// saveQueue saves the queued runs, the runs in progress and the restored runs
+// awaiting a decision, so they survive a restart
+func (a *App) saveQueue() {
+	a.queueSaveMu.Lock()
+	defer a.queueSaveMu.Unlock()
+
+	a.mu.Lock()
+	if !a.queueLoaded || fsutil.ReadOnly() {
+		a.mu.Unlock()
+		return
+	}
+	runs := make([]config.QueuedRun, 0, len(a.restored)+len(a.runs)+len(a.queue))
+	for i := range a.restored {
+		runs = append(runs, a.restored[i].toQueuedRun())
+	}
+	// A stopped run is not restored
+	for _, id := range a.runOrder {
+		if r := a.runs[id]; !r.done && !r.stopped {
+			run := r.item.toQueuedRun()
+			run.Interrupted = true
+			runs = append(runs, run)
+		}
+	}
+	for i := range a.queue {
+		runs = append(runs, a.queue[i].toQueuedRun())
 	}
+	a.mu.Unlock()
 
-	runtime.EventsEmit(a.ctx, "updateComplete", "Update installed successfully. Please restart the application.")
-	return true
+	if err := config.SaveQueue(runs); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save the execution queue: "+err.Error())
+	}
 }
 
-// RestartApp restarts the application
-func (a *App) RestartApp() {
-	exePath, err := os.Executable()
+// restoreQueue loads the runs saved when the app last closed. They wait in
+// restored until the user resumes, re-queues or discards them. If the file
+// cannot be read, the queue is not saved this session so the file is kept.
+func (a *App) restoreQueue() {
+	runs, err := config.LoadQueue()
 	if err != nil {
-		runtime.EventsEmit(a.ctx, "updateError", "Failed to restart: "+err.Error())
+		runtime.EventsEmit(a.ctx, "error", "Failed to load the saved execution queue: "+err.Error())
 		return
 	}
 
-	cmd := exec.Command(exePath)
-	cmd.Start()
-	os.Exit(0)
+	items := make([]queueItem, len(runs))
+	for i, run := range runs {
+		items[i] = queueItem{
+			id:                run.ID,
+			priority:          run.Priority,
+			servers:           run.Servers,
+			commands:          run.Commands,
+			username:          run.Username,
+			password:          run.Password,
+			timeout:           run.Timeout,
+			enableMode:        run.EnableMode,
+			disablePaging:     run.DisablePaging,
+			autoExportExcel:   run.AutoExportExcel,
+			enablePassword:    run.EnablePassword,
+			scheduleName:      run.ScheduleName,
+			credentialProfile: run.CredentialProfile,
+			isManual:          run.IsManual,
+			queuedAt:          run.QueuedAt,
+			interrupted:       run.Interrupted,
+		}
+		if items[i].id == "" {
+			items[i].id = uuid.New().String()
+		}
+		// A deleted schedule's run still runs, without its email and chain
+		if run.TaskID != "" {
+			items[i].task = a.scheduler.GetTask(run.TaskID)
+		}
+	}
+
+	a.mu.Lock()
+	a.restored = items
+	a.queueLoaded = true
+	a.mu.Unlock()
+
+	if len(items) > 0 {
+		runtime.EventsEmit(a.ctx, "queueRestored", len(items))
+	}
 }
 
-// ExportResults exports execution results to Excel file
-func (a *App) ExportResults() string {
+// GetRestoredQueue returns the runs saved when the app last closed that
+// await a decision. The frontend calls it when it starts and on the
+// "queueRestored" event, since the queue is restored before it listens.
+func (a *App) GetRestoredQueue() []map[string]interface{} {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	if a.runner == nil {
-		return ""
+	result := make([]map[string]interface{}, len(a.restored))
+	for i, item := range a.restored {
+		entry := map[string]interface{}{
+			"id":           item.id,
+			"priority":     queuePriority(item.priority),
+			"scheduleName": item.scheduleName,
+			"isManual":     item.isManual,
+			"interrupted":  item.interrupted,
+			"serverCount":  len(item.servers),
+			"commandCount": len(item.commands),
+			"queuedAt":     item.queuedAt.Format(time.RFC3339),
+		}
+		if item.task != nil {
+			entry["taskId"] = item.task.ID
+		}
+		result[i] = entry
 	}
+	return result
+}
 
-	results := a.runner.GetResults()
-	if len(results) == 0 {
-		return ""
+// ResolveRestoredQueue applies a decision to each run returned by
+// GetRestoredQueue, in the same order: "resume" runs it before the runs
+// queued since the app started, "requeue" after them and "discard" drops it.
+// Kept runs wait in the queue from now on.
+func (a *App) ResolveRestoredQueue(actions []string) bool {
+	a.mu.Lock()
+	if len(a.restored) == 0 && len(actions) == 0 {
+		a.mu.Unlock()
+		return true
+	}
+	if len(actions) != len(a.restored) {
+		a.mu.Unlock()
+		runtime.EventsEmit(a.ctx, "error", "The restored queue has changed; review it again")
+		return false
+	}
+	for _, action := range actions {
+		switch action {
+		case restoreResume, restoreRequeue, restoreDiscard:
+		default:
+			a.mu.Unlock()
+			runtime.EventsEmit(a.ctx, "error", "Unknown decision for a restored run: "+action)
+			return false
+		}
 	}
 
-	outputPath := filepath.Join(a.runner.LogDir, "results.xlsx")
+	var resumed, requeued []queueItem
+	decided := map[string][]string{}
+	for i, item := range a.restored {
+		decided[actions[i]] = append(decided[actions[i]], item.scheduleName)
+		if actions[i] == restoreDiscard {
+			continue
+		}
+		item.interrupted = false
+		item.queuedAt = time.Now()
+		if item.task != nil {
+			item.chain = &chainRun{id: uuid.New().String(), root: item.task.Name, started: item.queuedAt, pending: 1}
+		}
+		if actions[i] == restoreResume {
+			resumed = append(resumed, item)
+		} else {
+			requeued = append(requeued, item)
+		}
+	}
+	a.restored = nil
+	queue := make([]queueItem, 0, len(resumed)+len(a.queue)+len(requeued))
+	queue = append(append(append(queue, resumed...), a.queue...), requeued...)
+	a.queue = queue
+	a.armQueueExpiry()
+	a.mu.Unlock()
+	a.saveQueue()
 
-	err := cisco.ExportToExcel(results, a.commands, outputPath)
-	if err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to export Excel: "+err.Error())
-		return ""
+	a.recordAudit(audit.QueueRestore, map[string]interface{}{
+		"resumed":   decided[restoreResume],
+		"requeued":  decided[restoreRequeue],
+		"discarded": decided[restoreDiscard],
+	})
+
+	for _, items := range [][]queueItem{resumed, requeued} {
+		for i := range items {
+			a.emitQueueStatus(&items[i], queueQueued, nil)
+		}
+	}
+	if len(resumed)+len(requeued) > 0 {
+		go a.processQueue()
 	}
+	return true
+}
 
-	return outputPath
+// ==================== Blackout Windows ====================
+
+// GetBlackouts returns the global blackout windows
+func (a *App) GetBlackouts() []map[string]interface{} {
+	blackouts, err := config.LoadBlackouts()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load blackout windows: "+err.Error())
+		return []map[string]interface{}{}
+	}
+	return blackoutsToMaps(blackouts)
 }
 
-// sendScheduleResultEmail zips the log directory and sends it via email
-func (a *App) sendScheduleResultEmail(task *scheduler.ScheduledTask, logDir string, success, fail, total int) {
-	// Export Excel first if needed
-	excelPath := filepath.Join(logDir, "results.xlsx")
-	if _, err := os.Stat(excelPath); os.IsNotExist(err) {
-		a.mu.Lock()
-		if a.runner != nil {
-			results := a.runner.GetResults()
-			if len(results) > 0 {
-				cisco.ExportToExcel(results, a.commands, excelPath)
-			}
+// SaveBlackouts replaces the global blackout windows
+func (a *App) SaveBlackouts(list []map[string]interface{}) bool {
+	blackouts := make([]scheduler.Blackout, 0, len(list))
+	for _, data := range list {
+		b := mapToBlackout(data)
+		if err := b.Validate(); err != nil {
+			runtime.EventsEmit(a.ctx, "error", "Invalid blackout window: "+err.Error())
+			return false
 		}
-		a.mu.Unlock()
+		blackouts = append(blackouts, b)
 	}
 
-	// Create ZIP
-	zipPath := filepath.Join(logDir, fmt.Sprintf("%s_%s.zip", task.Name, time.Now().Format("2006-01-02_150405")))
-	if err := zipDirectory(logDir, zipPath); err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to create ZIP: "+err.Error())
-		return
+	if err := config.SaveBlackouts(blackouts); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save blackout windows: "+err.Error())
+		return false
+	}
+	a.recordAudit(audit.BlackoutUpdate, map[string]interface{}{"blackouts": blackoutNames(blackouts)})
+	return true
+}
+
+// ImportBlackoutCalendar imports the events of an iCalendar (.ics) file as
+// global blackout windows. Windows imported earlier from a file with the
+// same name are replaced.
+func (a *App) ImportBlackoutCalendar() map[string]interface{} {
+	file, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Holiday / Change Freeze Calendar",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "iCalendar Files (*.ics)", Pattern: "*.ics"},
+			{DisplayName: "All Files (*.*)", Pattern: "*.*"},
+		},
+	})
+	if err != nil || file == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": "Failed to read file: " + err.Error()}
+	}
+	source := filepath.Base(file)
+	imported, err := scheduler.ParseICS(data, source)
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
 	}
 
-	// Load global SMTP settings
-	smtpCfg, err := config.LoadSmtp()
+	existing, err := config.LoadBlackouts()
 	if err != nil {
-		runtime.EventsEmit(a.ctx, "error", "SMTP not configured: "+err.Error())
-		os.Remove(zipPath)
-		return
+		return map[string]interface{}{"success": false, "error": err.Error()}
 	}
+	blackouts := make([]scheduler.Blackout, 0, len(existing)+len(imported))
+	replaced := 0
+	for _, b := range existing {
+		if b.Source == source {
+			replaced++
+			continue
+		}
+		blackouts = append(blackouts, b)
+	}
+	blackouts = append(blackouts, imported...)
 
-	// Send email
-	cfg := email.Config{
-		SmtpServer: smtpCfg.Server,
-		SmtpPort:   smtpCfg.Port,
-		Username:   smtpCfg.Username,
-		Password:   smtpCfg.Password,
-		To:         task.EmailTo,
+	if err := config.SaveBlackouts(blackouts); err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
 	}
-	summary := email.Summary{
-		Success: success,
-		Fail:    fail,
-		Total:   total,
+	a.recordAudit(audit.BlackoutUpdate, map[string]interface{}{
+		"source":   source,
+		"imported": len(imported),
+		"replaced": replaced,
+	})
+	return map[string]interface{}{
+		"success":  true,
+		"source":   source,
+		"imported": len(imported),
+		"replaced": replaced,
 	}
+}
 
-	if err := email.SendResultEmail(cfg, zipPath, task.Name, summary); err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to send email: "+err.Error())
-		return
+// blackedOut checks whether a scheduled run falls into a blackout window
+// and, if so, skips or defers it according to the task's blackout policy.
+// If the global windows cannot be read, the run is skipped rather than
+// risk touching devices during a freeze.
+func (a *App) blackedOut(task *scheduler.ScheduledTask, trigger string) bool {
+	details := map[string]interface{}{
+		"id":      task.ID,
+		"name":    task.Name,
+		"trigger": trigger,
 	}
 
-	runtime.EventsEmit(a.ctx, "info", fmt.Sprintf("Email sent to %s", task.EmailTo))
+	global, err := config.LoadBlackouts()
+	if err != nil {
+		a.skipRun(task, "Blackout windows could not be read: "+err.Error(), details)
+		return true
+	}
 
-	// Clean up ZIP file
-	os.Remove(zipPath)
-}
+	b, until := task.ActiveBlackout(time.Now(), global)
+	if b == nil {
+		return false
+	}
+	reason := fmt.Sprintf("Blackout window '%s' (%s) until %s", b.Name, b.Describe(), until.Format("2006-01-02 15:04"))
+	details["blackout"] = b.Name
 
-// zipDirectory creates a ZIP file from all files in the directory (excluding .zip files)
-func zipDirectory(srcDir, zipPath string) error {
-	zipFile, err := os.Create(zipPath)
-	if err != nil {
-		return err
+	if task.BlackoutPolicy == scheduler.BlackoutDefer {
+		a.deferRun(task, trigger, until, reason, details)
+	} else {
+		a.skipRun(task, reason, details)
 	}
-	defer zipFile.Close()
+	return true
+}
 
-	w := zip.NewWriter(zipFile)
-	defer w.Close()
+// skipRun records and reports a scheduled run that does not run
+func (a *App) skipRun(task *scheduler.ScheduledTask, reason string, details map[string]interface{}) {
+	details["reason"] = reason
+	a.recordAudit(audit.ScheduleSkip, details)
+	runtime.EventsEmit(a.ctx, "scheduleSkipped", map[string]interface{}{
+		"taskId":   task.ID,
+		"taskName": task.Name,
+		"reason":   reason,
+	})
+}
 
-	return filepath.Walk(srcDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil
-		}
-		if info.IsDir() {
-			return nil
-		}
-		// Skip the zip file itself
-		if filepath.Ext(path) == ".zip" {
-			return nil
-		}
+// deferRun runs a task when a blackout ends. A task has at most one
+// deferred run; later runs during the same blackout are skipped.
+func (a *App) deferRun(task *scheduler.ScheduledTask, trigger string, until time.Time, reason string, details map[string]interface{}) {
+	id := task.ID
 
-		relPath, err := filepath.Rel(srcDir, path)
-		if err != nil {
-			return err
-		}
+	a.mu.Lock()
+	if _, pending := a.deferred[id]; pending {
+		a.mu.Unlock()
+		a.skipRun(task, reason+"; a deferred run is already pending", details)
+		return
+	}
+	if a.deferred == nil {
+		a.deferred = make(map[string]*time.Timer)
+	}
+	a.deferred[id] = time.AfterFunc(time.Until(until), func() {
+		a.mu.Lock()
+		delete(a.deferred, id)
+		a.mu.Unlock()
 
-		f, err := w.Create(relPath)
-		if err != nil {
-			return err
+		// The task may have been changed, disabled or deleted meanwhile.
+		// One-time tasks disable themselves when they fire.
+		current := a.scheduler.GetTask(id)
+		if current == nil || (!current.Enabled && current.ScheduleType != scheduler.TypeOnce) {
+			return
 		}
-
-		src, err := os.Open(path)
-		if err != nil {
-			return err
+		if !a.blackedOut(current, trigger) {
+			a.executeTask(current, trigger, nil)
 		}
-		defer src.Close()
+	})
+	a.mu.Unlock()
 
-		_, err = io.Copy(f, src)
-		return err
+	details["reason"] = reason
+	details["until"] = until.Format(time.RFC3339)
+	a.recordAudit(audit.ScheduleDefer, details)
+	runtime.EventsEmit(a.ctx, "scheduleDeferred", map[string]interface{}{
+		"taskId":   task.ID,
+		"taskName": task.Name,
+		"reason":   reason,
+		"until":    until.Format(time.RFC3339),
 	})
 }
 
-// ==================== SMTP Settings ====================
+// stopDeferredRuns cancels the runs deferred past a blackout
+func (a *App) stopDeferredRuns() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
-// SaveSmtpSettings saves SMTP configuration (encrypted)
-func (a *App) SaveSmtpSettings(data map[string]interface{}) bool {
-	cfg := &config.SmtpConfig{
-		Port: 587,
+	for id, timer := range a.deferred {
+		timer.Stop()
+		delete(a.deferred, id)
 	}
-	if server, ok := data["server"].(string); ok {
-		cfg.Server = server
+}
+
+// mapToBlackout converts a map from the UI to a blackout window
+func mapToBlackout(data map[string]interface{}) scheduler.Blackout {
+	b := scheduler.Blackout{}
+	if v, ok := data["name"].(string); ok {
+		b.Name = strings.TrimSpace(v)
 	}
-	if port, ok := data["port"].(float64); ok {
-		cfg.Port = int(port)
+	if v, ok := data["type"].(string); ok {
+		b.Type = v
 	}
-	if username, ok := data["username"].(string); ok {
-		cfg.Username = username
+	if days, ok := data["daysOfWeek"].([]interface{}); ok {
+		for _, d := range days {
+			if day, ok := d.(float64); ok {
+				b.DaysOfWeek = append(b.DaysOfWeek, int(day))
+			}
+		}
 	}
-	if password, ok := data["password"].(string); ok {
-		cfg.Password = password
+	if v, ok := data["startTime"].(string); ok {
+		b.StartTime = v
 	}
-
-	if err := config.SaveSmtp(cfg); err != nil {
-		runtime.EventsEmit(a.ctx, "error", "Failed to save SMTP settings: "+err.Error())
-		return false
+	if v, ok := data["endTime"].(string); ok {
+		b.EndTime = v
 	}
-	return true
+	if v, ok := data["start"].(string); ok {
+		b.Start = v
+	}
+	if v, ok := data["end"].(string); ok {
+		b.End = v
+	}
+	if v, ok := data["source"].(string); ok {
+		b.Source = v
+	}
+	return b
 }
 
-// LoadSmtpSettings loads SMTP configuration (decrypted) for UI display
-func (a *App) LoadSmtpSettings() map[string]interface{} {
-	cfg, err := config.LoadSmtp()
-	if err != nil {
-		return map[string]interface{}{
-			"server":   "",
-			"port":     587,
-			"username": "",
-			"password": "",
+// blackoutsToMaps converts blackout windows to maps for the UI
+func blackoutsToMaps(blackouts []scheduler.Blackout) []map[string]interface{} {
+	result := make([]map[string]interface{}, len(blackouts))
+	for i, b := range blackouts {
+		result[i] = map[string]interface{}{
+			"name":        b.Name,
+			"type":        b.Type,
+			"daysOfWeek":  b.DaysOfWeek,
+			"startTime":   b.StartTime,
+			"endTime":     b.EndTime,
+			"start":       b.Start,
+			"end":         b.End,
+			"source":      b.Source,
+			"description": b.Describe(),
 		}
 	}
-	return map[string]interface{}{
-		"server":   cfg.Server,
-		"port":     cfg.Port,
-		"username": cfg.Username,
-		"password": cfg.Password,
+	return result
+}
+
+// blackoutNames returns the names of blackout windows
+func blackoutNames(blackouts []scheduler.Blackout) []string {
+	names := make([]string, len(blackouts))
+	for i, b := range blackouts {
+		names[i] = b.Name
 	}
+	return names
 }
 
 // ==================== Schedule Management ====================
@@ -854,6 +4271,7 @@ func (a *App) CreateSchedule(taskData map[string]interface{}) string {
 	}
 
 	a.saveSchedules()
+	a.recordAudit(audit.ScheduleCreate, scheduleAuditDetails(task))
 	return task.ID
 }
 
@@ -867,17 +4285,24 @@ func (a *App) UpdateSchedule(taskData map[string]interface{}) bool {
 	}
 
 	a.saveSchedules()
+	a.recordAudit(audit.ScheduleUpdate, scheduleAuditDetails(task))
 	return true
 }
 
 // DeleteSchedule removes a scheduled task
 func (a *App) DeleteSchedule(id string) bool {
+	details := map[string]interface{}{"id": id}
+	if task := a.scheduler.GetTask(id); task != nil {
+		details["name"] = task.Name
+	}
+
 	if err := a.scheduler.DeleteTask(id); err != nil {
 		runtime.EventsEmit(a.ctx, "error", "Failed to delete schedule: "+err.Error())
 		return false
 	}
 
 	a.saveSchedules()
+	a.recordAudit(audit.ScheduleDelete, details)
 	return true
 }
 
@@ -901,6 +4326,11 @@ func (a *App) ToggleSchedule(id string, enabled bool) bool {
 	}
 
 	a.saveSchedules()
+	details := map[string]interface{}{"id": id, "enabled": enabled}
+	if task := a.scheduler.GetTask(id); task != nil {
+		details["name"] = task.Name
+	}
+	a.recordAudit(audit.ScheduleToggle, details)
 	return true
 }
 
@@ -912,16 +4342,177 @@ func (a *App) RunScheduleNow(id string) bool {
 		return false
 	}
 
-	go a.executeScheduledTask(task)
+	go a.executeTask(task, audit.TriggerManual, nil)
 	return true
 }
 
+// PreviewSchedule validates the schedule part of taskData and returns its
+// description and next fire times
+func (a *App) PreviewSchedule(taskData map[string]interface{}, count int) map[string]interface{} {
+	task := a.mapToScheduledTask(taskData)
+	if count <= 0 || count > 50 {
+		count = 5
+	}
+
+	runs, err := task.NextRuns(time.Now(), count)
+	if err != nil {
+		return map[string]interface{}{"success": false, "error": err.Error()}
+	}
+
+	nextRuns := make([]string, len(runs))
+	zonedRuns := make([]string, len(runs))
+	for i, t := range runs {
+		nextRuns[i] = t.Format(time.RFC3339)
+		zonedRuns[i] = formatInZone(t, task)
+	}
+	return map[string]interface{}{
+		"success":       true,
+		"description":   a.scheduler.Describe(task),
+		"nextRuns":      nextRuns,
+		"nextRunsZoned": zonedRuns,
+	}
+}
+
+// formatInZone formats t in the task's time zone, or returns "" for tasks
+// in the local zone
+func formatInZone(t time.Time, task *scheduler.ScheduledTask) string {
+	if task.TimeZone == "" {
+		return ""
+	}
+	loc, err := task.Location()
+	if err != nil {
+		return ""
+	}
+	return t.In(loc).Format("2006-01-02 15:04 MST")
+}
+
 // saveSchedules saves all schedules to config file
 func (a *App) saveSchedules() {
 	tasks := a.scheduler.GetTasks()
 	if err := config.SaveSchedules(tasks); err != nil {
 		runtime.EventsEmit(a.ctx, "error", "Failed to save schedules: "+err.Error())
 	}
+	a.saveRunStates()
+}
+
+// saveRunStates saves when schedules last ran, so runs missed while the
+// app is closed can be detected at the next start
+func (a *App) saveRunStates() {
+	a.mu.Lock()
+	loaded := a.stateLoaded
+	a.mu.Unlock()
+
+	// Without loaded schedules (e.g. a locked vault) there is nothing to save,
+	// and saving would drop the states of the saved schedules
+	if !loaded || fsutil.ReadOnly() {
+		return
+	}
+	if err := config.SaveRunStates(a.scheduler.RunStates(time.Now())); err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to save schedule run times: "+err.Error())
+	}
+}
+
+// catchUpSchedules finds the runs that were due while the app was closed and
+// handles them according to each schedule's missed run policy
+func (a *App) catchUpSchedules() {
+	states, err := config.LoadRunStates()
+	if err != nil {
+		runtime.EventsEmit(a.ctx, "error", "Failed to load schedule run times: "+err.Error())
+		states = map[string]scheduler.RunState{}
+	}
+	missed := a.scheduler.CatchUp(states, time.Now())
+	a.saveRunStates()
+
+	var runs []*scheduler.ScheduledTask
+	disabled := false
+	for _, m := range missed {
+		task := m.Task
+		policy := task.MissedRunPolicy
+		if policy == "" {
+			policy = scheduler.MissedSkip
+		}
+
+		n, action := 0, "Skipped"
+		switch policy {
+		case scheduler.MissedRunOnce:
+			n, action = 1, "Running once now"
+		case scheduler.MissedRunAll:
+			n = min(len(m.Times), scheduler.MaxCatchUpRuns)
+			action = fmt.Sprintf("Running %d missed run(s) now", n)
+		}
+
+		times := make([]string, len(m.Times))
+		for i, t := range m.Times {
+			times[i] = t.Format(time.RFC3339)
+		}
+		a.recordAudit(audit.ScheduleMissed, map[string]interface{}{
+			"id":        task.ID,
+			"name":      task.Name,
+			"policy":    policy,
+			"missed":    times,
+			"truncated": m.Truncated,
+			"runs":      n,
+		})
+
+		a.mu.Lock()
+		a.missedRuns = append(a.missedRuns, map[string]interface{}{
+			"taskId":    task.ID,
+			"taskName":  task.Name,
+			"count":     len(m.Times),
+			"truncated": m.Truncated,
+			"first":     times[0],
+			"last":      times[len(times)-1],
+			"action":    action,
+		})
+		a.mu.Unlock()
+
+		if task.NotifyMissed && task.EmailTo != "" {
+			go a.sendMissedRunEmail(task, m, action)
+		}
+		for i := 0; i < n; i++ {
+			runs = append(runs, task)
+		}
+
+		// A one-time task is done once its time has passed
+		if task.ScheduleType == scheduler.TypeOnce {
+			a.scheduler.ToggleTask(task.ID, false)
+			disabled = true
+		}
+	}
+	if disabled {
+		a.saveSchedules()
+	}
+
+	if len(missed) > 0 {
+		runtime.EventsEmit(a.ctx, "scheduleMissed")
+	}
+
+	// The first run starts, the others queue behind it whatever the
+	// schedule's overlap policy
+	if len(runs) > 0 {
+		go func() {
+			for _, task := range runs {
+				if !a.blackedOut(task, audit.TriggerCatchUp) {
+					a.executeTask(task, audit.TriggerCatchUp, nil)
+				}
+			}
+		}()
+	}
+}
+
+// TakeMissedRuns returns the missed runs found since the last call. The
+// frontend calls it when it starts and on the "scheduleMissed" event, since
+// missed runs found at startup are reported before it listens.
+func (a *App) TakeMissedRuns() []map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	missed := a.missedRuns
+	a.missedRuns = nil
+	if missed == nil {
+		missed = []map[string]interface{}{}
+	}
+	return missed
 }
 
 // mapToScheduledTask converts a map to ScheduledTask
@@ -957,6 +4548,62 @@ func (a *App) mapToScheduledTask(data map[string]interface{}) *scheduler.Schedul
 	if dayOfMonth, ok := data["dayOfMonth"].(float64); ok {
 		task.DayOfMonth = int(dayOfMonth)
 	}
+	if cronExpression, ok := data["cronExpression"].(string); ok {
+		task.CronExpression = strings.TrimSpace(cronExpression)
+	}
+	if interval, ok := data["interval"].(float64); ok {
+		task.Interval = int(interval)
+	}
+	if intervalUnit, ok := data["intervalUnit"].(string); ok {
+		task.IntervalUnit = intervalUnit
+	}
+	if runAt, ok := data["runAt"].(string); ok {
+		task.RunAt = strings.TrimSpace(runAt)
+	}
+	if startDate, ok := data["startDate"].(string); ok {
+		task.StartDate = strings.TrimSpace(startDate)
+	}
+	if endDate, ok := data["endDate"].(string); ok {
+		task.EndDate = strings.TrimSpace(endDate)
+	}
+	if timeZone, ok := data["timeZone"].(string); ok {
+		task.TimeZone = strings.TrimSpace(timeZone)
+	}
+	if policy, ok := data["missedRunPolicy"].(string); ok {
+		task.MissedRunPolicy = policy
+	}
+	if notifyMissed, ok := data["notifyMissed"].(bool); ok {
+		task.NotifyMissed = notifyMissed
+	}
+	if after, ok := data["after"].([]interface{}); ok && task.ScheduleType == scheduler.TypeAfter {
+		for _, d := range after {
+			if m, ok := d.(map[string]interface{}); ok {
+				dep := scheduler.Dependency{}
+				dep.TaskID, _ = m["taskId"].(string)
+				dep.Condition, _ = m["condition"].(string)
+				task.After = append(task.After, dep)
+			}
+		}
+	}
+	if overlap, ok := data["overlapPolicy"].(string); ok {
+		task.OverlapPolicy = overlap
+	}
+	if wait, ok := data["maxQueueWait"].(float64); ok {
+		task.MaxQueueWait = int(wait)
+	}
+	if priority, ok := data["priority"].(string); ok {
+		task.Priority = priority
+	}
+	if blackouts, ok := data["blackouts"].([]interface{}); ok {
+		for _, b := range blackouts {
+			if m, ok := b.(map[string]interface{}); ok {
+				task.Blackouts = append(task.Blackouts, mapToBlackout(m))
+			}
+		}
+	}
+	if policy, ok := data["blackoutPolicy"].(string); ok {
+		task.BlackoutPolicy = policy
+	}
 	if timeout, ok := data["timeout"].(float64); ok {
 		task.Timeout = int(timeout)
 	}
@@ -978,6 +4625,17 @@ func (a *App) mapToScheduledTask(data map[string]interface{}) *scheduler.Schedul
 		task.EmailTo = emailTo
 	}
 
+	// Secret redaction
+	if redactLogs, ok := data["redactLogs"].(bool); ok {
+		task.RedactLogs = redactLogs
+	}
+	if redactEmail, ok := data["redactEmail"].(bool); ok {
+		task.RedactEmail = redactEmail
+	}
+	if keepUnredacted, ok := data["keepUnredacted"].(bool); ok {
+		task.KeepUnredacted = keepUnredacted
+	}
+
 	// Parse credentials
 	if username, ok := data["username"].(string); ok {
 		task.Username = username
@@ -988,38 +4646,29 @@ func (a *App) mapToScheduledTask(data map[string]interface{}) *scheduler.Schedul
 	if enablePassword, ok := data["enablePassword"].(string); ok {
 		task.EnablePassword = enablePassword
 	}
+	if profile, ok := data["credentialProfile"].(string); ok {
+		task.CredentialProfile = strings.TrimSpace(profile)
+	}
 
 	// Parse servers
 	if servers, ok := data["servers"].([]interface{}); ok {
 		task.Servers = make([]cisco.Server, 0, len(servers))
 		for _, s := range servers {
 			if serverMap, ok := s.(map[string]interface{}); ok {
-				server := cisco.Server{}
-				if ip, ok := serverMap["ip"].(string); ok {
-					server.IP = ip
-				}
-				if hostname, ok := serverMap["hostname"].(string); ok {
-					server.Hostname = hostname
-				}
-				if username, ok := serverMap["username"].(string); ok {
-					server.Username = username
-				}
-				if password, ok := serverMap["password"].(string); ok {
-					server.Password = password
-				}
-				if enablePassword, ok := serverMap["enablePassword"].(string); ok {
-					server.EnablePassword = enablePassword
-				}
-				if server.IP != "" {
-					if server.Hostname == "" {
-						server.Hostname = server.IP
-					}
+				if server, ok := mapToServer(toStringMap(serverMap)); ok {
 					task.Servers = append(task.Servers, server)
 				}
 			}
 		}
 	}
 
+	// Parse target (groups/tags/expression resolved at run time)
+	if targetData, ok := data["target"].(map[string]interface{}); ok {
+		if target := mapToTarget(targetData); !target.IsEmpty() {
+			task.Target = target
+		}
+	}
+
 	// Parse commands
 	if commands, ok := data["commands"].([]interface{}); ok {
 		task.Commands = make([]string, 0, len(commands))
@@ -1037,42 +4686,200 @@ func (a *App) mapToScheduledTask(data map[string]interface{}) *scheduler.Schedul
 func (a *App) scheduledTaskToMap(task *scheduler.ScheduledTask) map[string]interface{} {
 	servers := make([]map[string]string, len(task.Servers))
 	for i, s := range task.Servers {
-		servers[i] = map[string]string{
-			"ip":             s.IP,
-			"hostname":       s.Hostname,
-			"username":       s.Username,
-			"password":       s.Password,
-			"enablePassword": s.EnablePassword,
-		}
+		servers[i] = serverToMap(s)
 	}
 
 	result := map[string]interface{}{
-		"id":              task.ID,
-		"name":            task.Name,
-		"enabled":         task.Enabled,
-		"scheduleType":    task.ScheduleType,
-		"time":            task.Time,
-		"daysOfWeek":      task.DaysOfWeek,
-		"dayOfMonth":      task.DayOfMonth,
-		"username":        task.Username,
-		"password":        task.Password,
-		"enablePassword":  task.EnablePassword,
-		"servers":         servers,
-		"commands":        task.Commands,
-		"timeout":         task.Timeout,
-		"enableMode":      task.EnableMode,
-		"disablePaging":   task.DisablePaging,
-		"autoExportExcel": task.AutoExportExcel,
-		"emailEnabled":    task.EmailEnabled,
-		"emailTo":         task.EmailTo,
+		"id":                task.ID,
+		"name":              task.Name,
+		"enabled":           task.Enabled,
+		"scheduleType":      task.ScheduleType,
+		"time":              task.Time,
+		"daysOfWeek":        task.DaysOfWeek,
+		"dayOfMonth":        task.DayOfMonth,
+		"cronExpression":    task.CronExpression,
+		"interval":          task.Interval,
+		"intervalUnit":      task.IntervalUnit,
+		"runAt":             task.RunAt,
+		"startDate":         task.StartDate,
+		"endDate":           task.EndDate,
+		"timeZone":          task.TimeZone,
+		"missedRunPolicy":   task.MissedRunPolicy,
+		"notifyMissed":      task.NotifyMissed,
+		"after":             task.After,
+		"overlapPolicy":     task.OverlapPolicy,
+		"maxQueueWait":      task.MaxQueueWait,
+		"priority":          queuePriority(task.Priority),
+		"blackouts":         blackoutsToMaps(task.Blackouts),
+		"blackoutPolicy":    task.BlackoutPolicy,
+		"description":       a.scheduler.Describe(task),
+		"expired":           task.Expired(time.Now()),
+		"username":          task.Username,
+		"password":          task.Password,
+		"enablePassword":    task.EnablePassword,
+		"credentialProfile": task.CredentialProfile,
+		"servers":           servers,
+		"commands":          task.Commands,
+		"timeout":           task.Timeout,
+		"enableMode":        task.EnableMode,
+		"disablePaging":     task.DisablePaging,
+		"autoExportExcel":   task.AutoExportExcel,
+		"emailEnabled":      task.EmailEnabled,
+		"emailTo":           task.EmailTo,
+		"redactLogs":        task.RedactLogs,
+		"redactEmail":       task.RedactEmail,
+		"keepUnredacted":    task.KeepUnredacted,
+	}
+
+	if !task.Target.IsEmpty() {
+		result["target"] = targetToMap(task.Target)
 	}
 
 	if task.LastRun != nil {
 		result["lastRun"] = task.LastRun.Format(time.RFC3339)
+		result["lastRunZoned"] = formatInZone(*task.LastRun, task)
 	}
 	if task.NextRun != nil {
 		result["nextRun"] = task.NextRun.Format(time.RFC3339)
+		result["nextRunZoned"] = formatInZone(*task.NextRun, task)
+	}
+
+	return result
+}
+
+// mapToTarget converts a map from the UI to an inventory target
+func mapToTarget(data map[string]interface{}) *inventory.Target {
+	target := &inventory.Target{}
+	if groups, ok := data["groups"].([]interface{}); ok {
+		target.Groups = toStringSlice(groups)
+	}
+	if tags, ok := data["tags"].([]interface{}); ok {
+		target.Tags = toStringSlice(tags)
 	}
+	if expression, ok := data["expression"].(string); ok {
+		target.Expression = strings.TrimSpace(expression)
+	}
+	return target
+}
+
+// targetToMap converts an inventory target to a map for the UI
+func targetToMap(target *inventory.Target) map[string]interface{} {
+	return map[string]interface{}{
+		"groups":      target.Groups,
+		"tags":        target.Tags,
+		"expression":  target.Expression,
+		"description": target.String(),
+	}
+}
+
+// mapToServer converts a server map from the UI to a cisco.Server.
+// Returns false if the entry has no IP.
+func mapToServer(s map[string]string) (cisco.Server, bool) {
+	ip := strings.TrimSpace(s["ip"])
+	if ip == "" {
+		return cisco.Server{}, false
+	}
+	hostname := strings.TrimSpace(s["hostname"])
+	if hostname == "" {
+		hostname = ip
+	}
+	port, _ := strconv.Atoi(strings.TrimSpace(s["port"]))
+	return cisco.Server{
+		IP:                ip,
+		Hostname:          hostname,
+		Username:          s["username"],
+		Password:          s["password"],
+		EnablePassword:    s["enablePassword"],
+		Port:              port,
+		DeviceType:        strings.TrimSpace(s["deviceType"]),
+		Groups:            inventory.SplitList(s["groups"]),
+		Tags:              inventory.SplitList(s["tags"]),
+		CredentialProfile: strings.TrimSpace(s["credentialProfile"]),
+		Site:              strings.TrimSpace(s["site"]),
+		Description:       s["description"],
+		Source:            s["source"],
+	}, true
+}
+
+// keepInventoryAttrs copies inventory attributes from the saved entry for
+// every attribute key that is absent from the UI map
+func keepInventoryAttrs(srv *cisco.Server, saved *cisco.Server, s map[string]string) {
+	if _, ok := s["port"]; !ok {
+		srv.Port = saved.Port
+	}
+	if _, ok := s["deviceType"]; !ok {
+		srv.DeviceType = saved.DeviceType
+	}
+	if _, ok := s["groups"]; !ok {
+		srv.Groups = saved.Groups
+	}
+	if _, ok := s["tags"]; !ok {
+		srv.Tags = saved.Tags
+	}
+	if _, ok := s["credentialProfile"]; !ok {
+		srv.CredentialProfile = saved.CredentialProfile
+	}
+	if _, ok := s["site"]; !ok {
+		srv.Site = saved.Site
+	}
+	if _, ok := s["description"]; !ok {
+		srv.Description = saved.Description
+	}
+	if _, ok := s["source"]; !ok {
+		srv.Source = saved.Source
+	}
+}
+
+// serverToMap converts a cisco.Server to a map for the UI
+func serverToMap(s cisco.Server) map[string]string {
+	port := ""
+	if s.Port != 0 {
+		port = strconv.Itoa(s.Port)
+	}
+	return map[string]string{
+		"ip":                s.IP,
+		"hostname":          s.Hostname,
+		"username":          s.Username,
+		"password":          s.Password,
+		"enablePassword":    s.EnablePassword,
+		"port":              port,
+		"deviceType":        s.DeviceType,
+		"groups":            inventory.JoinList(s.Groups),
+		"tags":              inventory.JoinList(s.Tags),
+		"credentialProfile": s.CredentialProfile,
+		"site":              s.Site,
+		"description":       s.Description,
+		"source":            s.Source,
+	}
+}
 
+// toStringSlice converts a JSON array to non-empty trimmed strings
+func toStringSlice(values []interface{}) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			if str = strings.TrimSpace(str); str != "" {
+				result = append(result, str)
+			}
+		}
+	}
+	return result
+}
+
+// toStringMap converts a JSON object with scalar values to a string map
+func toStringMap(m map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		switch val := v.(type) {
+		case string:
+			result[k] = val
+		case float64:
+			result[k] = strconv.FormatFloat(val, 'f', -1, 64)
+		case bool:
+			result[k] = strconv.FormatBool(val)
+		case []interface{}:
+			result[k] = inventory.JoinList(toStringSlice(val))
+		}
+	}
 	return result
 }
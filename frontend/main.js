@@ -65,10 +65,7 @@ const elements = {
 };
 
 // State
-let liveLogs = [];
-let serverLogs = {};
-let currentServerTab = null;  // null means show all logs
-let knownServers = new Set();
+let currentServerTab = null;  // null means show all logs of the selected run
 let latestUpdateInfo = null;
 let currentSection = 'execution';
 
@@ -78,6 +75,10 @@ document.addEventListener('DOMContentLoaded', () => {
     loadVersion();
     setupInputListeners();
     addServerRow(); // Add one empty row by default
+    checkVaultLocked();
+    checkReadOnly();
+    loadWorkspaces();
+    loadRuns();
 });
 
 // Setup Wails event listeners
@@ -88,10 +89,16 @@ function setupEventListeners() {
         window.runtime.EventsOn('completed', handleCompleted);
         window.runtime.EventsOn('error', handleError);
         window.runtime.EventsOn('log', handleLog);
+        window.runtime.EventsOn('runStarted', handleRunStarted);
+        window.runtime.EventsOn('runEnded', handleRunEnded);
         window.runtime.EventsOn('updateProgress', handleUpdateProgress);
         window.runtime.EventsOn('updateError', handleUpdateError);
         window.runtime.EventsOn('updateComplete', handleUpdateComplete);
         window.runtime.EventsOn('info', (msg) => showToast(msg, 'info'));
+        window.runtime.EventsOn('warning', (msg) => showToast(msg, 'warning'));
+        window.runtime.EventsOn('inventorySynced', () => loadSavedServerList());
+        window.runtime.EventsOn('workspaceChanged', handleWorkspaceChanged);
+        window.runtime.EventsOn('queueItem', loadQueue);
     }
 }
 
@@ -102,6 +109,17 @@ function setupInputListeners() {
     }
 }
 
+// Show a banner when another instance owns the configuration
+async function checkReadOnly() {
+    try {
+        if (await runtime.IsReadOnly()) {
+            document.getElementById('readOnlyBanner').style.display = 'inline-flex';
+        }
+    } catch (err) {
+        console.error('Failed to get read-only status:', err);
+    }
+}
+
 // Load and display version
 async function loadVersion() {
     try {
@@ -224,16 +242,19 @@ function clearServersTable() {
     updateServerCount();
 }
 
-// Import CSV file
+// Import CSV/Excel file into the saved inventory (all columns are kept)
 async function importCSV() {
     try {
-        const servers = await runtime.ImportServersFromCSV();
-        if (servers && servers.length > 0) {
-            clearServersTable();
-            servers.forEach(server => {
-                addServerRow(server.ip, server.hostname);
-            });
-            autoSaveServerList();
+        const preview = await runtime.PreviewServerImport();
+        if (!preview || !preview.servers || preview.servers.length === 0) {
+            return;
+        }
+        const mode = confirm(`Import ${preview.servers.length} servers.\nOK = replace the server list, Cancel = merge into it`) ? 'replace' : 'merge';
+        const result = await runtime.ApplyServerImport(preview.path, preview.mapping, mode);
+        if (result) {
+            await loadSavedServerList();
+            const skipped = (result.errors || []).length;
+            showToast(`Imported: ${result.added} added, ${result.updated} updated` + (skipped ? `, ${skipped} skipped` : ''), skipped ? 'warning' : 'success');
         }
     } catch (err) {
         showError('Failed to import CSV: ' + err);
@@ -458,10 +479,175 @@ async function restartApp() {
     }
 }
 
+// ==================== Runs ====================
+
+// Several runs execute at once. Each keeps its own progress, results and
+// logs; the Progress, Results and Live Logs panels show the selected run.
+let runs = {};        // by run ID
+let runOrder = [];    // run IDs in start order
+let selectedRun = null;
+const MAX_FINISHED_RUNS = 20;
+
+// ensureRun returns the state of a run, creating it for events that arrive
+// before "runStarted"
+function ensureRun(runId) {
+    if (!runs[runId]) {
+        runs[runId] = {
+            id: runId,
+            name: '',
+            isManual: true,
+            started: new Date().toISOString(),
+            running: true,
+            stopped: false,
+            logDir: '',
+            progress: { percent: 0, text: '0 / 0', current: '' },
+            results: [],
+            summary: null,
+            logs: [],
+            serverLogs: {},
+            servers: {}  // hostname by IP, in first-log order
+        };
+        runOrder.push(runId);
+    }
+    return runs[runId];
+}
+
+// trimRuns drops the oldest ended runs, as the backend does
+function trimRuns() {
+    let ended = runOrder.filter(id => !runs[id].running).length;
+    runOrder = runOrder.filter(id => {
+        if (ended > MAX_FINISHED_RUNS && !runs[id].running && id !== selectedRun) {
+            ended--;
+            delete runs[id];
+            return false;
+        }
+        return true;
+    });
+}
+
+// loadRuns picks up runs started before the frontend listened
+async function loadRuns() {
+    try {
+        const list = await runtime.GetRuns() || [];
+        for (const info of list) {
+            const run = applyRunInfo(info);
+            const results = await runtime.GetRunResults(info.runId) || [];
+            run.results = results;
+            run.progress.percent = info.total ? ((info.success + info.fail) / info.total) * 100 : 0;
+            run.progress.text = `${info.success + info.fail} / ${info.total}`;
+            if (!info.running && info.success + info.fail === info.total) {
+                run.summary = info;
+            }
+        }
+        if (list.length > 0) {
+            const running = list.filter(info => info.running);
+            selectRun((running[0] || list[list.length - 1]).runId);
+        }
+    } catch (err) {
+        console.error('Failed to load runs:', err);
+    }
+}
+
+// applyRunInfo stores what GetRuns and "runStarted" report about a run
+function applyRunInfo(info) {
+    const run = ensureRun(info.runId);
+    run.name = info.scheduleName || '';
+    run.isManual = info.isManual;
+    run.started = info.started || run.started;
+    run.running = info.running;
+    run.stopped = info.stopped;
+    run.logDir = info.logDir || '';
+    return run;
+}
+
+// resetRuns forgets the runs of the previous workspace
+function resetRuns() {
+    runs = {};
+    runOrder = [];
+    selectedRun = null;
+    currentServerTab = null;
+    elements.progressSection.style.display = 'none';
+    renderRunSelectors();
+    renderProgress();
+    renderResults();
+    renderLogs();
+    updateRunControls();
+}
+
+function runLabel(run) {
+    const name = run.name || (run.isManual ? 'Manual execution' : 'Task');
+    const state = run.running ? 'running' : (run.stopped ? 'stopped' : 'done');
+    return `${name} · ${new Date(run.started).toLocaleTimeString()} (${state})`;
+}
+
+// renderRunSelectors fills the run selectors of the Progress, Results and
+// Live Logs panels
+function renderRunSelectors() {
+    const options = runOrder.slice().reverse().map(id =>
+        `<option value="${id}" ${id === selectedRun ? 'selected' : ''}>${escapeHtml(runLabel(runs[id]))}</option>`
+    ).join('');
+    document.querySelectorAll('.run-select').forEach(select => {
+        select.innerHTML = options || '<option value="">No runs</option>';
+        select.disabled = runOrder.length === 0;
+    });
+    if (runOrder.length > 0) {
+        elements.progressSection.style.display = 'block';
+    }
+}
+
+function selectRun(runId) {
+    if (!runs[runId]) return;
+    selectedRun = runId;
+    currentServerTab = null;
+    renderRunSelectors();
+    renderProgress();
+    renderResults();
+    renderLogs();
+    updateRunControls();
+}
+
+// updateRunControls enables Stop for a selected run in progress
+function updateRunControls() {
+    const run = runs[selectedRun];
+    elements.stopBtn.disabled = !run || !run.running;
+
+    const active = runOrder.filter(id => runs[id].running).length;
+    if (elements.statusDot) {
+        elements.statusDot.className = 'status-dot' + (active > 0 ? ' running' : '');
+    }
+    updateConnectionInfo(active > 0 ? `${active} run(s) in progress` : 'No active connections');
+}
+
+function handleRunStarted(info) {
+    const run = applyRunInfo(info);
+    run.progress.text = `0 / ${info.total}`;
+
+    // Follow the new run unless another run in progress is selected
+    if (!runs[selectedRun] || !runs[selectedRun].running) {
+        selectRun(info.runId);
+    } else {
+        renderRunSelectors();
+        updateRunControls();
+    }
+}
+
+function handleRunEnded(data) {
+    const run = ensureRun(data.runId);
+    run.running = false;
+    run.stopped = data.stopped;
+    if (data.stopped && data.runId === selectedRun) {
+        setStatus('Stopped');
+    }
+    trimRuns();
+    renderRunSelectors();
+    updateRunControls();
+}
+
 // ==================== Live Logs ====================
 
 function handleLog(data) {
-    const { serverIP, hostname, line } = data;
+    const { runId, serverIP, hostname, line } = data;
+    const run = ensureRun(runId);
     const timestamp = Date.now();
 
     const logEntry = {
@@ -472,21 +658,25 @@ function handleLog(data) {
         formattedTime: new Date(timestamp).toLocaleTimeString()
     };
 
-    liveLogs.push(logEntry);
+    run.logs.push(logEntry);
 
-    if (!serverLogs[serverIP]) {
-        serverLogs[serverIP] = [];
-        addServerTab(serverIP, hostname);
+    if (!run.serverLogs[serverIP]) {
+        run.serverLogs[serverIP] = [];
+        run.servers[serverIP] = hostname;
+        if (runId === selectedRun) {
+            addServerTab(serverIP, hostname);
+        }
     }
-    serverLogs[serverIP].push(logEntry);
+    run.serverLogs[serverIP].push(logEntry);
 
     // Show log if no filter or matches current filter
-    if (!currentServerTab || currentServerTab === serverIP) {
+    if (runId === selectedRun && (!currentServerTab || currentServerTab === serverIP)) {
         appendToLogView(logEntry);
     }
 
-    if (liveLogs.length > 10000) {
-        liveLogs.shift();
+    if (run.logs.length > 10000) {
+        const dropped = run.logs.shift();
+        run.serverLogs[dropped.serverIP].shift();
     }
 }
 
@@ -516,9 +706,6 @@ function appendToLogView(logEntry) {
 }
 
 function addServerTab(serverIP, hostname) {
-    if (knownServers.has(serverIP)) return;
-    knownServers.add(serverIP);
-
     const tabsContainer = document.getElementById('serverTabs');
     if (!tabsContainer) return;
 
@@ -541,24 +728,37 @@ function switchServerTab(serverIP) {
     if (!logContent) return;
     logContent.innerHTML = '';
 
-    let logsToShow = serverLogs[serverIP] || [];
+    const run = runs[selectedRun];
+    let logsToShow = run?.serverLogs[serverIP] || [];
     logsToShow.forEach(log => appendToLogView(log));
 }
 
-function clearLiveLogs() {
-    liveLogs = [];
-    serverLogs = {};
-    knownServers.clear();
-    currentServerTab = null;
-
+// renderLogs shows the logs of the selected run
+function renderLogs() {
+    const run = runs[selectedRun];
+    const tabsContainer = document.getElementById('serverTabs');
+    if (tabsContainer) {
+        tabsContainer.innerHTML = '';
+    }
     if (elements.combinedLogContent) {
         elements.combinedLogContent.innerHTML = '';
     }
+    if (!run) return;
 
-    const tabsContainer = document.getElementById('serverTabs');
-    if (tabsContainer) {
-        tabsContainer.innerHTML = '';
+    Object.entries(run.servers).forEach(([ip, hostname]) => addServerTab(ip, hostname));
+    run.logs.forEach(log => appendToLogView(log));
+}
+
+// clearLiveLogs clears the logs of the selected run
+function clearLiveLogs() {
+    const run = runs[selectedRun];
+    if (run) {
+        run.logs = [];
+        run.serverLogs = {};
+        run.servers = {};
     }
+    currentServerTab = null;
+    renderLogs();
 }
 
 // ==================== Execution Control ====================
@@ -578,10 +778,9 @@ async function startExecution() {
         enablePwd = sameAsLogin ? password : (elements.enablePassword?.value || '');
     }
 
-    if (!username || !password) {
-        showError('Please enter username and password');
-        return;
-    }
+    // Without a username and password, servers use their own, their groups'
+    // or the selected credential profile; the backend reports any left without
+    const credentialProfile = document.getElementById('credentialProfile')?.value || '';
 
     const servers = getServersFromTable();
     if (servers.length === 0) {
@@ -595,24 +794,20 @@ async function startExecution() {
         return;
     }
 
-    clearLiveLogs();
-
     try {
         // Send servers and commands to backend
         await runtime.SetServers(servers);
         await runtime.SetCommands(commands);
-
-        const success = await runtime.StartExecution(username, password, timeout, enableMode, disablePaging, autoExportExcel, enablePwd, "");
-        if (success) {
-            setRunningState(true);
-            elements.resultsBody.innerHTML = '';
-            elements.progressSection.style.display = 'block';
-            elements.progressFill.style.width = '0%';
-            elements.progressText.textContent = '0 / 0';
-            elements.currentServer.textContent = '';
-            elements.summary.innerHTML = '';
+        await runtime.SetCredentialProfile(credentialProfile);
+        await runtime.SetRunPriority(document.getElementById('runPriority').value);
+
+        const started = await runtime.StartExecution(username, password, timeout, enableMode, disablePaging, autoExportExcel, enablePwd, "");
+        if (started?.runId) {
+            // Show the run just started even if another one is selected
+            const run = ensureRun(started.runId);
+            run.logDir = started.logDir;
+            selectRun(started.runId);
             setStatus('Running...');
-            updateConnectionInfo(`Executing on ${servers.length} servers`);
         }
     } catch (err) {
         showError('Failed to start: ' + err);
@@ -620,36 +815,134 @@ async function startExecution() {
 }
 
 async function stopExecution() {
+    if (!selectedRun) return;
     try {
-        await runtime.StopExecution();
-        setRunningState(false);
-        setStatus('Stopped');
-        updateConnectionInfo('Execution stopped');
+        if (await runtime.StopExecution(selectedRun)) {
+            elements.stopBtn.disabled = true;
+            setStatus('Stopping...');
+        }
     } catch (err) {
         showError('Failed to stop: ' + err);
     }
 }
 
+// ==================== Queue ====================
+
+const queuePriorities = [['high', 'High'], ['normal', 'Normal'], ['low', 'Low']];
+
+// loadQueue shows the runs waiting for a free SSH session
+async function loadQueue() {
+    try {
+        const queue = await runtime.GetQueue() || [];
+        document.getElementById('queueSection').style.display = queue.length > 0 ? 'block' : 'none';
+        document.getElementById('queueCount').textContent = queue.length;
+        document.getElementById('queueList').innerHTML = queue.map((q, i) => {
+            const name = q.scheduleName || (q.isManual ? 'Manual execution' : 'Task');
+            const queued = q.queuedAt ? `queued ${new Date(q.queuedAt).toLocaleTimeString()}` : '';
+            const expires = q.expires ? `, expires ${new Date(q.expires).toLocaleTimeString()}` : '';
+            return `
+                <div class="queue-item">
+                    <span class="queue-position">${q.position}</span>
+                    <div class="queue-item-info">
+                        <div>${escapeHtml(name)}${q.isManual ? '' : ' (scheduled)'}</div>
+                        <div class="queue-item-detail">${q.serverCount} server(s), ${q.commandCount} command(s), ${queued}${expires}</div>
+                    </div>
+                    <select onchange="setQueuePriority('${q.id}', this.value)" title="Priority">
+                        ${queuePriorities.map(([value, label]) => `<option value="${value}" ${q.priority === value ? 'selected' : ''}>${label}</option>`).join('')}
+                    </select>
+                    <button class="btn-secondary btn-small" onclick="moveQueueItem('${q.id}', -1)" ${i === 0 ? 'disabled' : ''} title="Move up">↑</button>
+                    <button class="btn-secondary btn-small" onclick="moveQueueItem('${q.id}', 1)" ${i === queue.length - 1 ? 'disabled' : ''} title="Move down">↓</button>
+                    <button class="btn-danger btn-small" onclick="cancelQueueItem('${q.id}')" title="Cancel">&times;</button>
+                </div>
+            `;
+        }).join('');
+    } catch (err) {
+        console.error('Failed to load queue:', err);
+    }
+}
+
+async function cancelQueueItem(id) {
+    try {
+        await runtime.CancelQueueItem(id);
+    } catch (err) {
+        showToast('Failed to cancel queued run: ' + err, 'error');
+    }
+    loadQueue();
+}
+
+async function moveQueueItem(id, offset) {
+    try {
+        await runtime.MoveQueueItem(id, offset);
+    } catch (err) {
+        showToast('Failed to move queued run: ' + err, 'error');
+    }
+    loadQueue();
+}
+
+async function setQueuePriority(id, priority) {
+    try {
+        await runtime.SetQueuePriority(id, priority);
+    } catch (err) {
+        showToast('Failed to change priority: ' + err, 'error');
+    }
+    loadQueue();
+}
+
+async function clearQueue() {
+    if (!confirm('Cancel all queued runs?')) {
+        return;
+    }
+    try {
+        await runtime.ClearQueue();
+    } catch (err) {
+        showToast('Failed to clear queue: ' + err, 'error');
+    }
+    loadQueue();
+}
+
+window.cancelQueueItem = cancelQueueItem;
+window.moveQueueItem = moveQueueItem;
+window.setQueuePriority = setQueuePriority;
+window.clearQueue = clearQueue;
+
 // ==================== Event Handlers ====================
 
 function handleProgress(data) {
-    const { current, total, hostname, ip, status } = data;
-    const percent = (current / total) * 100;
-
-    elements.progressFill.style.width = percent + '%';
-    elements.progressText.textContent = `${current} / ${total}`;
+    const { runId, current, total, hostname, ip, status } = data;
+    const run = ensureRun(runId);
 
+    run.progress.percent = (current / total) * 100;
+    run.progress.text = `${current} / ${total}`;
     if (status === 'connecting') {
-        elements.currentServer.textContent = `Connecting to ${hostname} (${ip})...`;
-        updateConnectionInfo(`Connecting to ${hostname}`);
+        run.progress.current = `Connecting to ${hostname} (${ip})...`;
     } else if (status === 'success') {
-        elements.currentServer.textContent = `${hostname}: Success`;
+        run.progress.current = `${hostname}: Success`;
     } else if (status === 'failed') {
-        elements.currentServer.textContent = `${hostname}: Failed`;
+        run.progress.current = `${hostname}: Failed`;
+    }
+
+    if (runId === selectedRun) {
+        renderProgress();
     }
 }
 
+// renderProgress shows the progress of the selected run
+function renderProgress() {
+    const run = runs[selectedRun];
+    elements.progressFill.style.width = (run ? run.progress.percent : 0) + '%';
+    elements.progressText.textContent = run ? run.progress.text : '0 / 0';
+    elements.currentServer.textContent = run ? run.progress.current : '';
+}
+
 function handleResult(data) {
+    const run = ensureRun(data.runId);
+    run.results.push(data);
+    if (data.runId === selectedRun) {
+        appendResultRow(data);
+    }
+}
+
+function appendResultRow(data) {
     const { hostname, ip, success, error, logPath, duration } = data;
 
     const row = document.createElement('tr');
@@ -666,26 +959,43 @@ function handleResult(data) {
     elements.resultsBody.appendChild(row);
 }
 
-function handleCompleted(data) {
-    const { success, fail, total, logDir, autoExportExcel } = data;
-
-    setRunningState(false);
-
-    elements.summary.innerHTML = `
-        <span class="success">Success: ${success}</span> |
-        <span class="fail">Failed: ${fail}</span> |
-        Total: ${total} |
-        Logs: ${logDir}
-    `;
+// renderResults shows the results and summary of the selected run
+function renderResults() {
+    const run = runs[selectedRun];
+    elements.resultsBody.innerHTML = '';
+    elements.summary.innerHTML = '';
+    if (!run) return;
+
+    run.results.forEach(appendResultRow);
+    if (run.summary) {
+        const { success, fail, total, logDir } = run.summary;
+        elements.summary.innerHTML = `
+            <span class="success">Success: ${success}</span> |
+            <span class="fail">Failed: ${fail}</span> |
+            Total: ${total} |
+            Logs: ${escapeHtml(logDir)}
+        `;
+    }
+}
 
-    setStatus(`Completed: ${success} success, ${fail} failed`);
-    updateConnectionInfo('No active connections');
+function handleCompleted(data) {
+    const { runId, success, fail, total, autoExportExcel } = data;
+    const run = ensureRun(runId);
+    run.summary = data;
 
     // Auto export Excel if enabled (from event data)
     if (autoExportExcel) {
-        exportResults();
+        exportResults(true, runId);
+    }
+
+    if (runId !== selectedRun) {
+        showToast(`${runLabel(run)}: ${success} success, ${fail} failed`, fail > 0 ? 'warning' : 'success');
+        return;
     }
 
+    renderResults();
+    setStatus(`Completed: ${success} success, ${fail} failed (${total} total)`);
+
     // Auto switch to results section
     showSection('results');
 }
@@ -696,11 +1006,15 @@ function handleError(message) {
 
 // ==================== Log Viewer ====================
 
+let viewedLogPath = '';
+
 async function viewLog(path, hostname) {
     try {
         const content = await runtime.ReadLogFile(path);
+        viewedLogPath = path;
         elements.logTitle.textContent = hostname + '.log';
         elements.logContent.textContent = content;
+        document.getElementById('logViewerFooter').style.display = path.endsWith('.enc') ? 'flex' : 'none';
         elements.logViewerModal.style.display = 'flex';
     } catch (err) {
         showError('Failed to read log: ' + err);
@@ -711,18 +1025,42 @@ function closeLogViewer() {
     elements.logViewerModal.style.display = 'none';
 }
 
+async function exportDecryptedLog() {
+    await exportDecryptedCopy(viewedLogPath);
+}
+
+async function exportDecryptedCopy(path) {
+    try {
+        const saved = await runtime.ExportDecryptedCopy(path);
+        if (saved) {
+            showToast('Decrypted copy saved: ' + saved, 'success', 5000);
+        }
+    } catch (err) {
+        showError('Failed to export decrypted copy: ' + err);
+    }
+}
+
 async function openLogsFolder() {
     try {
-        await runtime.OpenLogsFolder();
+        await runtime.OpenLogsFolder(selectedRun || '');
     } catch (err) {
         showError('Failed to open logs folder: ' + err);
     }
 }
 
-async function exportResults() {
+async function exportResults(auto = false, runId = selectedRun) {
+    if (!runId) {
+        showError('No results to export');
+        return;
+    }
     try {
-        const path = await runtime.ExportResults();
-        if (path) {
+        const path = await runtime.ExportResults(runId);
+        if (path && path.endsWith('.enc')) {
+            showToast('Excel exported (encrypted): ' + path, 'success', 5000);
+            if (!auto && confirm('The Excel report is stored encrypted. Export a decrypted copy?')) {
+                await exportDecryptedCopy(path);
+            }
+        } else if (path) {
             showToast('Excel exported: ' + path, 'success', 5000);
         } else {
             showError('No results to export');
@@ -734,33 +1072,6 @@ async function exportResults() {
 
 // ==================== UI Helpers ====================
 
-function setRunningState(running) {
-    elements.runBtn.disabled = running;
-    elements.stopBtn.disabled = !running;
-    elements.username.disabled = running;
-    elements.password.disabled = running;
-    elements.timeout.disabled = running;
-    if (elements.enableMode) elements.enableMode.disabled = running;
-    if (elements.disablePaging) elements.disablePaging.disabled = running;
-    if (elements.autoExportExcel) elements.autoExportExcel.disabled = running;
-    if (elements.samePassword) elements.samePassword.disabled = running;
-    if (elements.enablePassword) elements.enablePassword.disabled = running;
-
-    // Update status dot
-    if (elements.statusDot) {
-        elements.statusDot.className = 'status-dot' + (running ? ' running' : '');
-    }
-
-    // Disable server table inputs
-    const serverInputs = elements.serversBody?.querySelectorAll('input, button') || [];
-    serverInputs.forEach(el => el.disabled = running);
-
-    // Disable commands textarea
-    if (elements.commandsInput) {
-        elements.commandsInput.disabled = running;
-    }
-}
-
 function setStatus(text) {
     elements.statusText.textContent = text;
 }
@@ -795,10 +1106,12 @@ window.updateServerCount = updateServerCount;
 window.startExecution = startExecution;
 window.stopExecution = stopExecution;
 window.viewLog = viewLog;
+window.exportDecryptedLog = exportDecryptedLog;
 window.closeLogViewer = closeLogViewer;
 window.openLogsFolder = openLogsFolder;
 window.switchServerTab = switchServerTab;
 window.clearLiveLogs = clearLiveLogs;
+window.selectRun = selectRun;
 window.checkForUpdates = checkForUpdates;
 window.closeUpdateModal = closeUpdateModal;
 window.downloadUpdate = downloadUpdate;
@@ -843,24 +1156,26 @@ function renderSchedules() {
         const typeLabels = {
             daily: 'Daily',
             weekly: 'Weekly',
-            monthly: 'Monthly'
+            monthly: 'Monthly',
+            interval: 'Interval',
+            cron: 'Cron',
+            once: 'Once',
+            after: 'After'
         };
 
-        const daysOfWeek = ['Sun', 'Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat'];
-        let scheduleDesc = schedule.time;
-        if (schedule.scheduleType === 'weekly' && schedule.daysOfWeek) {
-            const days = schedule.daysOfWeek.map(d => daysOfWeek[d]).join(', ');
-            scheduleDesc = `${schedule.time} (${days})`;
-        } else if (schedule.scheduleType === 'monthly') {
-            scheduleDesc = `${schedule.time} (Day ${schedule.dayOfMonth})`;
-        }
+        const scheduleDesc = escapeHtml(schedule.description || schedule.time);
 
-        const nextRun = schedule.nextRun ? new Date(schedule.nextRun).toLocaleString() : '-';
-        const lastRun = schedule.lastRun ? new Date(schedule.lastRun).toLocaleString() : '-';
+        const nextRun = schedule.expired
+            ? '<span class="schedule-expired-badge">Expired</span>'
+            : formatScheduleTime(schedule.nextRun, schedule.nextRunZoned);
+        if (schedule.expired) {
+            row.classList.add('schedule-expired');
+        }
+        const lastRun = formatScheduleTime(schedule.lastRun, schedule.lastRunZoned);
 
         row.innerHTML = `
             <td>${escapeHtml(schedule.name)}</td>
-            <td>${typeLabels[schedule.scheduleType] || schedule.scheduleType}</td>
+            <td>${escapeHtml(typeLabels[schedule.scheduleType] || schedule.scheduleType)}</td>
             <td>${scheduleDesc}</td>
             <td>${nextRun}</td>
             <td>${lastRun}</td>
@@ -882,6 +1197,22 @@ function renderSchedules() {
     });
 }
 
+// formatScheduleTime shows a run time in this computer's zone and, for
+// schedules with their own time zone, in that zone below it
+function formatScheduleTime(time, zoned) {
+    if (!time) return '-';
+    const local = escapeHtml(new Date(time).toLocaleString());
+    return zoned ? `${local}<div class="schedule-zoned-time">${escapeHtml(zoned)}</div>` : local;
+}
+
+// fillTimeZoneList offers the time zones known to the browser engine
+function fillTimeZoneList() {
+    const list = document.getElementById('timeZoneList');
+    if (!list || list.options.length > 0 || typeof Intl.supportedValuesOf !== 'function') return;
+    list.innerHTML = Intl.supportedValuesOf('timeZone')
+        .map(tz => `<option value="${escapeHtml(tz)}"></option>`).join('');
+}
+
 function showScheduleForm(scheduleId = null) {
     const modal = document.getElementById('scheduleModal');
     const title = document.getElementById('scheduleModalTitle');
@@ -892,6 +1223,7 @@ function showScheduleForm(scheduleId = null) {
     document.getElementById('scheduleFormUsername').value = '';
     document.getElementById('scheduleFormPassword').value = '';
     document.getElementById('scheduleFormEnablePassword').value = '';
+    document.getElementById('scheduleFormCredentialProfile').value = '';
     document.querySelector('input[name="scheduleType"][value="daily"]').checked = true;
     document.getElementById('scheduleTime').value = '09:00';
     document.getElementById('scheduleTimeout').value = '1';
@@ -906,11 +1238,32 @@ function showScheduleForm(scheduleId = null) {
     document.getElementById('scheduleEmailTo').value = '';
     document.getElementById('emailOptions').style.display = 'none';
 
+    // Reset redaction fields
+    document.getElementById('scheduleRedactLogs').checked = false;
+    document.getElementById('scheduleRedactEmail').checked = false;
+    document.getElementById('scheduleKeepUnredacted').checked = false;
+
     // Reset days checkboxes
     document.querySelectorAll('.days-selector input[type="checkbox"]').forEach((cb, i) => {
         cb.checked = (i >= 1 && i <= 5); // Mon-Fri default
     });
     document.getElementById('dayOfMonth').value = '1';
+    document.getElementById('scheduleInterval').value = '15';
+    document.getElementById('scheduleIntervalUnit').value = 'minutes';
+    document.getElementById('scheduleCronExpression').value = '';
+    document.getElementById('scheduleRunAt').value = '';
+    document.getElementById('scheduleStartDate').value = '';
+    document.getElementById('scheduleEndDate').value = '';
+    document.getElementById('scheduleTimeZone').value = '';
+    document.getElementById('scheduleMissedRunPolicy').value = 'skip';
+    document.getElementById('scheduleNotifyMissed').checked = false;
+    document.getElementById('scheduleBlackoutPolicy').value = 'skip';
+    document.getElementById('scheduleOverlapPolicy').value = 'queue';
+    document.getElementById('schedulePriority').value = 'normal';
+    document.getElementById('scheduleMaxQueueWait').value = '0';
+    renderBlackoutEditor('scheduleBlackoutEditor', []);
+    document.getElementById('scheduleAfterList').innerHTML = '';
+    fillTimeZoneList();
 
     updateScheduleOptions();
 
@@ -933,6 +1286,7 @@ function populateScheduleForm(schedule) {
     document.getElementById('scheduleFormUsername').value = schedule.username || '';
     document.getElementById('scheduleFormPassword').value = schedule.password || '';
     document.getElementById('scheduleFormEnablePassword').value = schedule.enablePassword || '';
+    document.getElementById('scheduleFormCredentialProfile').value = schedule.credentialProfile || '';
     document.querySelector(`input[name="scheduleType"][value="${schedule.scheduleType}"]`).checked = true;
     document.getElementById('scheduleTime').value = schedule.time;
     document.getElementById('scheduleTimeout').value = schedule.timeout || 1;
@@ -948,12 +1302,34 @@ function populateScheduleForm(schedule) {
     if (schedule.dayOfMonth) {
         document.getElementById('dayOfMonth').value = schedule.dayOfMonth;
     }
+    if (schedule.interval) {
+        document.getElementById('scheduleInterval').value = schedule.interval;
+        document.getElementById('scheduleIntervalUnit').value = schedule.intervalUnit || 'minutes';
+    }
+    document.getElementById('scheduleCronExpression').value = schedule.cronExpression || '';
+    document.getElementById('scheduleRunAt').value = schedule.runAt || '';
+    document.getElementById('scheduleStartDate').value = schedule.startDate || '';
+    document.getElementById('scheduleEndDate').value = schedule.endDate || '';
+    document.getElementById('scheduleTimeZone').value = schedule.timeZone || '';
+    document.getElementById('scheduleMissedRunPolicy').value = schedule.missedRunPolicy || 'skip';
+    document.getElementById('scheduleNotifyMissed').checked = schedule.notifyMissed || false;
+    document.getElementById('scheduleBlackoutPolicy').value = schedule.blackoutPolicy || 'skip';
+    document.getElementById('scheduleOverlapPolicy').value = schedule.overlapPolicy || 'queue';
+    document.getElementById('scheduleMaxQueueWait').value = schedule.maxQueueWait || 0;
+    document.getElementById('schedulePriority').value = schedule.priority || 'normal';
+    renderBlackoutEditor('scheduleBlackoutEditor', schedule.blackouts || []);
+    (schedule.after || []).forEach(d => addScheduleDependency(d.taskId, d.condition));
 
     // Email notification
     document.getElementById('scheduleEmailEnabled').checked = schedule.emailEnabled || false;
     document.getElementById('scheduleEmailTo').value = schedule.emailTo || '';
     toggleEmailOptions();
 
+    // Secret redaction
+    document.getElementById('scheduleRedactLogs').checked = schedule.redactLogs || false;
+    document.getElementById('scheduleRedactEmail').checked = schedule.redactEmail || false;
+    document.getElementById('scheduleKeepUnredacted').checked = schedule.keepUnredacted || false;
+
     // Populate servers
     const tbody = document.getElementById('scheduleServersBody');
     tbody.innerHTML = '';
@@ -976,7 +1352,8 @@ function closeScheduleForm() {
 }
 
 function toggleEmailOptions() {
-    const enabled = document.getElementById('scheduleEmailEnabled').checked;
+    const enabled = document.getElementById('scheduleEmailEnabled').checked ||
+        document.getElementById('scheduleNotifyMissed').checked;
     document.getElementById('emailOptions').style.display = enabled ? 'block' : 'none';
 }
 
@@ -984,6 +1361,65 @@ function updateScheduleOptions() {
     const type = document.querySelector('input[name="scheduleType"]:checked')?.value;
     document.getElementById('weeklyOptions').style.display = type === 'weekly' ? 'block' : 'none';
     document.getElementById('monthlyOptions').style.display = type === 'monthly' ? 'block' : 'none';
+    document.getElementById('intervalOptions').style.display = type === 'interval' ? 'block' : 'none';
+    document.getElementById('cronOptions').style.display = type === 'cron' ? 'block' : 'none';
+    document.getElementById('onceOptions').style.display = type === 'once' ? 'block' : 'none';
+    document.getElementById('afterOptions').style.display = type === 'after' ? 'block' : 'none';
+    document.getElementById('dateRangeOptions').style.display = (type === 'once' || type === 'after') ? 'none' : 'block';
+    document.getElementById('scheduleTimeZoneGroup').style.display = type === 'after' ? 'none' : '';
+    document.getElementById('scheduleMissedGroup').style.display = type === 'after' ? 'none' : '';
+    document.getElementById('scheduleTimeRow').style.display = (type === 'cron' || type === 'once' || type === 'after') ? 'none' : '';
+    document.getElementById('scheduleTimeLabel').textContent = type === 'interval' ? 'Anchor Time' : 'Time';
+    document.getElementById('schedulePreviewResult').innerHTML = '';
+}
+
+// addScheduleDependency adds a "run after" row listing the other schedules
+function addScheduleDependency(taskId = '', condition = 'success') {
+    const currentId = document.getElementById('scheduleId').value;
+    const options = schedules
+        .filter(s => s.id !== currentId)
+        .map(s => `<option value="${escapeHtml(s.id)}" ${s.id === taskId ? 'selected' : ''}>${escapeHtml(s.name)}</option>`)
+        .join('');
+    if (!options) {
+        showToast('Create the schedules to run after first', 'warning');
+        return;
+    }
+
+    const row = document.createElement('div');
+    row.className = 'form-row schedule-dependency';
+    row.innerHTML = `
+        <div class="form-group">
+            <select class="dependency-task">${options}</select>
+        </div>
+        <div class="form-group">
+            <select class="dependency-condition">
+                <option value="success">Succeeds</option>
+                <option value="failure">Fails</option>
+                <option value="always">Finishes (any result)</option>
+            </select>
+        </div>
+        <button type="button" class="btn-icon-only danger" onclick="this.parentElement.remove()" title="Remove">✕</button>`;
+    row.querySelector('.dependency-condition').value = condition || 'success';
+    document.getElementById('scheduleAfterList').appendChild(row);
+}
+
+async function previewSchedule() {
+    const result = document.getElementById('schedulePreviewResult');
+    try {
+        const preview = await runtime.PreviewSchedule(getScheduleFormData(), 5);
+        if (!preview.success) {
+            result.innerHTML = `<span class="error">${escapeHtml(preview.error)}</span>`;
+            return;
+        }
+        const runs = (preview.nextRuns || []).map((r, i) => {
+            const zoned = preview.nextRunsZoned?.[i];
+            return `<li>${escapeHtml(new Date(r).toLocaleString())}${zoned ? ` (${escapeHtml(zoned)})` : ''}</li>`;
+        }).join('');
+        result.innerHTML = `<strong>${escapeHtml(preview.description)}</strong>` +
+            (runs ? `<ul>${runs}</ul>` : '<div>No upcoming runs</div>');
+    } catch (err) {
+        result.innerHTML = `<span class="error">${escapeHtml(String(err))}</span>`;
+    }
 }
 
 function addScheduleServerRow(ip = '', hostname = '', creds = null) {
@@ -1028,6 +1464,7 @@ function getScheduleFormData() {
     const username = document.getElementById('scheduleFormUsername').value.trim();
     const password = document.getElementById('scheduleFormPassword').value;
     const enablePassword = document.getElementById('scheduleFormEnablePassword').value;
+    const credentialProfile = document.getElementById('scheduleFormCredentialProfile').value;
     const scheduleType = document.querySelector('input[name="scheduleType"]:checked')?.value;
     const time = document.getElementById('scheduleTime').value;
     const timeout = parseInt(document.getElementById('scheduleTimeout').value) || 1;
@@ -1039,6 +1476,11 @@ function getScheduleFormData() {
     const emailEnabled = document.getElementById('scheduleEmailEnabled').checked;
     const emailTo = document.getElementById('scheduleEmailTo').value.trim();
 
+    // Secret redaction
+    const redactLogs = document.getElementById('scheduleRedactLogs').checked;
+    const redactEmail = document.getElementById('scheduleRedactEmail').checked;
+    const keepUnredacted = document.getElementById('scheduleKeepUnredacted').checked;
+
     // Get days of week
     const daysOfWeek = [];
     document.querySelectorAll('.days-selector input[type="checkbox"]:checked').forEach(cb => {
@@ -1046,6 +1488,30 @@ function getScheduleFormData() {
     });
 
     const dayOfMonth = parseInt(document.getElementById('dayOfMonth').value) || 1;
+    const interval = parseInt(document.getElementById('scheduleInterval').value) || 0;
+    const intervalUnit = document.getElementById('scheduleIntervalUnit').value;
+    const cronExpression = document.getElementById('scheduleCronExpression').value.trim();
+    const isOnce = scheduleType === 'once';
+    const runAt = isOnce ? document.getElementById('scheduleRunAt').value : '';
+    const startDate = isOnce ? '' : document.getElementById('scheduleStartDate').value;
+    const endDate = isOnce ? '' : document.getElementById('scheduleEndDate').value;
+    const timeZone = document.getElementById('scheduleTimeZone').value.trim();
+    const missedRunPolicy = document.getElementById('scheduleMissedRunPolicy').value;
+    const notifyMissed = document.getElementById('scheduleNotifyMissed').checked;
+    const blackoutPolicy = document.getElementById('scheduleBlackoutPolicy').value;
+    const blackouts = blackoutEditors['scheduleBlackoutEditor'] || [];
+    const overlapPolicy = document.getElementById('scheduleOverlapPolicy').value;
+    const maxQueueWait = parseInt(document.getElementById('scheduleMaxQueueWait').value) || 0;
+    const priority = document.getElementById('schedulePriority').value;
+    const after = [];
+    if (scheduleType === 'after') {
+        document.querySelectorAll('#scheduleAfterList .schedule-dependency').forEach(row => {
+            after.push({
+                taskId: row.querySelector('.dependency-task').value,
+                condition: row.querySelector('.dependency-condition').value
+            });
+        });
+    }
 
     // Get servers
     const servers = [];
@@ -1074,10 +1540,26 @@ function getScheduleFormData() {
         username,
         password,
         enablePassword,
+        credentialProfile,
         scheduleType,
         time,
         daysOfWeek,
         dayOfMonth,
+        interval,
+        intervalUnit,
+        cronExpression,
+        runAt,
+        startDate,
+        endDate,
+        timeZone,
+        missedRunPolicy,
+        notifyMissed,
+        blackoutPolicy,
+        blackouts,
+        after,
+        overlapPolicy,
+        maxQueueWait,
+        priority,
         servers,
         commands,
         timeout,
@@ -1086,6 +1568,9 @@ function getScheduleFormData() {
         enableMode,
         emailEnabled,
         emailTo,
+        redactLogs,
+        redactEmail,
+        keepUnredacted,
         enabled: true
     };
 }
@@ -1097,10 +1582,6 @@ async function saveSchedule() {
         showToast('Please enter a schedule name', 'warning');
         return;
     }
-    if (!data.username || !data.password) {
-        showToast('Please enter username and password', 'warning');
-        return;
-    }
     if (data.servers.length === 0) {
         showToast('Please add at least one server', 'warning');
         return;
@@ -1113,6 +1594,29 @@ async function saveSchedule() {
         showToast('Please select at least one day of the week', 'warning');
         return;
     }
+    if (data.scheduleType === 'cron' && !data.cronExpression) {
+        showToast('Please enter a cron expression', 'warning');
+        return;
+    }
+
+    if (data.scheduleType === 'once' && !data.runAt) {
+        showToast('Please enter the date and time to run', 'warning');
+        return;
+    }
+
+    if (data.scheduleType === 'after' && data.after.length === 0) {
+        showToast('Please add at least one schedule to run after', 'warning');
+        return;
+    }
+
+    const preview = await runtime.PreviewSchedule(data, 1);
+    if (!preview.success) {
+        showToast('Invalid schedule: ' + preview.error, 'warning');
+        return;
+    }
+    if (data.scheduleType !== 'after' && preview.nextRuns.length === 0 && !confirm('This schedule will never run (its time has passed). Save anyway?')) {
+        return;
+    }
 
     try {
         if (data.id) {
@@ -1191,6 +1695,60 @@ function setupScheduleEventListeners() {
             const name = data.scheduleName || 'Queued task';
             showToast(`Starting queued task: ${name} (${data.remaining} remaining)`, 'info');
         });
+
+        window.runtime.EventsOn('scheduleDeferred', (data) => {
+            showToast(`Schedule "${data.taskName}" deferred: ${data.reason}`, 'info');
+        });
+
+        window.runtime.EventsOn('chainCompleted', (data) => {
+            const result = data.success ? 'succeeded' : 'finished with failures';
+            showToast(`Chain started by "${data.schedule}" ${result} (${data.runs} runs)`, data.success ? 'success' : 'warning');
+        });
+
+        window.runtime.EventsOn('execApproval', approveExecCommand);
+        window.runtime.EventsOn('scheduleMissed', showMissedRuns);
+        window.runtime.EventsOn('queueRestored', showRestoredQueue);
+    }
+    // Runs missed before the app started were found before we listened,
+    // and the queue of the last session was restored
+    showMissedRuns();
+    showRestoredQueue();
+}
+
+// showMissedRuns reports runs that were due while the app was closed
+async function showMissedRuns() {
+    try {
+        const missed = await runtime.TakeMissedRuns() || [];
+        missed.forEach(m => {
+            const count = m.truncated ? `${m.count}+` : m.count;
+            const last = new Date(m.last).toLocaleString();
+            showToast(`Schedule "${m.taskName}" missed ${count} run(s) while the app was closed (last ${last}). ${m.action}.`, 'warning');
+        });
+        if (missed.length > 0) {
+            loadSchedules();
+        }
+    } catch (err) {
+        console.error('Failed to get missed runs:', err);
+    }
+}
+
+// Fill every credential profile dropdown, keeping the current selections
+async function loadCredentialProfiles() {
+    try {
+        const profiles = await runtime.GetCredentialProfiles() || [];
+        document.querySelectorAll('.credential-profile-select').forEach(select => {
+            const current = select.value;
+            select.innerHTML = '<option value="">None</option>';
+            profiles.forEach(p => {
+                const option = document.createElement('option');
+                option.value = p.name;
+                option.textContent = p.username ? `${p.name} (${p.username})` : p.name;
+                select.appendChild(option);
+            });
+            select.value = current;
+        });
+    } catch (err) {
+        console.error('Failed to load credential profiles:', err);
     }
 }
 
@@ -1198,12 +1756,15 @@ function setupScheduleEventListeners() {
 document.addEventListener('DOMContentLoaded', () => {
     setTimeout(setupScheduleEventListeners, 100);
     setTimeout(loadSavedServerList, 200);
+    setTimeout(loadCredentialProfiles, 200);
 });
 
 // Expose schedule functions
 window.showScheduleForm = showScheduleForm;
 window.closeScheduleForm = closeScheduleForm;
 window.updateScheduleOptions = updateScheduleOptions;
+window.previewSchedule = previewSchedule;
+window.addScheduleDependency = addScheduleDependency;
 window.addScheduleServerRow = addScheduleServerRow;
 window.copyServersFromExecution = copyServersFromExecution;
 window.copyCommandsFromExecution = copyCommandsFromExecution;
@@ -1412,7 +1973,885 @@ async function saveSmtpSettings() {
     }
 }
 
+// ==================== Redaction ====================
+
+// Names of the loaded custom rules by pattern, so they survive a save
+let redactionRuleNames = {};
+
+function getRedactionSettingsForm() {
+    const rules = document.getElementById('redactionRules').value
+        .split('\n')
+        .map(line => line.trim())
+        .filter(line => line)
+        .map((pattern, i) => ({ name: redactionRuleNames[pattern] || `Custom rule ${i + 1}`, pattern }));
+    return {
+        disableBuiltin: !document.getElementById('redactionUseBuiltin').checked,
+        rules,
+        redactManualRuns: document.getElementById('redactionManualRuns').checked,
+        keepUnredactedManual: document.getElementById('redactionKeepManual').checked
+    };
+}
+
+async function showRedactionSettings() {
+    const data = await runtime.LoadRedactionSettings();
+    document.getElementById('redactionUseBuiltin').checked = !data.disableBuiltin;
+    document.getElementById('redactionBuiltinCount').textContent = (data.builtinRules || []).length;
+    redactionRuleNames = {};
+    (data.rules || []).forEach(r => { redactionRuleNames[r.pattern] = r.name; });
+    document.getElementById('redactionRules').value = (data.rules || []).map(r => r.pattern).join('\n');
+    document.getElementById('redactionManualRuns').checked = data.redactManualRuns || false;
+    document.getElementById('redactionKeepManual').checked = data.keepUnredactedManual || false;
+    document.getElementById('redactionPreview').style.display = 'none';
+    document.getElementById('redactionModal').style.display = 'flex';
+}
+
+function closeRedactionSettings() {
+    document.getElementById('redactionModal').style.display = 'none';
+}
+
+async function saveRedactionSettings() {
+    try {
+        if (await runtime.SaveRedactionSettings(getRedactionSettingsForm())) {
+            showToast('Redaction settings saved.', 'success');
+            closeRedactionSettings();
+        }
+    } catch (err) {
+        showToast('Failed to save redaction settings: ' + err, 'error');
+    }
+}
+
+async function previewRedaction() {
+    const sample = document.getElementById('redactionSample').value;
+    if (!sample.trim()) {
+        showToast('Paste sample output to preview', 'warning');
+        return;
+    }
+    const result = await runtime.PreviewRedaction(getRedactionSettingsForm(), sample);
+    if (!result.success) {
+        showToast('Invalid pattern: ' + result.error, 'error');
+        return;
+    }
+    const preview = document.getElementById('redactionPreview');
+    preview.textContent = result.text;
+    preview.style.display = 'block';
+}
+
+window.showRedactionSettings = showRedactionSettings;
+window.closeRedactionSettings = closeRedactionSettings;
+window.saveRedactionSettings = saveRedactionSettings;
+window.previewRedaction = previewRedaction;
+
+// ==================== Blackout Windows ====================
+
+// Blackout windows being edited, by editor container ID
+const blackoutEditors = {};
+
+const blackoutDayNames = ['Sun', 'Mon', 'Tue', 'Wed', 'Thu', 'Fri', 'Sat'];
+
+async function showBlackoutSettings() {
+    try {
+        renderBlackoutEditor('blackoutEditor', await runtime.GetBlackouts() || []);
+        document.getElementById('blackoutModal').style.display = 'flex';
+    } catch (err) {
+        showToast('Failed to load blackout windows: ' + err, 'error');
+    }
+}
+
+function closeBlackoutSettings() {
+    document.getElementById('blackoutModal').style.display = 'none';
+}
+
+async function saveBlackoutSettings() {
+    try {
+        if (await runtime.SaveBlackouts(blackoutEditors['blackoutEditor'])) {
+            showToast('Blackout windows saved.', 'success');
+            closeBlackoutSettings();
+        }
+    } catch (err) {
+        showToast('Failed to save blackout windows: ' + err, 'error');
+    }
+}
+
+async function importBlackoutCalendar() {
+    try {
+        const result = await runtime.ImportBlackoutCalendar();
+        if (!result) {
+            return;
+        }
+        if (!result.success) {
+            showToast('Failed to import calendar: ' + result.error, 'error');
+            return;
+        }
+        const replaced = result.replaced ? `, replaced ${result.replaced}` : '';
+        showToast(`Imported ${result.imported} window(s) from ${result.source}${replaced}.`, 'success');
+        renderBlackoutEditor('blackoutEditor', await runtime.GetBlackouts() || []);
+    } catch (err) {
+        showToast('Failed to import calendar: ' + err, 'error');
+    }
+}
+
+// describeBlackout returns a short description of a blackout window
+function describeBlackout(b) {
+    if (b.type === 'weekly') {
+        const days = b.daysOfWeek && b.daysOfWeek.length > 0 && b.daysOfWeek.length < 7
+            ? b.daysOfWeek.map(d => blackoutDayNames[d]).join(', ')
+            : 'Every day';
+        return `${days} ${b.startTime}-${b.endTime}`;
+    }
+    return `${(b.start || '').replace('T', ' ')} to ${(b.end || '').replace('T', ' ')}`;
+}
+
+// renderBlackoutEditor shows a list of blackout windows with a form to add more
+function renderBlackoutEditor(containerId, list) {
+    blackoutEditors[containerId] = list.map(b => ({
+        name: b.name,
+        type: b.type,
+        daysOfWeek: b.daysOfWeek || [],
+        startTime: b.startTime || '',
+        endTime: b.endTime || '',
+        start: b.start || '',
+        end: b.end || '',
+        source: b.source || ''
+    }));
+
+    const p = containerId;
+    const days = blackoutDayNames.map((name, i) =>
+        `<label class="day-checkbox"><input type="checkbox" value="${i}"> ${name}</label>`).join('');
+
+    document.getElementById(containerId).innerHTML = `
+        <div class="blackout-list" id="${p}-list"></div>
+        <div class="form-row">
+            <div class="form-group">
+                <label>Name</label>
+                <input type="text" id="${p}-name" placeholder="Change freeze">
+            </div>
+            <div class="form-group form-group-small">
+                <label>Type</label>
+                <select id="${p}-type" onchange="updateBlackoutType('${p}')">
+                    <option value="weekly">Weekly</option>
+                    <option value="range">Date Range</option>
+                </select>
+            </div>
+        </div>
+        <div id="${p}-weekly">
+            <div class="blackout-days" id="${p}-days">${days}</div>
+            <div class="form-row">
+                <div class="form-group">
+                    <label>From</label>
+                    <input type="time" id="${p}-startTime" value="00:00">
+                </div>
+                <div class="form-group">
+                    <label>To</label>
+                    <input type="time" id="${p}-endTime" value="06:00">
+                </div>
+            </div>
+            <p class="form-hint">A window that ends at or before its start time ends the next day. No days selected means every day.</p>
+        </div>
+        <div id="${p}-range" style="display: none;">
+            <div class="form-row">
+                <div class="form-group">
+                    <label>From</label>
+                    <input type="datetime-local" id="${p}-start">
+                </div>
+                <div class="form-group">
+                    <label>To</label>
+                    <input type="datetime-local" id="${p}-end">
+                </div>
+            </div>
+        </div>
+        <button type="button" class="btn-secondary btn-small" onclick="addBlackout('${p}')">Add Window</button>`;
+
+    renderBlackoutList(containerId);
+}
+
+function renderBlackoutList(containerId) {
+    const list = blackoutEditors[containerId];
+    const el = document.getElementById(`${containerId}-list`);
+    if (list.length === 0) {
+        el.innerHTML = '<div class="blackout-empty">No blackout windows</div>';
+        return;
+    }
+    el.innerHTML = list.map((b, i) => `
+        <div class="blackout-item">
+            <div class="blackout-item-info">
+                <div>${escapeHtml(b.name)}</div>
+                <div class="blackout-item-detail">${escapeHtml(describeBlackout(b))}${b.source ? ' &middot; ' + escapeHtml(b.source) : ''}</div>
+            </div>
+            <button type="button" class="btn-secondary btn-small" onclick="removeBlackout('${containerId}', ${i})">Delete</button>
+        </div>`).join('');
+}
+
+function updateBlackoutType(containerId) {
+    const type = document.getElementById(`${containerId}-type`).value;
+    document.getElementById(`${containerId}-weekly`).style.display = type === 'weekly' ? 'block' : 'none';
+    document.getElementById(`${containerId}-range`).style.display = type === 'range' ? 'block' : 'none';
+}
+
+function addBlackout(containerId) {
+    const value = field => document.getElementById(`${containerId}-${field}`).value.trim();
+    const b = { name: value('name'), type: value('type'), daysOfWeek: [], startTime: '', endTime: '', start: '', end: '', source: '' };
+
+    if (!b.name) {
+        showToast('Please enter a name for the blackout window', 'warning');
+        return;
+    }
+    if (b.type === 'weekly') {
+        document.querySelectorAll(`#${containerId}-days input:checked`).forEach(cb => {
+            b.daysOfWeek.push(parseInt(cb.value));
+        });
+        b.startTime = value('startTime');
+        b.endTime = value('endTime');
+        if (!b.startTime || !b.endTime) {
+            showToast('Please enter the start and end times', 'warning');
+            return;
+        }
+    } else {
+        b.start = value('start');
+        b.end = value('end');
+        if (!b.start || !b.end) {
+            showToast('Please enter the start and end', 'warning');
+            return;
+        }
+        if (b.end <= b.start) {
+            showToast('The end must be after the start', 'warning');
+            return;
+        }
+    }
+
+    blackoutEditors[containerId].push(b);
+    document.getElementById(`${containerId}-name`).value = '';
+    renderBlackoutList(containerId);
+}
+
+function removeBlackout(containerId, index) {
+    blackoutEditors[containerId].splice(index, 1);
+    renderBlackoutList(containerId);
+}
+
+window.showBlackoutSettings = showBlackoutSettings;
+window.closeBlackoutSettings = closeBlackoutSettings;
+window.saveBlackoutSettings = saveBlackoutSettings;
+window.importBlackoutCalendar = importBlackoutCalendar;
+window.updateBlackoutType = updateBlackoutType;
+window.addBlackout = addBlackout;
+window.removeBlackout = removeBlackout;
+
+// ==================== Restored Queue ====================
+
+const restoreActions = [['resume', 'Resume'], ['requeue', 'Re-queue'], ['discard', 'Discard']];
+
+// showRestoredQueue asks what to do with the runs queued or in progress
+// when the app last closed
+async function showRestoredQueue() {
+    try {
+        const runs = await runtime.GetRestoredQueue() || [];
+        if (runs.length === 0) {
+            return;
+        }
+        document.getElementById('restoredQueueList').innerHTML = runs.map((r, i) => {
+            const name = r.scheduleName || (r.isManual ? 'Manual execution' : 'Task');
+            const state = r.interrupted ? 'Interrupted while running' : `Queued ${new Date(r.queuedAt).toLocaleString()}`;
+            return `
+                <div class="blackout-item">
+                    <div class="blackout-item-info">
+                        <div>${escapeHtml(name)}${r.isManual ? '' : ' (scheduled)'}</div>
+                        <div class="blackout-item-detail">${state} - ${r.serverCount} server(s), ${r.commandCount} command(s)</div>
+                    </div>
+                    <select class="restored-queue-action" data-index="${i}">
+                        ${restoreActions.map(([value, label]) => `<option value="${value}">${label}</option>`).join('')}
+                    </select>
+                </div>
+            `;
+        }).join('');
+        document.getElementById('restoredQueueModal').style.display = 'flex';
+    } catch (err) {
+        showToast('Failed to load the restored queue: ' + err, 'error');
+    }
+}
+
+function setRestoredQueueActions(action) {
+    document.querySelectorAll('.restored-queue-action').forEach(select => {
+        select.value = action;
+    });
+}
+
+async function resolveRestoredQueue() {
+    const actions = [];
+    document.querySelectorAll('.restored-queue-action').forEach(select => {
+        actions[parseInt(select.dataset.index)] = select.value;
+    });
+    try {
+        if (await runtime.ResolveRestoredQueue(actions)) {
+            const kept = actions.filter(a => a !== 'discard').length;
+            showToast(kept > 0 ? `${kept} restored run(s) queued.` : 'Restored runs discarded.', 'success');
+            document.getElementById('restoredQueueModal').style.display = 'none';
+        } else {
+            showRestoredQueue();
+        }
+    } catch (err) {
+        showToast('Failed to restore the queue: ' + err, 'error');
+    }
+}
+
+window.setRestoredQueueActions = setRestoredQueueActions;
+window.resolveRestoredQueue = resolveRestoredQueue;
+
+// ==================== Configuration Bundle ====================
+
+// Actions offered for bundle items that already exist locally
+const bundleConflictActions = {
+    schedules: [['skip', 'Skip'], ['replace', 'Replace'], ['rename', 'Import as Copy']],
+    servers: [['skip', 'Skip'], ['merge', 'Merge'], ['replace', 'Replace']],
+    groups: [['skip', 'Skip'], ['replace', 'Replace']],
+    credentialProfiles: [['skip', 'Skip'], ['replace', 'Replace']],
+    smtp: [['skip', 'Skip'], ['replace', 'Replace']]
+};
+
+const bundleCategoryLabels = {
+    schedules: 'Schedule',
+    servers: 'Server',
+    groups: 'Group',
+    credentialProfiles: 'Credential Profile',
+    smtp: 'SMTP Settings'
+};
+
+function showExportBundle() {
+    document.getElementById('bundleExportPassword').value = '';
+    document.getElementById('bundleExportConfirm').value = '';
+    document.getElementById('exportBundleModal').style.display = 'flex';
+}
+
+function closeExportBundle() {
+    document.getElementById('exportBundleModal').style.display = 'none';
+}
+
+async function exportBundle() {
+    const password = document.getElementById('bundleExportPassword').value;
+    if (password.length < 8) {
+        showToast('Bundle password must be at least 8 characters', 'warning');
+        return;
+    }
+    if (password !== document.getElementById('bundleExportConfirm').value) {
+        showToast('Passwords do not match', 'warning');
+        return;
+    }
+
+    const path = await runtime.ExportBundle({
+        password,
+        schedules: document.getElementById('bundleExportSchedules').checked,
+        inventory: document.getElementById('bundleExportInventory').checked,
+        credentialProfiles: document.getElementById('bundleExportProfiles').checked,
+        smtp: document.getElementById('bundleExportSmtp').checked
+    });
+    if (path) {
+        showToast('Bundle exported: ' + path, 'success', 5000);
+        closeExportBundle();
+    }
+}
+
+function showImportBundle() {
+    document.getElementById('bundleImportPassword').value = '';
+    document.getElementById('bundleImportItems').style.display = 'none';
+    document.getElementById('bundleImportBody').innerHTML = '';
+    document.getElementById('bundleImportBtn').disabled = true;
+    document.getElementById('importBundleModal').style.display = 'flex';
+}
+
+function closeImportBundle() {
+    document.getElementById('importBundleModal').style.display = 'none';
+    runtime.CloseBundle();
+}
+
+async function openBundle() {
+    const password = document.getElementById('bundleImportPassword').value;
+    if (!password) {
+        showToast('Enter the bundle password', 'warning');
+        return;
+    }
+
+    const result = await runtime.OpenBundle(password);
+    if (!result) return;
+    if (!result.success) {
+        showToast('Failed to open bundle: ' + result.error, 'error');
+        return;
+    }
+
+    const preview = result.preview;
+    const tbody = document.getElementById('bundleImportBody');
+    tbody.innerHTML = '';
+    ['credentialProfiles', 'groups', 'servers', 'schedules'].forEach(category => {
+        (preview[category] || []).forEach(item => addBundleItemRow(tbody, category, item));
+    });
+    if (preview.smtp) {
+        addBundleItemRow(tbody, 'smtp', preview.smtp);
+    }
+
+    document.getElementById('bundleImportInfo').textContent = `${result.file} (created ${result.created})`;
+    document.getElementById('bundleImportItems').style.display = 'block';
+    document.getElementById('bundleImportBtn').disabled = tbody.children.length === 0;
+}
+
+function addBundleItemRow(tbody, category, item) {
+    const actions = item.conflict ? bundleConflictActions[category] : [['add', 'Import'], ['skip', 'Skip']];
+    const row = document.createElement('tr');
+    row.dataset.category = category;
+    row.dataset.key = item.key;
+    row.innerHTML = `
+        <td>${bundleCategoryLabels[category]}</td>
+        <td>${escapeHtml(item.label)}</td>
+        <td><span class="status-badge ${item.conflict ? 'conflict' : 'enabled'}">${item.conflict ? 'Exists' : 'New'}</span></td>
+        <td><select>${actions.map(([value, label]) => `<option value="${value}">${label}</option>`).join('')}</select></td>
+    `;
+    tbody.appendChild(row);
+}
+
+async function importBundle() {
+    const selection = { schedules: {}, servers: {}, groups: {}, credentialProfiles: {}, smtp: 'skip' };
+    document.querySelectorAll('#bundleImportBody tr').forEach(row => {
+        const action = row.querySelector('select').value;
+        if (row.dataset.category === 'smtp') {
+            selection.smtp = action;
+        } else {
+            selection[row.dataset.category][row.dataset.key] = action;
+        }
+    });
+
+    const result = await runtime.ImportBundle(selection);
+    if (!result.success) {
+        showToast('Import failed: ' + result.error, 'error');
+        return;
+    }
+
+    showToast(`Imported: ${result.added} added, ${result.replaced} replaced, ${result.merged} merged, ${result.renamed} copied, ${result.skipped} skipped`, 'success', 5000);
+    (result.scheduleErrors || []).forEach(err => showToast('Schedule not imported: ' + err, 'error', 8000));
+    if (result.stripped && result.stripped.length > 0) {
+        alert('These secrets were references (env:, file:, exec:, vault:) and were not imported, so the bundle can't send this computer's secrets to its devices. Re-enter them:\n\n' +
+            result.stripped.join('\n'));
+    }
+    document.getElementById('importBundleModal').style.display = 'none';
+
+    await loadSavedServerList();
+    await loadSchedules();
+    await loadCredentialProfiles();
+}
+
+window.showExportBundle = showExportBundle;
+window.closeExportBundle = closeExportBundle;
+window.exportBundle = exportBundle;
+window.showImportBundle = showImportBundle;
+window.closeImportBundle = closeImportBundle;
+window.openBundle = openBundle;
+window.importBundle = importBundle;
+
+// ==================== Log Storage ====================
+
+async function showLogStorageSettings() {
+    const data = await runtime.LoadLogStorageSettings();
+    document.getElementById('logStorageEncrypt').checked = data.encrypt || false;
+    document.getElementById('logStorageModal').style.display = 'flex';
+}
+
+function closeLogStorageSettings() {
+    document.getElementById('logStorageModal').style.display = 'none';
+}
+
+async function saveLogStorageSettings() {
+    try {
+        const encrypt = document.getElementById('logStorageEncrypt').checked;
+        if (await runtime.SaveLogStorageSettings({ encrypt })) {
+            showToast('Log storage settings saved.', 'success');
+            closeLogStorageSettings();
+        }
+    } catch (err) {
+        showToast('Failed to save log storage settings: ' + err, 'error');
+    }
+}
+
+window.showLogStorageSettings = showLogStorageSettings;
+window.closeLogStorageSettings = closeLogStorageSettings;
+window.saveLogStorageSettings = saveLogStorageSettings;
+
+// ==================== Execution Settings ====================
+
+async function showExecutionSettings() {
+    const data = await runtime.LoadExecutionSettings();
+    const input = document.getElementById('executionMaxSessions');
+    input.max = data.limit;
+    input.value = data.maxSessions;
+    document.getElementById('executionModal').style.display = 'flex';
+}
+
+function closeExecutionSettings() {
+    document.getElementById('executionModal').style.display = 'none';
+}
+
+async function saveExecutionSettings() {
+    try {
+        const maxSessions = parseInt(document.getElementById('executionMaxSessions').value) || 0;
+        if (await runtime.SaveExecutionSettings({ maxSessions })) {
+            showToast('Execution settings saved.', 'success');
+            closeExecutionSettings();
+        }
+    } catch (err) {
+        showToast('Failed to save execution settings: ' + err, 'error');
+    }
+}
+
+window.showExecutionSettings = showExecutionSettings;
+window.closeExecutionSettings = closeExecutionSettings;
+window.saveExecutionSettings = saveExecutionSettings;
+
+// ==================== Secret Providers ====================
+
+function getSecretSettingsForm() {
+    return {
+        vaultAddress: document.getElementById('secretVaultAddress').value.trim(),
+        vaultToken: document.getElementById('secretVaultToken').value.trim(),
+        vaultNamespace: document.getElementById('secretVaultNamespace').value.trim()
+    };
+}
+
+async function showSecretSettings() {
+    const data = await runtime.LoadSecretSettings();
+    document.getElementById('secretVaultAddress').value = data.vaultAddress || '';
+    document.getElementById('secretVaultToken').value = data.vaultToken || '';
+    document.getElementById('secretVaultNamespace').value = data.vaultNamespace || '';
+    document.getElementById('secretTestRef').value = '';
+    document.getElementById('secretApprovedCount').textContent = data.approvedCommands || 0;
+    document.getElementById('secretModal').style.display = 'flex';
+}
+
+function closeSecretSettings() {
+    document.getElementById('secretModal').style.display = 'none';
+}
+
+async function saveSecretSettings() {
+    try {
+        if (await runtime.SaveSecretSettings(getSecretSettingsForm())) {
+            showToast('Secret provider settings saved.', 'success');
+            closeSecretSettings();
+        }
+    } catch (err) {
+        showToast('Failed to save secret provider settings: ' + err, 'error');
+    }
+}
+
+async function testSecretReference() {
+    const ref = document.getElementById('secretTestRef').value.trim();
+    if (!ref) {
+        showToast('Enter a reference to test', 'warning');
+        return;
+    }
+    let result = await runtime.TestSecretReference(getSecretSettingsForm(), ref);
+    if (!result.success && result.command && await approveExecCommand({ command: result.command })) {
+        result = await runtime.TestSecretReference(getSecretSettingsForm(), ref);
+    }
+    if (result.success) {
+        showToast(result.empty ? 'Resolved, but the value is empty.' : 'Reference resolved.', result.empty ? 'warning' : 'success');
+    } else {
+        showToast('Failed: ' + result.error, 'error');
+    }
+}
+
+window.showSecretSettings = showSecretSettings;
+window.closeSecretSettings = closeSecretSettings;
+window.saveSecretSettings = saveSecretSettings;
+window.testSecretReference = testSecretReference;
+
+// An exec: command runs only once it has been allowed here, so a reference
+// that arrived from elsewhere cannot run without the user seeing it
+async function approveExecCommand(data) {
+    const message = 'A saved credential wants to run this command on this computer:\n\n' +
+        data.command + '\n\n' +
+        'Allow it only if you entered this command yourself. Allow it to run now and from now on?';
+    if (!confirm(message)) {
+        return false;
+    }
+    if (!await runtime.ApproveExecCommand(data.command)) {
+        return false;
+    }
+    if (data.runName !== undefined) {
+        showToast('Command allowed. Start the run again.', 'info');
+    }
+    return true;
+}
+
+async function revokeExecCommands() {
+    if (!confirm('Withdraw every allowed exec: command? Each one asks again before it next runs.')) {
+        return;
+    }
+    if (await runtime.RevokeExecCommands()) {
+        document.getElementById('secretApprovedCount').textContent = '0';
+        showToast('Allowed commands withdrawn', 'success');
+    }
+}
+window.revokeExecCommands = revokeExecCommands;
+
+// ==================== Master Password ====================
+
+// Ask for the master password at startup when the vault is locked
+async function checkVaultLocked() {
+    try {
+        const status = await runtime.GetVaultStatus();
+        if (status && status.locked) {
+            document.getElementById('unlockModal').style.display = 'flex';
+            document.getElementById('unlockPassword').focus();
+        }
+    } catch (err) {
+        console.error('Failed to get vault status:', err);
+    }
+}
+
+async function unlockVault() {
+    const input = document.getElementById('unlockPassword');
+    const ok = await runtime.UnlockVault(input.value);
+    input.value = '';
+    if (ok) {
+        document.getElementById('unlockModal').style.display = 'none';
+        await loadSavedServerList();
+        await loadCredentialProfiles();
+        loadSchedules();
+        showToast('Unlocked.', 'success');
+    }
+}
+
+async function showMasterPasswordSettings() {
+    const status = await runtime.GetVaultStatus();
+    document.getElementById('masterPasswordStatus').textContent = status.enabled
+        ? 'Master password is enabled. The encryption key is stored only in wrapped form.'
+        : 'Master password is disabled. The encryption key is stored in config/encryption.key.';
+    document.getElementById('masterPasswordCurrentGroup').style.display = status.enabled ? 'block' : 'none';
+    document.getElementById('masterPasswordDisableBtn').style.display = status.enabled ? 'inline-block' : 'none';
+    ['masterPasswordCurrent', 'masterPasswordNew', 'masterPasswordConfirm'].forEach(id => {
+        document.getElementById(id).value = '';
+    });
+    document.getElementById('masterPasswordModal').dataset.enabled = status.enabled ? 'true' : '';
+    document.getElementById('masterPasswordModal').style.display = 'flex';
+}
+
+function closeMasterPasswordSettings() {
+    document.getElementById('masterPasswordModal').style.display = 'none';
+}
+
+async function saveMasterPassword() {
+    const enabled = !!document.getElementById('masterPasswordModal').dataset.enabled;
+    const current = document.getElementById('masterPasswordCurrent').value;
+    const newPassword = document.getElementById('masterPasswordNew').value;
+    const confirmPassword = document.getElementById('masterPasswordConfirm').value;
+
+    if (newPassword !== confirmPassword) {
+        showToast('New passwords do not match', 'warning');
+        return;
+    }
+
+    const ok = enabled
+        ? await runtime.ChangeMasterPassword(current, newPassword)
+        : await runtime.EnableMasterPassword(newPassword);
+    if (ok) {
+        showToast(enabled ? 'Master password changed.' : 'Master password enabled.', 'success');
+        closeMasterPasswordSettings();
+    }
+}
+
+async function disableMasterPassword() {
+    const current = document.getElementById('masterPasswordCurrent').value;
+    if (!confirm('Disable the master password? The encryption key will be stored unprotected in config/encryption.key.')) {
+        return;
+    }
+    if (await runtime.DisableMasterPassword(current)) {
+        showToast('Master password disabled.', 'success');
+        closeMasterPasswordSettings();
+    }
+}
+
+async function rotateEncryptionKey() {
+    const enabled = !!document.getElementById('masterPasswordModal').dataset.enabled;
+    const current = document.getElementById('masterPasswordCurrent').value;
+    if (enabled && !current) {
+        showToast('Enter the current master password to rotate the key', 'warning');
+        return;
+    }
+    if (!confirm('Generate a new encryption key and re-encrypt all saved passwords?')) {
+        return;
+    }
+    if (await runtime.RotateEncryptionKey(current)) {
+        showToast('Encryption key rotated. All saved secrets were re-encrypted.', 'success');
+        closeMasterPasswordSettings();
+    }
+}
+
+window.unlockVault = unlockVault;
+window.rotateEncryptionKey = rotateEncryptionKey;
+window.showMasterPasswordSettings = showMasterPasswordSettings;
+window.closeMasterPasswordSettings = closeMasterPasswordSettings;
+window.saveMasterPassword = saveMasterPassword;
+window.disableMasterPassword = disableMasterPassword;
+
 window.showSmtpSettings = showSmtpSettings;
 window.closeSmtpSettings = closeSmtpSettings;
 window.saveSmtpSettings = saveSmtpSettings;
 window.toggleEmailOptions = toggleEmailOptions;
+
+// ==================== Workspaces ====================
+
+async function loadWorkspaces() {
+    try {
+        const info = await runtime.GetWorkspaces();
+        const select = document.getElementById('workspaceSelect');
+        select.innerHTML = info.workspaces.map(name =>
+            `<option value="${escapeHtml(name)}">${escapeHtml(name)}</option>`).join('');
+        select.value = info.current;
+        select.style.display = info.workspaces.length > 1 ? 'inline-block' : 'none';
+        return info;
+    } catch (err) {
+        console.error('Failed to load workspaces:', err);
+        return null;
+    }
+}
+
+async function switchWorkspace(name) {
+    if (!await runtime.SwitchWorkspace(name)) {
+        loadWorkspaces(); // Restore the selection
+    }
+}
+
+// Reload everything that belongs to the workspace
+async function handleWorkspaceChanged(name) {
+    await loadWorkspaces();
+    resetRuns();
+    await checkVaultLocked();
+    await loadSavedServerList();
+    await loadCredentialProfiles();
+    loadSchedules();
+    if (document.getElementById('workspacesModal').style.display === 'flex') {
+        renderWorkspaces();
+    }
+    showToast(`Switched to workspace "${name}".`, 'info');
+}
+
+async function showWorkspaces() {
+    document.getElementById('workspaceName').value = '';
+    await renderWorkspaces();
+    document.getElementById('workspacesModal').style.display = 'flex';
+}
+
+function closeWorkspaces() {
+    document.getElementById('workspacesModal').style.display = 'none';
+}
+
+async function renderWorkspaces() {
+    const info = await loadWorkspaces();
+    if (!info) return;
+
+    document.getElementById('workspaceDataDir').textContent = info.dataDir || '(working directory)';
+    const tbody = document.getElementById('workspaceList');
+    tbody.innerHTML = '';
+    info.workspaces.forEach(name => {
+        const active = name === info.current;
+        const row = document.createElement('tr');
+        row.innerHTML = `
+            <td>${escapeHtml(name)}</td>
+            <td>${active ? '<span class="status-badge enabled">Active</span>' : ''}</td>
+            <td>
+                ${active ? '' : '<button class="btn-secondary" data-action="switch">Switch</button>'}
+                ${active || name === 'default' ? '' : '<button class="btn-danger" data-action="delete">Delete</button>'}
+            </td>
+        `;
+        row.querySelector('[data-action="switch"]')?.addEventListener('click', () => switchWorkspace(name));
+        row.querySelector('[data-action="delete"]')?.addEventListener('click', () => deleteWorkspace(name));
+        tbody.appendChild(row);
+    });
+}
+
+async function createWorkspace() {
+    const name = document.getElementById('workspaceName').value.trim();
+    if (!name) {
+        showToast('Enter a workspace name', 'warning');
+        return;
+    }
+    if (await runtime.CreateWorkspace(name)) {
+        document.getElementById('workspaceName').value = '';
+        await renderWorkspaces();
+        showToast(`Workspace "${name}" created.`, 'success');
+    }
+}
+
+async function deleteWorkspace(name) {
+    if (!confirm(`Delete workspace "${name}" with all its servers, schedules, settings and logs? This cannot be undone.`)) {
+        return;
+    }
+    if (await runtime.DeleteWorkspace(name)) {
+        await renderWorkspaces();
+        showToast(`Workspace "${name}" deleted.`, 'success');
+    }
+}
+
+window.switchWorkspace = switchWorkspace;
+window.showWorkspaces = showWorkspaces;
+window.closeWorkspaces = closeWorkspaces;
+window.createWorkspace = createWorkspace;
+
+// ==================== Audit Log ====================
+
+async function showAuditLog() {
+    document.getElementById('auditStatus').textContent = '';
+    document.getElementById('auditModal').style.display = 'flex';
+    await loadAuditLog();
+}
+
+function closeAuditLog() {
+    document.getElementById('auditModal').style.display = 'none';
+}
+
+async function loadAuditLog() {
+    const result = await runtime.QueryAudit({
+        type: document.getElementById('auditType').value,
+        since: document.getElementById('auditSince').value,
+        until: document.getElementById('auditUntil').value,
+        text: document.getElementById('auditText').value,
+        limit: 500
+    });
+    if (!result.success) {
+        showToast(result.error, 'error');
+        return;
+    }
+
+    const tbody = document.getElementById('auditBody');
+    tbody.innerHTML = result.entries.map(e => `
+        <tr>
+            <td>${e.seq}</td>
+            <td>${escapeHtml(new Date(e.time).toLocaleString())}</td>
+            <td>${escapeHtml(e.user || '')}</td>
+            <td>${escapeHtml(e.type)}</td>
+            <td class="audit-details">${escapeHtml(formatAuditDetails(e.details))}</td>
+        </tr>
+    `).join('');
+    document.getElementById('auditStatus').textContent =
+        `${result.entries.length} entries${result.entries.length === 500 ? ' (newest 500)' : ''} - ${result.path}`;
+}
+
+function formatAuditDetails(details) {
+    if (!details) return '';
+    return Object.entries(details).map(([key, value]) => {
+        const text = Array.isArray(value)
+            ? value.map(v => (v && typeof v === 'object') ? '\n  ' + JSON.stringify(v) : v).join(', ')
+            : (value && typeof value === 'object') ? JSON.stringify(value) : String(value);
+        return `${key}: ${text}`;
+    }).join('\n');
+}
+
+async function verifyAuditLog() {
+    const result = await runtime.VerifyAudit();
+    if (!result.success) {
+        showToast('Failed to verify audit log: ' + result.error, 'error');
+    } else if (result.valid) {
+        showToast(`Audit log intact: ${result.entries} entries verified.`, 'success');
+    } else {
+        showToast(`Audit log tampered at line ${result.line}: ${result.reason}`, 'error');
+    }
+}
+
+window.showAuditLog = showAuditLog;
+window.closeAuditLog = closeAuditLog;
+window.loadAuditLog = loadAuditLog;
+window.verifyAuditLog = verifyAuditLog;
@@ -2,62 +2,194 @@
 // This file is automatically generated. DO NOT EDIT
 import {updater} from '../models';
 
+export function ApplyServerImport(arg1:string,arg2:Record<string, string>,arg3:string):Promise<Record<string, any>>;
+
+export function ApproveExecCommand(arg1:string):Promise<boolean>;
+
+export function CancelQueueItem(arg1:string):Promise<boolean>;
+
+export function ChangeMasterPassword(arg1:string,arg2:string):Promise<boolean>;
+
 export function CheckForUpdates():Promise<updater.UpdateInfo>;
 
 export function ClearQueue():Promise<void>;
 
+export function CloseBundle():Promise<void>;
+
 export function CreateSchedule(arg1:Record<string, any>):Promise<string>;
 
+export function CreateWorkspace(arg1:string):Promise<boolean>;
+
+export function DeleteCredentialProfile(arg1:string):Promise<boolean>;
+
+export function DeleteGroup(arg1:string):Promise<boolean>;
+
 export function DeleteSchedule(arg1:string):Promise<boolean>;
 
+export function DeleteWorkspace(arg1:string):Promise<boolean>;
+
+export function DisableMasterPassword(arg1:string):Promise<boolean>;
+
 export function DownloadAndInstallUpdate(arg1:string):Promise<boolean>;
 
+export function EnableMasterPassword(arg1:string):Promise<boolean>;
+
+export function ExportAnsibleInventory():Promise<boolean>;
+
+export function ExportBundle(arg1:Record<string, any>):Promise<string>;
+
 export function ExportCommandsToTxt(arg1:string):Promise<boolean>;
 
-export function ExportResults():Promise<string>;
+export function ExportDecryptedCopy(arg1:string):Promise<string>;
+
+export function ExportInventory():Promise<boolean>;
+
+export function ExportResults(arg1:string):Promise<string>;
 
 export function ExportServersToCSV(arg1:Array<Record<string, string>>):Promise<boolean>;
 
-export function GetCurrentLogDir():Promise<string>;
+export function GetBlackouts():Promise<Array<Record<string, any>>>;
+
+export function GetCredentialProfile(arg1:string):Promise<Record<string, any>>;
+
+export function GetCredentialProfileUsage(arg1:string):Promise<Record<string, Array<string>>>;
+
+export function GetCredentialProfiles():Promise<Array<Record<string, any>>>;
+
+export function GetCurrentLogDir(arg1:string):Promise<string>;
 
 export function GetCurrentVersion():Promise<string>;
 
+export function GetGroups():Promise<Array<Record<string, any>>>;
+
 export function GetLogFiles():Promise<Array<Record<string, string>>>;
 
 export function GetQueue():Promise<Array<Record<string, any>>>;
 
+export function GetRestoredQueue():Promise<Array<Record<string, any>>>;
+
+export function GetRunResults(arg1:string):Promise<Array<Record<string, any>>>;
+
+export function GetRuns():Promise<Array<Record<string, any>>>;
+
 export function GetSchedules():Promise<Array<Record<string, any>>>;
 
+export function GetTags():Promise<Array<string>>;
+
+export function GetVaultStatus():Promise<Record<string, any>>;
+
+export function GetWorkspaces():Promise<Record<string, any>>;
+
+export function ImportAnsibleInventory(arg1:string):Promise<Record<string, any>>;
+
+export function ImportBlackoutCalendar():Promise<Record<string, any>>;
+
+export function ImportBundle(arg1:Record<string, any>):Promise<Record<string, any>>;
+
 export function ImportCommandsFromTxt():Promise<string>;
 
 export function ImportServersFromCSV():Promise<Array<Record<string, string>>>;
 
-export function IsRunning():Promise<boolean>;
+export function IsReadOnly():Promise<boolean>;
+
+export function IsRunning(arg1:string):Promise<boolean>;
+
+export function LoadExecutionSettings():Promise<Record<string, any>>;
+
+export function LoadLogStorageSettings():Promise<Record<string, any>>;
+
+export function LoadNetBoxSettings():Promise<Record<string, any>>;
+
+export function LoadRedactionSettings():Promise<Record<string, any>>;
+
+export function LoadSecretSettings():Promise<Record<string, any>>;
 
 export function LoadServerList():Promise<Array<Record<string, string>>>;
 
 export function LoadSmtpSettings():Promise<Record<string, any>>;
 
-export function OpenLogsFolder():Promise<void>;
+export function MoveQueueItem(arg1:string,arg2:number):Promise<boolean>;
+
+export function OpenBundle(arg1:string):Promise<Record<string, any>>;
+
+export function OpenLogsFolder(arg1:string):Promise<void>;
+
+export function ParseServerImport(arg1:string,arg2:Record<string, string>):Promise<Record<string, any>>;
+
+export function PreviewRedaction(arg1:Record<string, any>,arg2:string):Promise<Record<string, any>>;
+
+export function PreviewSchedule(arg1:Record<string, any>,arg2:number):Promise<Record<string, any>>;
+
+export function PreviewServerImport():Promise<Record<string, any>>;
+
+export function QueryAudit(arg1:Record<string, any>):Promise<Record<string, any>>;
 
 export function ReadLogFile(arg1:string):Promise<string>;
 
+export function ResolveRestoredQueue(arg1:Array<string>):Promise<boolean>;
+
+export function ResolveTarget(arg1:Record<string, any>):Promise<Array<Record<string, string>>>;
+
 export function RestartApp():Promise<void>;
 
+export function RevokeExecCommands():Promise<boolean>;
+
+export function RotateEncryptionKey(arg1:string):Promise<boolean>;
+
 export function RunScheduleNow(arg1:string):Promise<boolean>;
 
+export function SaveBlackouts(arg1:Array<Record<string, any>>):Promise<boolean>;
+
+export function SaveCredentialProfile(arg1:Record<string, any>):Promise<boolean>;
+
+export function SaveExecutionSettings(arg1:Record<string, any>):Promise<boolean>;
+
+export function SaveGroup(arg1:Record<string, any>):Promise<boolean>;
+
+export function SaveLogStorageSettings(arg1:Record<string, any>):Promise<boolean>;
+
+export function SaveNetBoxSettings(arg1:Record<string, any>):Promise<boolean>;
+
+export function SaveRedactionSettings(arg1:Record<string, any>):Promise<boolean>;
+
+export function SaveSecretSettings(arg1:Record<string, any>):Promise<boolean>;
+
 export function SaveServerList(arg1:Array<Record<string, string>>):Promise<boolean>;
 
 export function SaveSmtpSettings(arg1:Record<string, any>):Promise<boolean>;
 
 export function SetCommands(arg1:Array<string>):Promise<void>;
 
+export function SetCredentialProfile(arg1:string):Promise<void>;
+
+export function SetQueuePriority(arg1:string,arg2:string):Promise<boolean>;
+
+export function SetRunPriority(arg1:string):Promise<void>;
+
 export function SetServers(arg1:Array<Record<string, string>>):Promise<void>;
 
-export function StartExecution(arg1:string,arg2:string,arg3:number,arg4:boolean,arg5:boolean,arg6:boolean,arg7:string,arg8:string):Promise<boolean>;
+export function SetTarget(arg1:Record<string, any>):Promise<number>;
+
+export function StartExecution(arg1:string,arg2:string,arg3:number,arg4:boolean,arg5:boolean,arg6:boolean,arg7:string,arg8:string):Promise<Record<string, any>>;
 
-export function StopExecution():Promise<void>;
+export function StopExecution(arg1:string):Promise<boolean>;
+
+export function SwitchWorkspace(arg1:string):Promise<boolean>;
+
+export function SyncNetBox():Promise<Record<string, any>>;
+
+export function TakeMissedRuns():Promise<Array<Record<string, any>>>;
+
+export function TestCredentialProfile(arg1:string,arg2:string,arg3:number):Promise<Record<string, any>>;
+
+export function TestNetBoxConnection(arg1:Record<string, any>):Promise<Record<string, any>>;
+
+export function TestSecretReference(arg1:Record<string, any>,arg2:string):Promise<Record<string, any>>;
 
 export function ToggleSchedule(arg1:string,arg2:boolean):Promise<boolean>;
 
+export function UnlockVault(arg1:string):Promise<boolean>;
+
 export function UpdateSchedule(arg1:Record<string, any>):Promise<boolean>;
+
+export function VerifyAudit():Promise<Record<string, any>>;
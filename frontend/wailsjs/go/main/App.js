@@ -2,6 +2,22 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ApplyServerImport(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ApplyServerImport'](arg1, arg2, arg3);
+}
+
+export function ApproveExecCommand(arg1) {
+  return window['go']['main']['App']['ApproveExecCommand'](arg1);
+}
+
+export function CancelQueueItem(arg1) {
+  return window['go']['main']['App']['CancelQueueItem'](arg1);
+}
+
+export function ChangeMasterPassword(arg1, arg2) {
+  return window['go']['main']['App']['ChangeMasterPassword'](arg1, arg2);
+}
+
 export function CheckForUpdates() {
   return window['go']['main']['App']['CheckForUpdates']();
 }
@@ -10,38 +26,102 @@ export function ClearQueue() {
   return window['go']['main']['App']['ClearQueue']();
 }
 
+export function CloseBundle() {
+  return window['go']['main']['App']['CloseBundle']();
+}
+
 export function CreateSchedule(arg1) {
   return window['go']['main']['App']['CreateSchedule'](arg1);
 }
 
+export function CreateWorkspace(arg1) {
+  return window['go']['main']['App']['CreateWorkspace'](arg1);
+}
+
+export function DeleteCredentialProfile(arg1) {
+  return window['go']['main']['App']['DeleteCredentialProfile'](arg1);
+}
+
+export function DeleteGroup(arg1) {
+  return window['go']['main']['App']['DeleteGroup'](arg1);
+}
+
 export function DeleteSchedule(arg1) {
   return window['go']['main']['App']['DeleteSchedule'](arg1);
 }
 
+export function DeleteWorkspace(arg1) {
+  return window['go']['main']['App']['DeleteWorkspace'](arg1);
+}
+
+export function DisableMasterPassword(arg1) {
+  return window['go']['main']['App']['DisableMasterPassword'](arg1);
+}
+
 export function DownloadAndInstallUpdate(arg1) {
   return window['go']['main']['App']['DownloadAndInstallUpdate'](arg1);
 }
 
+export function EnableMasterPassword(arg1) {
+  return window['go']['main']['App']['EnableMasterPassword'](arg1);
+}
+
+export function ExportAnsibleInventory() {
+  return window['go']['main']['App']['ExportAnsibleInventory']();
+}
+
+export function ExportBundle(arg1) {
+  return window['go']['main']['App']['ExportBundle'](arg1);
+}
+
 export function ExportCommandsToTxt(arg1) {
   return window['go']['main']['App']['ExportCommandsToTxt'](arg1);
 }
 
-export function ExportResults() {
-  return window['go']['main']['App']['ExportResults']();
+export function ExportDecryptedCopy(arg1) {
+  return window['go']['main']['App']['ExportDecryptedCopy'](arg1);
+}
+
+export function ExportInventory() {
+  return window['go']['main']['App']['ExportInventory']();
+}
+
+export function ExportResults(arg1) {
+  return window['go']['main']['App']['ExportResults'](arg1);
 }
 
 export function ExportServersToCSV(arg1) {
   return window['go']['main']['App']['ExportServersToCSV'](arg1);
 }
 
-export function GetCurrentLogDir() {
-  return window['go']['main']['App']['GetCurrentLogDir']();
+export function GetBlackouts() {
+  return window['go']['main']['App']['GetBlackouts']();
+}
+
+export function GetCredentialProfile(arg1) {
+  return window['go']['main']['App']['GetCredentialProfile'](arg1);
+}
+
+export function GetCredentialProfileUsage(arg1) {
+  return window['go']['main']['App']['GetCredentialProfileUsage'](arg1);
+}
+
+export function GetCredentialProfiles() {
+  return window['go']['main']['App']['GetCredentialProfiles']();
+}
+
+export function GetCurrentLogDir(arg1) {
+  return window['go']['main']['App']['GetCurrentLogDir'](arg1);
 }
 
 export function GetCurrentVersion() {
   return window['go']['main']['App']['GetCurrentVersion']();
 }
 
+export function GetGroups() {
+  return window['go']['main']['App']['GetGroups']();
+}
+
 export function GetLogFiles() {
   return window['go']['main']['App']['GetLogFiles']();
 }
@@ -50,10 +130,46 @@ export function GetQueue() {
   return window['go']['main']['App']['GetQueue']();
 }
 
+export function GetRestoredQueue() {
+  return window['go']['main']['App']['GetRestoredQueue']();
+}
+
+export function GetRunResults(arg1) {
+  return window['go']['main']['App']['GetRunResults'](arg1);
+}
+
+export function GetRuns() {
+  return window['go']['main']['App']['GetRuns']();
+}
+
 export function GetSchedules() {
   return window['go']['main']['App']['GetSchedules']();
 }
 
+export function GetTags() {
+  return window['go']['main']['App']['GetTags']();
+}
+
+export function GetVaultStatus() {
+  return window['go']['main']['App']['GetVaultStatus']();
+}
+
+export function GetWorkspaces() {
+  return window['go']['main']['App']['GetWorkspaces']();
+}
+
+export function ImportAnsibleInventory(arg1) {
+  return window['go']['main']['App']['ImportAnsibleInventory'](arg1);
+}
+
+export function ImportBlackoutCalendar() {
+  return window['go']['main']['App']['ImportBlackoutCalendar']();
+}
+
+export function ImportBundle(arg1) {
+  return window['go']['main']['App']['ImportBundle'](arg1);
+}
+
 export function ImportCommandsFromTxt() {
   return window['go']['main']['App']['ImportCommandsFromTxt']();
 }
@@ -62,8 +178,32 @@ export function ImportServersFromCSV() {
   return window['go']['main']['App']['ImportServersFromCSV']();
 }
 
-export function IsRunning() {
-  return window['go']['main']['App']['IsRunning']();
+export function IsReadOnly() {
+  return window['go']['main']['App']['IsReadOnly']();
+}
+
+export function IsRunning(arg1) {
+  return window['go']['main']['App']['IsRunning'](arg1);
+}
+
+export function LoadExecutionSettings() {
+  return window['go']['main']['App']['LoadExecutionSettings']();
+}
+
+export function LoadLogStorageSettings() {
+  return window['go']['main']['App']['LoadLogStorageSettings']();
+}
+
+export function LoadNetBoxSettings() {
+  return window['go']['main']['App']['LoadNetBoxSettings']();
+}
+
+export function LoadRedactionSettings() {
+  return window['go']['main']['App']['LoadRedactionSettings']();
+}
+
+export function LoadSecretSettings() {
+  return window['go']['main']['App']['LoadSecretSettings']();
 }
 
 export function LoadServerList() {
@@ -74,22 +214,98 @@ export function LoadSmtpSettings() {
   return window['go']['main']['App']['LoadSmtpSettings']();
 }
 
-export function OpenLogsFolder() {
-  return window['go']['main']['App']['OpenLogsFolder']();
+export function MoveQueueItem(arg1, arg2) {
+  return window['go']['main']['App']['MoveQueueItem'](arg1, arg2);
+}
+
+export function OpenBundle(arg1) {
+  return window['go']['main']['App']['OpenBundle'](arg1);
+}
+
+export function OpenLogsFolder(arg1) {
+  return window['go']['main']['App']['OpenLogsFolder'](arg1);
+}
+
+export function ParseServerImport(arg1, arg2) {
+  return window['go']['main']['App']['ParseServerImport'](arg1, arg2);
+}
+
+export function PreviewRedaction(arg1, arg2) {
+  return window['go']['main']['App']['PreviewRedaction'](arg1, arg2);
+}
+
+export function PreviewSchedule(arg1, arg2) {
+  return window['go']['main']['App']['PreviewSchedule'](arg1, arg2);
+}
+
+export function PreviewServerImport() {
+  return window['go']['main']['App']['PreviewServerImport']();
+}
+
+export function QueryAudit(arg1) {
+  return window['go']['main']['App']['QueryAudit'](arg1);
 }
 
 export function ReadLogFile(arg1) {
   return window['go']['main']['App']['ReadLogFile'](arg1);
 }
 
+export function ResolveRestoredQueue(arg1) {
+  return window['go']['main']['App']['ResolveRestoredQueue'](arg1);
+}
+
+export function ResolveTarget(arg1) {
+  return window['go']['main']['App']['ResolveTarget'](arg1);
+}
+
 export function RestartApp() {
   return window['go']['main']['App']['RestartApp']();
 }
 
+export function RevokeExecCommands() {
+  return window['go']['main']['App']['RevokeExecCommands']();
+}
+
+export function RotateEncryptionKey(arg1) {
+  return window['go']['main']['App']['RotateEncryptionKey'](arg1);
+}
+
 export function RunScheduleNow(arg1) {
   return window['go']['main']['App']['RunScheduleNow'](arg1);
 }
 
+export function SaveBlackouts(arg1) {
+  return window['go']['main']['App']['SaveBlackouts'](arg1);
+}
+
+export function SaveCredentialProfile(arg1) {
+  return window['go']['main']['App']['SaveCredentialProfile'](arg1);
+}
+
+export function SaveExecutionSettings(arg1) {
+  return window['go']['main']['App']['SaveExecutionSettings'](arg1);
+}
+
+export function SaveGroup(arg1) {
+  return window['go']['main']['App']['SaveGroup'](arg1);
+}
+
+export function SaveLogStorageSettings(arg1) {
+  return window['go']['main']['App']['SaveLogStorageSettings'](arg1);
+}
+
+export function SaveNetBoxSettings(arg1) {
+  return window['go']['main']['App']['SaveNetBoxSettings'](arg1);
+}
+
+export function SaveRedactionSettings(arg1) {
+  return window['go']['main']['App']['SaveRedactionSettings'](arg1);
+}
+
+export function SaveSecretSettings(arg1) {
+  return window['go']['main']['App']['SaveSecretSettings'](arg1);
+}
+
 export function SaveServerList(arg1) {
   return window['go']['main']['App']['SaveServerList'](arg1);
 }
@@ -102,22 +318,70 @@ export function SetCommands(arg1) {
   return window['go']['main']['App']['SetCommands'](arg1);
 }
 
+export function SetCredentialProfile(arg1) {
+  return window['go']['main']['App']['SetCredentialProfile'](arg1);
+}
+
+export function SetQueuePriority(arg1, arg2) {
+  return window['go']['main']['App']['SetQueuePriority'](arg1, arg2);
+}
+
+export function SetRunPriority(arg1) {
+  return window['go']['main']['App']['SetRunPriority'](arg1);
+}
+
 export function SetServers(arg1) {
   return window['go']['main']['App']['SetServers'](arg1);
 }
 
+export function SetTarget(arg1) {
+  return window['go']['main']['App']['SetTarget'](arg1);
+}
+
 export function StartExecution(arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8) {
   return window['go']['main']['App']['StartExecution'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
 }
 
-export function StopExecution() {
-  return window['go']['main']['App']['StopExecution']();
+export function StopExecution(arg1) {
+  return window['go']['main']['App']['StopExecution'](arg1);
+}
+
+export function SwitchWorkspace(arg1) {
+  return window['go']['main']['App']['SwitchWorkspace'](arg1);
+}
+
+export function SyncNetBox() {
+  return window['go']['main']['App']['SyncNetBox']();
+}
+
+export function TakeMissedRuns() {
+  return window['go']['main']['App']['TakeMissedRuns']();
+}
+
+export function TestCredentialProfile(arg1, arg2, arg3) {
+  return window['go']['main']['App']['TestCredentialProfile'](arg1, arg2, arg3);
+}
+
+export function TestNetBoxConnection(arg1) {
+  return window['go']['main']['App']['TestNetBoxConnection'](arg1);
+}
+
+export function TestSecretReference(arg1, arg2) {
+  return window['go']['main']['App']['TestSecretReference'](arg1, arg2);
 }
 
 export function ToggleSchedule(arg1, arg2) {
   return window['go']['main']['App']['ToggleSchedule'](arg1, arg2);
 }
 
+export function UnlockVault(arg1) {
+  return window['go']['main']['App']['UnlockVault'](arg1);
+}
+
 export function UpdateSchedule(arg1) {
   return window['go']['main']['App']['UpdateSchedule'](arg1);
 }
+
+export function VerifyAudit() {
+  return window['go']['main']['App']['VerifyAudit']();
+}
@@ -0,0 +1,251 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/fsutil"
+)
+
+// Event types
+const (
+	RunStart         = "run.start"
+	RunComplete      = "run.complete"
+	RunStop          = "run.stop"
+	ScheduleCreate   = "schedule.create"
+	ScheduleUpdate   = "schedule.update"
+	ScheduleDelete   = "schedule.delete"
+	ScheduleToggle   = "schedule.toggle"
+	ScheduleMissed   = "schedule.missed"
+	ScheduleSkip     = "schedule.skip"
+	ScheduleDefer    = "schedule.defer"
+	BlackoutUpdate   = "blackout.update"
+	ChainComplete    = "chain.complete"
+	QueueRestore     = "queue.restore"
+	CredentialCreate = "credential.create"
+	CredentialUpdate = "credential.update"
+	CredentialDelete = "credential.delete"
+	BundleImport     = "bundle.import"
+	UpdateInstall    = "update.install"
+	KeyRotate        = "key.rotate"
+	SecretApprove    = "secret.approve"
+)
+
+// Triggers of a run
+const (
+	TriggerManual   = "manual"
+	TriggerSchedule = "schedule"
+	TriggerQueue    = "queue"
+	TriggerCatchUp  = "catch-up" // a run missed while the app was closed
+	TriggerChain    = "chain"    // another schedule the task runs after has completed
+)
+
+const logFile = "audit.jsonl"
+
+// Record is the hashed part of an audit entry
+type Record struct {
+	Seq     int64                  `json:"seq"`
+	Time    time.Time              `json:"time"`
+	Type    string                 `json:"type"`
+	User    string                 `json:"user"` // operating system user
+	Host    string                 `json:"host"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Prev    string                 `json:"prev"` // hash of the previous entry, empty for the first
+}
+
+// Entry is one line of the audit log. Hash is the SHA-256 of the entry's
+// JSON without the hash field, which is always written last, so any change
+// to an entry or to the order of entries breaks the chain.
+type Entry struct {
+	Record
+	Hash string `json:"hash"`
+}
+
+var (
+	mu       sync.Mutex
+	identity struct {
+		once       sync.Once
+		user, host string
+	}
+)
+
+// Path returns the audit log of the active workspace
+func Path() string {
+	return filepath.Join(datadir.ConfigDir(), logFile)
+}
+
+// Append adds an entry to the audit log. The file is only ever appended to
+// and synced after each entry.
+func Append(typ string, details map[string]interface{}) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Another instance (e.g. a read-only one) may append to the same log
+	lock, err := lockWait(path+".lock", 5*time.Second)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rec := Record{Time: time.Now(), Type: typ, Details: details}
+	rec.User, rec.Host = whoami()
+
+	last, err := lastLine(f)
+	if err != nil {
+		return err
+	}
+	if last != nil {
+		prev, err := parseLine(last)
+		if err != nil {
+			return fmt.Errorf("audit log is damaged, not appending: %v", err)
+		}
+		rec.Seq = prev.Seq + 1
+		rec.Prev = prev.Hash
+	} else {
+		rec.Seq = 1
+	}
+
+	line, err := encode(rec)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// encode returns the JSON line of a record with its hash appended
+func encode(rec Record) ([]byte, error) {
+	body, err := marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body)
+
+	line := make([]byte, 0, len(body)+80)
+	line = append(line, body[:len(body)-1]...)
+	line = append(line, `,"hash":"`...)
+	line = append(line, hex.EncodeToString(sum[:])...)
+	line = append(line, "\"}\n"...)
+	return line, nil
+}
+
+// marshal encodes v as JSON without escaping <, > and &, so commands stay
+// readable in the file
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// hashSuffixLen is the length of `,"hash":"<64 hex>"}`
+const hashSuffixLen = len(`,"hash":""}`) + sha256.Size*2
+
+// parseLine decodes a line and checks that its hash matches its content
+func parseLine(line []byte) (*Entry, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	if len(line) < hashSuffixLen || !bytes.HasPrefix(line[len(line)-hashSuffixLen:], []byte(`,"hash":"`)) {
+		return nil, errors.New("entry has no hash")
+	}
+
+	var e Entry
+	if err := json.Unmarshal(line, &e); err != nil {
+		return nil, err
+	}
+
+	body := append(append([]byte{}, line[:len(line)-hashSuffixLen]...), '}')
+	sum := sha256.Sum256(body)
+	if hex.EncodeToString(sum[:]) != e.Hash {
+		return nil, fmt.Errorf("entry %d was modified (hash mismatch)", e.Seq)
+	}
+	return &e, nil
+}
+
+// lastLine returns the last non-empty line of f, or nil for an empty file
+func lastLine(f *os.File) ([]byte, error) {
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	const chunk = 64 * 1024
+	var tail []byte
+	for offset := size; offset > 0; {
+		n := int64(chunk)
+		if offset < n {
+			n = offset
+		}
+		offset -= n
+
+		buf := make([]byte, n)
+		if _, err := f.ReadAt(buf, offset); err != nil {
+			return nil, err
+		}
+		tail = append(buf, tail...)
+
+		trimmed := bytes.TrimRight(tail, "\r\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if offset == 0 && len(trimmed) > 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+// lockWait takes the lock at path, retrying until timeout
+func lockWait(path string, timeout time.Duration) (*fsutil.Lock, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		lock, err := fsutil.TryLock(path)
+		if err != fsutil.ErrLocked || time.Now().After(deadline) {
+			return lock, err
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// whoami returns the operating system user and host name
+func whoami() (string, string) {
+	identity.once.Do(func() {
+		if u, err := user.Current(); err == nil {
+			identity.user = u.Username
+		}
+		if identity.user == "" {
+			identity.user = os.Getenv("USERNAME")
+		}
+		if identity.user == "" {
+			identity.user = os.Getenv("USER")
+		}
+		identity.host, _ = os.Hostname()
+	})
+	return identity.user, identity.host
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"cisco-plink/internal/datadir"
+)
+
+// appendEntries starts a new audit log with n entries and returns its lines
+func appendEntries(t *testing.T, n int) [][]byte {
+	t.Helper()
+	if err := datadir.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := Append(RunStart, map[string]interface{}{"command": "show run | include <x> & y", "n": i}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.SplitAfter(bytes.TrimRight(data, "\n"), []byte("\n"))
+}
+
+// writeLines replaces the audit log with lines
+func writeLines(t *testing.T, lines [][]byte) {
+	t.Helper()
+	data := bytes.Join(lines, nil)
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	if err := os.WriteFile(Path(), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAppendVerify(t *testing.T) {
+	lines := appendEntries(t, 3)
+	if len(lines) != 3 {
+		t.Fatalf("lines = %d, want 3", len(lines))
+	}
+	if !bytes.Contains(lines[0], []byte("<x> & y")) {
+		t.Errorf("line = %s, want < > & unescaped", lines[0])
+	}
+
+	res, err := Verify()
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !res.Valid || res.Entries != 3 {
+		t.Errorf("Verify = %+v, want 3 valid entries", res)
+	}
+
+	entries, err := Query(Filter{Type: "run."})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(entries) != 3 || entries[0].Seq != 3 || entries[2].Seq != 1 || entries[1].Prev != entries[2].Hash {
+		t.Errorf("Query = %+v, want entries 3, 2, 1 chained", entries)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(lines [][]byte) [][]byte
+		wantLine int
+		wantErr  string
+	}{
+		{
+			name: "edited",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(`"n":1`), []byte(`"n":9`), 1)
+				return lines
+			},
+			wantLine: 2,
+			wantErr:  "hash mismatch",
+		},
+		{
+			name: "deleted",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2:]...)
+			},
+			wantLine: 2,
+			wantErr:  "sequence jumps from 1 to 3",
+		},
+		{
+			name: "reordered",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			wantLine: 2,
+			wantErr:  "sequence jumps from 1 to 3",
+		},
+		{
+			name: "first deleted",
+			tamper: func(lines [][]byte) [][]byte {
+				return lines[1:]
+			},
+			wantLine: 1,
+			wantErr:  "first entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := appendEntries(t, 4)
+			writeLines(t, tt.tamper(lines))
+
+			res, err := Verify()
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if res.Valid || res.Line != tt.wantLine || !strings.Contains(res.Error, tt.wantErr) {
+				t.Errorf("Verify = %+v, want line %d broken with %q", res, tt.wantLine, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAppendRefusesDamagedLog(t *testing.T) {
+	lines := appendEntries(t, 2)
+	lines[1] = bytes.Replace(lines[1], []byte(`"n":1`), []byte(`"n":2`), 1)
+	writeLines(t, lines)
+
+	if err := Append(RunStop, nil); err == nil || !strings.Contains(err.Error(), "damaged") {
+		t.Errorf("Append = %v, want a damaged log error", err)
+	}
+}
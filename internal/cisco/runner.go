@@ -2,6 +2,7 @@ package cisco
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
@@ -20,7 +21,11 @@ type Runner struct {
 	DisablePaging  bool // Whether to disable paging (terminal length 0)
 	OnProgress     ProgressCallback
 	OnResult       ResultCallback
-	OnLog          LogCallback // Real-time log callback
+	OnLog          LogCallback                                  // Real-time log callback
+	OnDone         func()                                       // Called when the run has ended, completed or stopped (optional)
+	Redact         func(output string) string                   // Applied to output before it is saved (nil = no redaction)
+	SaveOriginal   func(logPath, output string) error           // Keeps the unredacted output when Redact is set (optional)
+	WriteLog       func(logPath, output string) (string, error) // Saves the log instead of SaveLog and returns the path written (optional)
 	ctx            context.Context
 	cancel         context.CancelFunc
 	mu             sync.Mutex
@@ -31,14 +36,15 @@ type Runner struct {
 	completedCount int
 }
 
-// NewRunner creates a new Runner instance
-func NewRunner(servers []Server, commands []string, creds *Credentials, chunkTimeout int, enableMode, disablePaging bool, scheduleName string) *Runner {
+// NewRunner creates a new Runner instance. Logs are written to a new
+// timestamped directory under logsDir (and scheduleName, if set).
+func NewRunner(servers []Server, commands []string, creds *Credentials, chunkTimeout int, enableMode, disablePaging bool, logsDir, scheduleName string) *Runner {
 	timestamp := time.Now().Format("2006-01-02_150405")
 	var logDir string
 	if scheduleName != "" {
-		logDir = filepath.Join("logs", scheduleName, timestamp)
+		logDir = filepath.Join(logsDir, scheduleName, timestamp)
 	} else {
-		logDir = filepath.Join("logs", timestamp)
+		logDir = filepath.Join(logsDir, timestamp)
 	}
 	if chunkTimeout <= 0 {
 		chunkTimeout = 1
@@ -99,6 +105,25 @@ func (r *Runner) IsRunning() bool {
 	return r.isRunning
 }
 
+// saveOriginal passes the unredacted output to SaveOriginal when redaction is on
+func (r *Runner) saveOriginal(logPath, output string) error {
+	if r.Redact == nil || r.SaveOriginal == nil {
+		return nil
+	}
+	if err := r.SaveOriginal(logPath, output); err != nil {
+		return fmt.Errorf("unredacted copy: %v", err)
+	}
+	return nil
+}
+
+// writeLog saves output with WriteLog if set, otherwise with SaveLog
+func (r *Runner) writeLog(logPath, output string) (string, error) {
+	if r.WriteLog != nil {
+		return r.WriteLog(logPath, output)
+	}
+	return logPath, SaveLog(logPath, output)
+}
+
 // GetResults returns the current results
 func (r *Runner) GetResults() []ExecutionResult {
 	r.mu.Lock()
@@ -124,6 +149,9 @@ func (r *Runner) run() {
 		r.mu.Lock()
 		r.isRunning = false
 		r.mu.Unlock()
+		if r.OnDone != nil {
+			r.OnDone()
+		}
 	}()
 
 	// Create job channel
@@ -188,7 +216,9 @@ func (r *Runner) worker(wg *sync.WaitGroup, jobs <-chan serverJob) {
 
 		// Use per-server credentials if set, otherwise use global credentials
 		creds := r.Credentials
-		if server.Username != "" && server.Password != "" {
+		if server.Credentials != nil {
+			creds = server.Credentials
+		} else if server.Username != "" && server.Password != "" {
 			creds = &Credentials{
 				User:           server.Username,
 				Password:       server.Password,
@@ -207,9 +237,16 @@ func (r *Runner) worker(wg *sync.WaitGroup, jobs <-chan serverJob) {
 			r.completedCount++
 			r.mu.Unlock()
 		} else {
-			// Save log
+			// Save log (redacted if configured)
 			logPath := filepath.Join(r.LogDir, server.Hostname+".log")
-			if saveErr := SaveLog(logPath, output); saveErr != nil {
+			saveErr := r.saveOriginal(logPath, output)
+			if r.Redact != nil {
+				output = r.Redact(output)
+			}
+			if saveErr == nil {
+				logPath, saveErr = r.writeLog(logPath, output)
+			}
+			if saveErr != nil {
 				result.Success = false
 				result.Error = "Failed to save log: " + saveErr.Error()
 				r.mu.Lock()
@@ -7,6 +7,27 @@ type Server struct {
 	Username       string `json:"username,omitempty"`
 	Password       string `json:"password,omitempty"`
 	EnablePassword string `json:"enablePassword,omitempty"`
+
+	// Inventory attributes
+	Port              int      `json:"port,omitempty"`              // SSH port (0 = 22)
+	DeviceType        string   `json:"deviceType,omitempty"`        // Platform, e.g. "cisco_ios", "cisco_nxos"
+	Groups            []string `json:"groups,omitempty"`            // Names of the groups this server belongs to
+	Tags              []string `json:"tags,omitempty"`              // Free-form tags, e.g. "site:seoul", "role:core"
+	CredentialProfile string   `json:"credentialProfile,omitempty"` // Name of the credential profile to use
+	Site              string   `json:"site,omitempty"`
+	Description       string   `json:"description,omitempty"`
+	Source            string   `json:"source,omitempty"` // Where the entry came from, e.g. "netbox" (empty = added by hand)
+
+	// Credentials resolved from a credential profile at run time (never persisted)
+	Credentials *Credentials `json:"-"`
+}
+
+// SSHPort returns the SSH port for the server, defaulting to 22
+func (s Server) SSHPort() int {
+	if s.Port <= 0 {
+		return 22
+	}
+	return s.Port
 }
 
 // Credentials holds SSH login information
@@ -14,6 +35,8 @@ type Credentials struct {
 	User           string `json:"user"`
 	Password       string `json:"password"`
 	EnablePassword string `json:"enablePassword"`
+	PrivateKey     string `json:"privateKey,omitempty"` // PEM-encoded SSH private key
+	Passphrase     string `json:"passphrase,omitempty"` // Passphrase for PrivateKey
 }
 
 // ExecutionResult represents the result of executing commands on a server
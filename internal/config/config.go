@@ -2,18 +2,30 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"cisco-plink/internal/cisco"
 	"cisco-plink/internal/crypto"
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/redact"
 	"cisco-plink/internal/scheduler"
+	"cisco-plink/internal/schema"
 )
 
 const (
-	configDir     = "config"
-	schedulesFile = "schedules.json"
-	smtpFile      = "smtp.json"
+	schedulesFile  = "schedules.json"
+	smtpFile       = "smtp.json"
+	netBoxFile     = "netbox.json"
+	secretsFile    = "secrets.json"
+	redactionFile  = "redaction.json"
+	logStorageFile = "log_storage.json"
+	runStateFile   = "schedule_runs.json"
+	blackoutsFile  = "blackouts.json"
+	queueFile      = "queue.json"
+	executionFile  = "execution.json"
 )
 
 // SmtpConfig holds SMTP server settings
@@ -24,6 +36,70 @@ type SmtpConfig struct {
 	Password string `json:"password"` // stored encrypted
 }
 
+// NetBoxConfig holds the settings of the NetBox inventory source
+type NetBoxConfig struct {
+	URL                 string     `json:"url"`
+	Token               string     `json:"token"` // stored encrypted
+	Sites               []string   `json:"sites,omitempty"`
+	Roles               []string   `json:"roles,omitempty"`
+	Tags                []string   `json:"tags,omitempty"`
+	Statuses            []string   `json:"statuses,omitempty"`
+	SyncIntervalMinutes int        `json:"syncIntervalMinutes"` // 0 = manual sync only
+	LastSync            *time.Time `json:"lastSync,omitempty"`
+}
+
+// SecretsConfig holds the settings of external secret providers
+type SecretsConfig struct {
+	VaultAddress     string   `json:"vaultAddress"`
+	VaultToken       string   `json:"vaultToken"` // stored encrypted
+	VaultNamespace   string   `json:"vaultNamespace,omitempty"`
+	ApprovedCommands []string `json:"approvedCommands,omitempty"` // exec: commands allowed to run (secret.CommandHash)
+}
+
+// RedactionConfig holds the secret redaction rules and the settings for manual runs
+// (schedules carry their own settings)
+type RedactionConfig struct {
+	DisableBuiltin bool          `json:"disableBuiltin,omitempty"` // Use only the custom rules
+	Rules          []redact.Rule `json:"rules"`                    // Custom rules, applied after the built-in ones
+
+	RedactManualRuns     bool `json:"redactManualRuns"`     // Redact logs and Excel of manual runs
+	KeepUnredactedManual bool `json:"keepUnredactedManual"` // Also keep an encrypted unredacted copy of manual runs
+}
+
+// Redactor compiles the active rules
+func (c *RedactionConfig) Redactor() (*redact.Redactor, error) {
+	var rules []redact.Rule
+	if !c.DisableBuiltin {
+		rules = append(rules, redact.Builtin...)
+	}
+	rules = append(rules, c.Rules...)
+	return redact.New(rules)
+}
+
+// Limits of ExecutionConfig.MaxSessions
+const (
+	DefaultMaxSessions = 4
+	MaxSessionsLimit   = 32
+)
+
+// ExecutionConfig holds how many runs may execute at once
+type ExecutionConfig struct {
+	MaxSessions int `json:"maxSessions"` // Concurrent SSH sessions across all runs (0 = DefaultMaxSessions)
+}
+
+// Sessions returns the session cap with the default filled in
+func (c *ExecutionConfig) Sessions() int {
+	if c.MaxSessions <= 0 {
+		return DefaultMaxSessions
+	}
+	return min(c.MaxSessions, MaxSessionsLimit)
+}
+
+// LogStorageConfig holds how run outputs are stored
+type LogStorageConfig struct {
+	Encrypt bool `json:"encrypt"` // Store logs and Excel reports encrypted (.enc)
+}
+
 // Config holds all application configuration
 type Config struct {
 	Schedules []*scheduler.ScheduledTask `json:"schedules"`
@@ -31,43 +107,30 @@ type Config struct {
 
 // Load loads configuration from disk and decrypts sensitive fields
 func Load() (*Config, error) {
-	cfg := &Config{
-		Schedules: make([]*scheduler.ScheduledTask, 0),
-	}
+	defer crypto.LockStores()()
 
-	path := filepath.Join(configDir, schedulesFile)
-	data, err := os.ReadFile(path)
+	data, err := readConfig(schedulesSchema)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return cfg, nil // Return empty config if file doesn't exist
+			// Return empty config if file doesn't exist
+			return &Config{Schedules: make([]*scheduler.ScheduledTask, 0)}, nil
 		}
 		return nil, err
 	}
 
-	if err := json.Unmarshal(data, cfg); err != nil {
-		return nil, err
-	}
-
-	// Decrypt passwords
 	key, err := crypto.LoadOrGenerateKey()
 	if err != nil {
-		return cfg, nil // Return config with encrypted passwords if key fails
-	}
-
-	for _, task := range cfg.Schedules {
-		task.Password, task.EnablePassword = crypto.DecryptFields(task.Password, task.EnablePassword, key)
-		for j := range task.Servers {
-			task.Servers[j].Password, task.Servers[j].EnablePassword = crypto.DecryptFields(task.Servers[j].Password, task.Servers[j].EnablePassword, key)
-		}
+		return nil, err
 	}
-
-	return cfg, nil
+	return decodeConfig(data, key)
 }
 
 // Save saves configuration to disk with sensitive fields encrypted
 func Save(cfg *Config) error {
+	defer crypto.LockStores()()
+
 	// Ensure config directory exists
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
 		return err
 	}
 
@@ -76,7 +139,46 @@ func Save(cfg *Config) error {
 		return err
 	}
 
-	// Create a copy with encrypted passwords (don't modify in-memory tasks)
+	data, err := encodeConfig(cfg, key)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(schedulesSchema, data, 0600)
+}
+
+// decodeConfig parses schedules.json and decrypts the credentials
+func decodeConfig(data, key []byte) (*Config, error) {
+	cfg := &Config{
+		Schedules: make([]*scheduler.ScheduledTask, 0),
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+
+	decrypt := crypto.DecryptFields
+	if crypto.HasLegacySecrets(data) {
+		decrypt = crypto.DecryptLegacyFields
+	}
+	for _, task := range cfg.Schedules {
+		var err error
+		if task.Password, task.EnablePassword, err = decrypt(task.Password, task.EnablePassword, key); err != nil {
+			return nil, fmt.Errorf("schedule %s: %w", task.Name, err)
+		}
+		for j := range task.Servers {
+			srv := &task.Servers[j]
+			if srv.Password, srv.EnablePassword, err = decrypt(srv.Password, srv.EnablePassword, key); err != nil {
+				return nil, fmt.Errorf("schedule %s, server %s: %w", task.Name, srv.IP, err)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// encodeConfig serializes the configuration with credentials encrypted.
+// The in-memory tasks are not modified.
+func encodeConfig(cfg *Config, key []byte) ([]byte, error) {
 	saveCfg := &Config{
 		Schedules: make([]*scheduler.ScheduledTask, len(cfg.Schedules)),
 	}
@@ -85,35 +187,107 @@ func Save(cfg *Config) error {
 		taskCopy := *task
 		encPwd, encEnPwd, err := crypto.EncryptFields(task.Password, task.EnablePassword, key)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		taskCopy.Password = encPwd
 		taskCopy.EnablePassword = encEnPwd
 
-		// Encrypt per-server credentials
+		// Encrypt per-server credentials, each field on its own: a server
+		// logging in through a credential profile may still carry an
+		// enable password
 		taskCopy.Servers = make([]cisco.Server, len(task.Servers))
 		copy(taskCopy.Servers, task.Servers)
 		for j, srv := range task.Servers {
-			if srv.Password != "" {
-				ep, eep, err := crypto.EncryptFields(srv.Password, srv.EnablePassword, key)
-				if err != nil {
-					return err
-				}
-				taskCopy.Servers[j].Password = ep
-				taskCopy.Servers[j].EnablePassword = eep
+			ep, eep, err := crypto.EncryptFields(srv.Password, srv.EnablePassword, key)
+			if err != nil {
+				return nil, err
 			}
+			taskCopy.Servers[j].Password = ep
+			taskCopy.Servers[j].EnablePassword = eep
 		}
 
 		saveCfg.Schedules[i] = &taskCopy
 	}
 
-	data, err := json.MarshalIndent(saveCfg, "", "  ")
+	return schedulesSchema.Marshal(saveCfg)
+}
+
+// runStates is the format of schedule_runs.json
+type runStates struct {
+	Tasks map[string]scheduler.RunState `json:"tasks"`
+}
+
+// LoadRunStates loads when each schedule last ran and up to when its runs
+// are accounted for. A missing file yields no states.
+func LoadRunStates() (map[string]scheduler.RunState, error) {
+	data, err := readConfig(runStateSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return map[string]scheduler.RunState{}, nil
+		}
+		return nil, err
+	}
+
+	states := &runStates{}
+	if err := json.Unmarshal(data, states); err != nil {
+		return nil, err
+	}
+	if states.Tasks == nil {
+		states.Tasks = map[string]scheduler.RunState{}
+	}
+	return states.Tasks, nil
+}
+
+// SaveRunStates saves the run states. The file changes on every scheduled
+// run, so it is written without backups.
+func SaveRunStates(states map[string]scheduler.RunState) error {
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+
+	data, err := runStateSchema.Marshal(&runStates{Tasks: states})
+	if err != nil {
+		return err
+	}
+	return writeConfig(runStateSchema, data, 0644)
+}
+
+// blackoutsConfig is the format of blackouts.json
+type blackoutsConfig struct {
+	Blackouts []scheduler.Blackout `json:"blackouts"`
+}
+
+// LoadBlackouts loads the global blackout windows. A missing file yields none.
+func LoadBlackouts() ([]scheduler.Blackout, error) {
+	data, err := readConfig(blackoutsSchema)
 	if err != nil {
+		if os.IsNotExist(err) {
+			return []scheduler.Blackout{}, nil
+		}
+		return nil, err
+	}
+
+	cfg := &blackoutsConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Blackouts == nil {
+		cfg.Blackouts = []scheduler.Blackout{}
+	}
+	return cfg.Blackouts, nil
+}
+
+// SaveBlackouts saves the global blackout windows
+func SaveBlackouts(blackouts []scheduler.Blackout) error {
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
 		return err
 	}
 
-	path := filepath.Join(configDir, schedulesFile)
-	return os.WriteFile(path, data, 0644)
+	data, err := blackoutsSchema.Marshal(&blackoutsConfig{Blackouts: blackouts})
+	if err != nil {
+		return err
+	}
+	return writeConfig(blackoutsSchema, data, 0644)
 }
 
 // SaveSchedules saves only the schedules
@@ -126,29 +300,163 @@ func SaveSchedules(schedules []*scheduler.ScheduledTask) error {
 
 // LoadSmtp loads SMTP configuration with decrypted password
 func LoadSmtp() (*SmtpConfig, error) {
-	path := filepath.Join(configDir, smtpFile)
-	data, err := os.ReadFile(path)
+	defer crypto.LockStores()()
+
+	data, err := readConfig(smtpSchema)
 	if err != nil {
 		return nil, err
 	}
 
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return decodeSmtp(data, key)
+}
+
+// SaveSmtp saves SMTP configuration with encrypted password
+func SaveSmtp(cfg *SmtpConfig) error {
+	defer crypto.LockStores()()
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeSmtp(cfg, key)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(smtpSchema, data, 0600)
+}
+
+// decodeSmtp parses smtp.json and decrypts the password
+func decodeSmtp(data, key []byte) (*SmtpConfig, error) {
 	var cfg SmtpConfig
 	if err := json.Unmarshal(data, &cfg); err != nil {
 		return nil, err
 	}
 
-	// Decrypt password
+	decrypt := crypto.Decrypt
+	if crypto.HasLegacySecrets(data) {
+		decrypt = crypto.DecryptLegacy
+	}
+	password, err := decrypt(cfg.Password, key)
+	if err != nil {
+		return nil, fmt.Errorf("SMTP password: %w", err)
+	}
+	cfg.Password = password
+
+	return &cfg, nil
+}
+
+// encodeSmtp serializes SMTP settings with the password encrypted
+func encodeSmtp(cfg *SmtpConfig, key []byte) ([]byte, error) {
+	saveCfg := *cfg
+	if cfg.Password != "" {
+		encPwd, err := crypto.Encrypt(cfg.Password, key)
+		if err != nil {
+			return nil, err
+		}
+		saveCfg.Password = encPwd
+	}
+
+	return smtpSchema.Marshal(&saveCfg)
+}
+
+// LoadNetBox loads NetBox source settings with decrypted token
+func LoadNetBox() (*NetBoxConfig, error) {
+	defer crypto.LockStores()()
+
+	data, err := readConfig(netBoxSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return decodeNetBox(data, key)
+}
+
+// SaveNetBox saves NetBox source settings with encrypted token
+func SaveNetBox(cfg *NetBoxConfig) error {
+	defer crypto.LockStores()()
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+
 	key, err := crypto.LoadOrGenerateKey()
-	if err == nil && cfg.Password != "" {
-		cfg.Password = crypto.Decrypt(cfg.Password, key)
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeNetBox(cfg, key)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(netBoxSchema, data, 0600)
+}
+
+// decodeNetBox parses netbox.json and decrypts the token
+func decodeNetBox(data, key []byte) (*NetBoxConfig, error) {
+	var cfg NetBoxConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	token, err := crypto.Decrypt(cfg.Token, key)
+	if err != nil {
+		return nil, fmt.Errorf("NetBox token: %w", err)
 	}
+	cfg.Token = token
 
 	return &cfg, nil
 }
 
-// SaveSmtp saves SMTP configuration with encrypted password
-func SaveSmtp(cfg *SmtpConfig) error {
-	if err := os.MkdirAll(configDir, 0755); err != nil {
+// encodeNetBox serializes NetBox settings with the token encrypted
+func encodeNetBox(cfg *NetBoxConfig, key []byte) ([]byte, error) {
+	saveCfg := *cfg
+	if cfg.Token != "" {
+		encToken, err := crypto.Encrypt(cfg.Token, key)
+		if err != nil {
+			return nil, err
+		}
+		saveCfg.Token = encToken
+	}
+
+	return netBoxSchema.Marshal(&saveCfg)
+}
+
+// LoadSecrets loads secret provider settings with decrypted token
+func LoadSecrets() (*SecretsConfig, error) {
+	defer crypto.LockStores()()
+
+	data, err := readConfig(secretsSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return decodeSecrets(data, key)
+}
+
+// SaveSecrets saves secret provider settings with encrypted token
+func SaveSecrets(cfg *SecretsConfig) error {
+	defer crypto.LockStores()()
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
 		return err
 	}
 
@@ -157,19 +465,201 @@ func SaveSmtp(cfg *SmtpConfig) error {
 		return err
 	}
 
+	data, err := encodeSecrets(cfg, key)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(secretsSchema, data, 0600)
+}
+
+// decodeSecrets parses secrets.json and decrypts the token
+func decodeSecrets(data, key []byte) (*SecretsConfig, error) {
+	var cfg SecretsConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+
+	token, err := crypto.Decrypt(cfg.VaultToken, key)
+	if err != nil {
+		return nil, fmt.Errorf("vault token: %w", err)
+	}
+	cfg.VaultToken = token
+
+	return &cfg, nil
+}
+
+// encodeSecrets serializes secret provider settings with the token encrypted
+func encodeSecrets(cfg *SecretsConfig, key []byte) ([]byte, error) {
 	saveCfg := *cfg
-	if cfg.Password != "" {
-		encPwd, err := crypto.Encrypt(cfg.Password, key)
+	if cfg.VaultToken != "" {
+		encToken, err := crypto.Encrypt(cfg.VaultToken, key)
 		if err != nil {
-			return err
+			return nil, err
 		}
-		saveCfg.Password = encPwd
+		saveCfg.VaultToken = encToken
+	}
+
+	return secretsSchema.Marshal(&saveCfg)
+}
+
+// LoadRedaction loads the redaction settings. A missing file yields the defaults.
+func LoadRedaction() (*RedactionConfig, error) {
+	cfg := &RedactionConfig{Rules: make([]redact.Rule, 0)}
+
+	data, err := readConfig(redactionSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// SaveRedaction saves the redaction settings
+func SaveRedaction(cfg *RedactionConfig) error {
+	if _, err := cfg.Redactor(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+
+	data, err := redactionSchema.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(redactionSchema, data, 0644)
+}
+
+// LoadLogStorage loads the log storage settings. A missing file yields the defaults.
+func LoadLogStorage() (*LogStorageConfig, error) {
+	cfg := &LogStorageConfig{}
+
+	data, err := readConfig(logStorageSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
 	}
 
-	data, err := json.MarshalIndent(&saveCfg, "", "  ")
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// SaveLogStorage saves the log storage settings
+func SaveLogStorage(cfg *LogStorageConfig) error {
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+
+	data, err := logStorageSchema.Marshal(cfg)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(filepath.Join(configDir, smtpFile), data, 0644)
+	return writeConfig(logStorageSchema, data, 0644)
+}
+
+// LoadExecution loads the execution settings. A missing file yields the defaults.
+func LoadExecution() (*ExecutionConfig, error) {
+	cfg := &ExecutionConfig{}
+
+	data, err := readConfig(executionSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// SaveExecution saves the execution settings
+func SaveExecution(cfg *ExecutionConfig) error {
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+
+	data, err := executionSchema.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+
+	return writeConfig(executionSchema, data, 0644)
+}
+
+// ReencryptSchedules re-encrypts schedules.json for key rotation (see crypto.Reencoder)
+func ReencryptSchedules(oldKey, newKey []byte) (string, []byte, error) {
+	return reencrypt(schedulesSchema, func(data []byte) ([]byte, error) {
+		cfg, err := decodeConfig(data, oldKey)
+		if err != nil {
+			return nil, err
+		}
+		return encodeConfig(cfg, newKey)
+	})
+}
+
+// ReencryptSmtp re-encrypts smtp.json for key rotation (see crypto.Reencoder)
+func ReencryptSmtp(oldKey, newKey []byte) (string, []byte, error) {
+	return reencrypt(smtpSchema, func(data []byte) ([]byte, error) {
+		cfg, err := decodeSmtp(data, oldKey)
+		if err != nil {
+			return nil, err
+		}
+		return encodeSmtp(cfg, newKey)
+	})
+}
+
+// ReencryptNetBox re-encrypts netbox.json for key rotation (see crypto.Reencoder)
+func ReencryptNetBox(oldKey, newKey []byte) (string, []byte, error) {
+	return reencrypt(netBoxSchema, func(data []byte) ([]byte, error) {
+		cfg, err := decodeNetBox(data, oldKey)
+		if err != nil {
+			return nil, err
+		}
+		return encodeNetBox(cfg, newKey)
+	})
+}
+
+// ReencryptSecrets re-encrypts secrets.json for key rotation (see crypto.Reencoder)
+func ReencryptSecrets(oldKey, newKey []byte) (string, []byte, error) {
+	return reencrypt(secretsSchema, func(data []byte) ([]byte, error) {
+		cfg, err := decodeSecrets(data, oldKey)
+		if err != nil {
+			return nil, err
+		}
+		return encodeSecrets(cfg, newKey)
+	})
+}
+
+// reencrypt reads a config file and converts it; a missing file yields an empty path
+func reencrypt(s *schema.Schema, convert func([]byte) ([]byte, error)) (string, []byte, error) {
+	data, err := readConfig(s)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+
+	converted, err := convert(data)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", s.Name, err)
+	}
+	return filepath.Join(datadir.ConfigDir(), s.Name), converted, nil
 }
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/scheduler"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestEncodeDecodeConfig(t *testing.T) {
+	cfg := &Config{Schedules: []*scheduler.ScheduledTask{{
+		ID:             "s1",
+		Name:           "backup",
+		Username:       "admin",
+		Password:       "plain-login",
+		EnablePassword: "plain-task-enable",
+		Servers: []cisco.Server{
+			{IP: "10.0.0.1", Username: "admin", Password: "plain-server", EnablePassword: "plain-server-enable"},
+			{IP: "10.0.0.2", CredentialProfile: "core", EnablePassword: "plain-enable"},
+		},
+	}}}
+
+	data, err := encodeConfig(cfg, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("plain-")) {
+		t.Errorf("encoded schedules contain a plaintext secret: %s", data)
+	}
+
+	got, err := decodeConfig(data, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	task := got.Schedules[0]
+	if task.Password != "plain-login" || task.EnablePassword != "plain-task-enable" {
+		t.Errorf("task credentials = %q/%q", task.Password, task.EnablePassword)
+	}
+	for i, srv := range task.Servers {
+		want := cfg.Schedules[0].Servers[i]
+		if srv.Password != want.Password || srv.EnablePassword != want.EnablePassword {
+			t.Errorf("server %s: got %q/%q, want %q/%q", srv.IP, srv.Password, srv.EnablePassword, want.Password, want.EnablePassword)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/crypto"
+	"cisco-plink/internal/datadir"
+)
+
+// QueuedRun is a run of the execution queue, saved so that queued runs and
+// the run in progress survive a restart
+type QueuedRun struct {
+	ID                string         `json:"id"`
+	Priority          string         `json:"priority,omitempty"` // "high", "normal" (default) or "low"
+	TaskID            string         `json:"taskId,omitempty"`   // scheduled runs
+	ScheduleName      string         `json:"scheduleName"`
+	Servers           []cisco.Server `json:"servers"`
+	Commands          []string       `json:"commands"`
+	Username          string         `json:"username"`
+	Password          string         `json:"password"` // stored encrypted
+	EnablePassword    string         `json:"enablePassword"`
+	Timeout           int            `json:"timeout"`
+	EnableMode        bool           `json:"enableMode"`
+	DisablePaging     bool           `json:"disablePaging"`
+	AutoExportExcel   bool           `json:"autoExportExcel"`
+	CredentialProfile string         `json:"credentialProfile,omitempty"`
+	IsManual          bool           `json:"isManual"`
+	QueuedAt          time.Time      `json:"queuedAt"`
+	Interrupted       bool           `json:"interrupted,omitempty"` // was running when the app closed
+}
+
+// queueConfig is the format of queue.json
+type queueConfig struct {
+	Runs []QueuedRun `json:"runs"`
+}
+
+// LoadQueue loads the saved execution queue with decrypted credentials. A
+// missing file yields no runs.
+func LoadQueue() ([]QueuedRun, error) {
+	defer crypto.LockStores()()
+
+	data, err := readConfig(queueSchema)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []QueuedRun{}, nil
+		}
+		return nil, err
+	}
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return decodeQueue(data, key)
+}
+
+// SaveQueue saves the execution queue with credentials encrypted. The file
+// changes with every queued run, so it is written without backups.
+func SaveQueue(runs []QueuedRun) error {
+	defer crypto.LockStores()()
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeQueue(runs, key)
+	if err != nil {
+		return err
+	}
+	return writeConfig(queueSchema, data, 0600)
+}
+
+// decodeQueue parses queue.json and decrypts the credentials
+func decodeQueue(data, key []byte) ([]QueuedRun, error) {
+	cfg := &queueConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Runs == nil {
+		cfg.Runs = []QueuedRun{}
+	}
+
+	for i := range cfg.Runs {
+		run := &cfg.Runs[i]
+		var err error
+		if run.Password, run.EnablePassword, err = crypto.DecryptFields(run.Password, run.EnablePassword, key); err != nil {
+			return nil, fmt.Errorf("queued run %s: %w", run.ScheduleName, err)
+		}
+		for j := range run.Servers {
+			srv := &run.Servers[j]
+			if srv.Password, srv.EnablePassword, err = crypto.DecryptFields(srv.Password, srv.EnablePassword, key); err != nil {
+				return nil, fmt.Errorf("queued run %s, server %s: %w", run.ScheduleName, srv.IP, err)
+			}
+		}
+	}
+	return cfg.Runs, nil
+}
+
+// encodeQueue serializes the queue with credentials encrypted. The given
+// runs are not modified.
+func encodeQueue(runs []QueuedRun, key []byte) ([]byte, error) {
+	saveCfg := &queueConfig{Runs: make([]QueuedRun, len(runs))}
+
+	for i, run := range runs {
+		runCopy := run
+		encPwd, encEnPwd, err := crypto.EncryptFields(run.Password, run.EnablePassword, key)
+		if err != nil {
+			return nil, err
+		}
+		runCopy.Password = encPwd
+		runCopy.EnablePassword = encEnPwd
+
+		runCopy.Servers = make([]cisco.Server, len(run.Servers))
+		copy(runCopy.Servers, run.Servers)
+		for j, srv := range run.Servers {
+			ep, eep, err := crypto.EncryptFields(srv.Password, srv.EnablePassword, key)
+			if err != nil {
+				return nil, err
+			}
+			runCopy.Servers[j].Password = ep
+			runCopy.Servers[j].EnablePassword = eep
+		}
+
+		saveCfg.Runs[i] = runCopy
+	}
+
+	return queueSchema.Marshal(saveCfg)
+}
+
+// ReencryptQueue re-encrypts queue.json for key rotation (see crypto.Reencoder)
+func ReencryptQueue(oldKey, newKey []byte) (string, []byte, error) {
+	return reencrypt(queueSchema, func(data []byte) ([]byte, error) {
+		runs, err := decodeQueue(data, oldKey)
+		if err != nil {
+			return nil, err
+		}
+		return encodeQueue(runs, newKey)
+	})
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"cisco-plink/internal/cisco"
+)
+
+func TestEncodeDecodeQueue(t *testing.T) {
+	runs := []QueuedRun{{
+		ID:             "q1",
+		ScheduleName:   "backup",
+		Username:       "admin",
+		Password:       "plain-login",
+		EnablePassword: "plain-run-enable",
+		Servers: []cisco.Server{
+			{IP: "10.0.0.1", Username: "admin", Password: "plain-server", EnablePassword: "plain-server-enable"},
+			{IP: "10.0.0.2", CredentialProfile: "core", EnablePassword: "plain-enable"},
+		},
+		Commands: []string{"show version"},
+		QueuedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}}
+
+	data, err := encodeQueue(runs, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("plain-")) {
+		t.Errorf("encoded queue contains a plaintext secret: %s", data)
+	}
+
+	got, err := decodeQueue(data, testKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, runs) {
+		t.Errorf("got %+v, want %+v", got, runs)
+	}
+}
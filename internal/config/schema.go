@@ -0,0 +1,39 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"cisco-plink/internal/crypto"
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/schema"
+)
+
+// Schemas of the config files. When a file's format changes in a way that
+// old files would be misread, bump its Version and append a Migration.
+var (
+	schedulesSchema  = &schema.Schema{Name: schedulesFile, Version: 2, Migrations: []schema.Migration{schema.Unversioned, markLegacySecrets}}
+	smtpSchema       = &schema.Schema{Name: smtpFile, Version: 2, Migrations: []schema.Migration{schema.Unversioned, markLegacySecrets}}
+	netBoxSchema     = &schema.Schema{Name: netBoxFile, Version: 1, Migrations: []schema.Migration{schema.Unversioned}}
+	secretsSchema    = &schema.Schema{Name: secretsFile, Version: 1, Migrations: []schema.Migration{schema.Unversioned}}
+	redactionSchema  = &schema.Schema{Name: redactionFile, Version: 1, Migrations: []schema.Migration{schema.Unversioned}}
+	logStorageSchema = &schema.Schema{Name: logStorageFile, Version: 1, Migrations: []schema.Migration{schema.Unversioned}}
+	runStateSchema   = &schema.Schema{Name: runStateFile, Version: 1, NoBackups: true}
+	blackoutsSchema  = &schema.Schema{Name: blackoutsFile, Version: 1}
+	queueSchema      = &schema.Schema{Name: queueFile, Version: 1, NoBackups: true}
+	executionSchema  = &schema.Schema{Name: executionFile, Version: 1}
+)
+
+// markLegacySecrets marks files that may hold passwords saved in plaintext
+// before they were encrypted (see crypto.LegacyField)
+var markLegacySecrets = schema.Migration{From: 1, Description: "mark possibly plaintext secrets", Apply: crypto.MarkLegacySecrets}
+
+// readConfig reads a config file and upgrades it to the current schema
+func readConfig(s *schema.Schema) ([]byte, error) {
+	return s.ReadFile(filepath.Join(datadir.ConfigDir(), s.Name))
+}
+
+// writeConfig writes a config file, refusing to overwrite one from a newer schema
+func writeConfig(s *schema.Schema, data []byte, perm os.FileMode) error {
+	return s.WriteFile(filepath.Join(datadir.ConfigDir(), s.Name), data, perm)
+}
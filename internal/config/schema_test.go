@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/schema"
+)
+
+// allSchemas are the config files readConfig and writeConfig handle
+var allSchemas = []*schema.Schema{
+	schedulesSchema, smtpSchema, netBoxSchema, secretsSchema, redactionSchema,
+	logStorageSchema, runStateSchema, blackoutsSchema, queueSchema, executionSchema,
+}
+
+func initDataDir(t *testing.T) {
+	t.Helper()
+	if err := datadir.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeRaw(t *testing.T, s *schema.Schema, content string) string {
+	t.Helper()
+	path := filepath.Join(datadir.ConfigDir(), s.Name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigSchemas(t *testing.T) {
+	for _, s := range allSchemas {
+		t.Run(s.Name, func(t *testing.T) {
+			initDataDir(t)
+
+			// Every step up to the current version has a migration, or the
+			// file was versioned from the start and has none
+			if len(s.Migrations) > 0 {
+				for v := 0; v < s.Version; v++ {
+					found := false
+					for _, m := range s.Migrations {
+						found = found || m.From == v
+					}
+					if !found {
+						t.Errorf("no migration from version %d", v)
+					}
+				}
+			}
+
+			// A file from a newer version is neither read nor overwritten
+			newer := `{"schemaVersion":` + strconv.Itoa(s.Version+1) + `}`
+			path := writeRaw(t, s, newer)
+			var nv *schema.NewerVersionError
+			if _, err := readConfig(s); !errors.As(err, &nv) {
+				t.Errorf("readConfig: err = %v, want a NewerVersionError", err)
+			}
+			if err := writeConfig(s, []byte(`{"schemaVersion":1}`), 0600); !errors.As(err, &nv) {
+				t.Errorf("writeConfig: err = %v, want a NewerVersionError", err)
+			}
+			if data, _ := os.ReadFile(path); string(data) != newer {
+				t.Errorf("newer file was overwritten with %s", data)
+			}
+		})
+	}
+}
+
+func TestConfigUnversioned(t *testing.T) {
+	for _, s := range allSchemas {
+		if len(s.Migrations) == 0 {
+			continue
+		}
+		t.Run(s.Name, func(t *testing.T) {
+			initDataDir(t)
+			path := writeRaw(t, s, `{"server":"smtp.example.com"}`)
+
+			data, err := readConfig(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]interface{}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got[schema.VersionField] != float64(s.Version) || got["server"] != "smtp.example.com" {
+				t.Errorf("got %s, want version %d and the original fields", data, s.Version)
+			}
+			if backups, _ := filepath.Glob(path + ".v0-*.bak"); len(backups) != 1 {
+				t.Errorf("backups = %v, want one of version 0", backups)
+			}
+		})
+	}
+}
+
+func TestConfigVersionedWithoutMigrations(t *testing.T) {
+	// Files versioned from the start have no migration from version 0
+	initDataDir(t)
+	writeRaw(t, queueSchema, `{"items":[]}`)
+	if _, err := readConfig(queueSchema); err == nil {
+		t.Error("readConfig of an unversioned queue succeeded, want an error")
+	}
+}
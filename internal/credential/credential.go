@@ -0,0 +1,275 @@
+package credential
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/crypto"
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/schema"
+	"cisco-plink/internal/secret"
+)
+
+const credentialsFile = "credential_profiles.json"
+
+// credentialsSchema describes credential_profiles.json
+var credentialsSchema = &schema.Schema{Name: credentialsFile, Version: 1, Migrations: []schema.Migration{schema.Unversioned}}
+
+// Profile is a named set of device credentials that servers, groups and
+// schedules reference by name, so a password rotation is a single edit
+type Profile struct {
+	Name           string `json:"name"`
+	Description    string `json:"description,omitempty"`
+	Username       string `json:"username"`
+	Password       string `json:"password,omitempty"`       // stored encrypted
+	EnablePassword string `json:"enablePassword,omitempty"` // stored encrypted
+	PrivateKey     string `json:"privateKey,omitempty"`     // PEM, stored encrypted
+	Passphrase     string `json:"passphrase,omitempty"`     // private key passphrase, stored encrypted
+}
+
+// Credentials converts the profile to SSH login information
+func (p *Profile) Credentials() *cisco.Credentials {
+	return &cisco.Credentials{
+		User:           p.Username,
+		Password:       p.Password,
+		EnablePassword: p.EnablePassword,
+		PrivateKey:     p.PrivateKey,
+		Passphrase:     p.Passphrase,
+	}
+}
+
+// Validate checks that the profile can be used to log in
+func (p *Profile) Validate() error {
+	if strings.TrimSpace(p.Name) == "" {
+		return fmt.Errorf("profile name is required")
+	}
+	if p.Username == "" {
+		return fmt.Errorf("username is required")
+	}
+	if p.Password == "" && p.PrivateKey == "" {
+		return fmt.Errorf("a password or private key is required")
+	}
+	if p.PrivateKey != "" && !secret.IsReference(p.PrivateKey) {
+		if err := cisco.ValidatePrivateKey(p.PrivateKey, p.Passphrase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Store holds all credential profiles
+type Store struct {
+	Profiles []Profile `json:"profiles"`
+}
+
+// Load reads config/credential_profiles.json and decrypts the secrets.
+// A missing file yields an empty store.
+func Load() (*Store, error) {
+	defer crypto.LockStores()()
+
+	data, err := credentialsSchema.ReadFile(filepath.Join(datadir.ConfigDir(), credentialsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Store{Profiles: make([]Profile, 0)}, nil
+		}
+		return nil, err
+	}
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return decode(data, key)
+}
+
+// Save writes the store to config/credential_profiles.json with secrets encrypted
+func Save(store *Store) error {
+	defer crypto.LockStores()()
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return err
+	}
+
+	data, err := encode(store, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+	return credentialsSchema.WriteFile(filepath.Join(datadir.ConfigDir(), credentialsFile), data, 0600)
+}
+
+// Reencrypt re-encrypts the profile store for key rotation (see crypto.Reencoder)
+func Reencrypt(oldKey, newKey []byte) (string, []byte, error) {
+	path := filepath.Join(datadir.ConfigDir(), credentialsFile)
+	data, err := credentialsSchema.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+
+	store, err := decode(data, oldKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", credentialsFile, err)
+	}
+	data, err = encode(store, newKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, data, nil
+}
+
+// decode parses the profile store and decrypts the secrets
+func decode(data, key []byte) (*Store, error) {
+	store := &Store{Profiles: make([]Profile, 0)}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, err
+	}
+
+	for i := range store.Profiles {
+		p := &store.Profiles[i]
+		var err error
+		if p.Password, p.EnablePassword, err = crypto.DecryptFields(p.Password, p.EnablePassword, key); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+		if p.PrivateKey, p.Passphrase, err = crypto.DecryptFields(p.PrivateKey, p.Passphrase, key); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", p.Name, err)
+		}
+	}
+
+	return store, nil
+}
+
+// encode serializes the store with secrets encrypted
+func encode(store *Store, key []byte) ([]byte, error) {
+	saveStore := &Store{Profiles: make([]Profile, len(store.Profiles))}
+	for i, p := range store.Profiles {
+		var err error
+		if p.Password, p.EnablePassword, err = crypto.EncryptFields(p.Password, p.EnablePassword, key); err != nil {
+			return nil, err
+		}
+		if p.PrivateKey, p.Passphrase, err = crypto.EncryptFields(p.PrivateKey, p.Passphrase, key); err != nil {
+			return nil, err
+		}
+		saveStore.Profiles[i] = p
+	}
+
+	return credentialsSchema.Marshal(saveStore)
+}
+
+// Find returns the profile with the given name (case-insensitive), or nil
+func (s *Store) Find(name string) *Profile {
+	for i := range s.Profiles {
+		if strings.EqualFold(s.Profiles[i].Name, name) {
+			return &s.Profiles[i]
+		}
+	}
+	return nil
+}
+
+// Set creates or updates a profile. If originalName is set and differs from
+// the profile name, the profile is renamed.
+func (s *Store) Set(profile Profile, originalName string) error {
+	if err := profile.Validate(); err != nil {
+		return err
+	}
+
+	if originalName == "" {
+		originalName = profile.Name
+	}
+	if !strings.EqualFold(originalName, profile.Name) && s.Find(profile.Name) != nil {
+		return fmt.Errorf("credential profile '%s' already exists", profile.Name)
+	}
+
+	if p := s.Find(originalName); p != nil {
+		*p = profile
+	} else {
+		s.Profiles = append(s.Profiles, profile)
+	}
+
+	sort.Slice(s.Profiles, func(i, j int) bool {
+		return strings.ToLower(s.Profiles[i].Name) < strings.ToLower(s.Profiles[j].Name)
+	})
+	return nil
+}
+
+// Delete removes a profile
+func (s *Store) Delete(name string) {
+	profiles := s.Profiles[:0]
+	for _, p := range s.Profiles {
+		if !strings.EqualFold(p.Name, name) {
+			profiles = append(profiles, p)
+		}
+	}
+	s.Profiles = profiles
+}
+
+// Resolve attaches credentials to each server. The first match wins:
+//  1. the server's own credential profile
+//  2. the server's inline credentials (username + password)
+//  3. the credential profile of the first of the server's groups that has one
+//  4. defaultProfile (the schedule's or manual run's profile), if set
+//
+// Servers left without credentials use the runner's global credentials.
+// groupProfiles maps lower-cased group names to profile names.
+func (s *Store) Resolve(servers []cisco.Server, groupProfiles map[string]string, defaultProfile string) ([]cisco.Server, error) {
+	lookup := func(name string) (*cisco.Credentials, error) {
+		p := s.Find(name)
+		if p == nil {
+			return nil, fmt.Errorf("credential profile '%s' not found", name)
+		}
+		return p.Credentials(), nil
+	}
+
+	var defaultCreds *cisco.Credentials
+	if defaultProfile != "" {
+		creds, err := lookup(defaultProfile)
+		if err != nil {
+			return nil, err
+		}
+		defaultCreds = creds
+	}
+
+	resolved := make([]cisco.Server, len(servers))
+	for i, srv := range servers {
+		switch {
+		case srv.CredentialProfile != "":
+			creds, err := lookup(srv.CredentialProfile)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", srv.Hostname, err)
+			}
+			srv.Credentials = creds
+
+		case srv.Username != "" && srv.Password != "":
+			// Inline credentials, handled by the runner
+
+		default:
+			for _, g := range srv.Groups {
+				if name := groupProfiles[strings.ToLower(g)]; name != "" {
+					creds, err := lookup(name)
+					if err != nil {
+						return nil, fmt.Errorf("group %s: %v", g, err)
+					}
+					srv.Credentials = creds
+					break
+				}
+			}
+			if srv.Credentials == nil {
+				srv.Credentials = defaultCreds
+			}
+		}
+		resolved[i] = srv
+	}
+
+	return resolved, nil
+}
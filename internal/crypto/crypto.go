@@ -6,25 +6,44 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/fsutil"
 )
 
+// ErrDecrypt is returned when a stored value is ciphertext that the current key cannot open
+var ErrDecrypt = errors.New("cannot decrypt stored secret: the encryption key does not match")
+
 const (
-	keyDir  = "config"
 	keyFile = "encryption.key"
 	keySize = 32 // AES-256
 )
 
 // LoadOrGenerateKey loads the encryption key from disk, or generates a new one if it doesn't exist.
+// In master-password mode the key is taken from the unlocked vault instead,
+// and ErrVaultLocked is returned until Unlock succeeds.
 func LoadOrGenerateKey() ([]byte, error) {
-	path := filepath.Join(keyDir, keyFile)
+	if VaultEnabled() {
+		return unlockedKey()
+	}
+
+	path := filepath.Join(datadir.ConfigDir(), keyFile)
 
 	key, err := os.ReadFile(path)
-	if err == nil && len(key) == keySize {
+	if err == nil {
+		if len(key) != keySize {
+			// Never replace an existing key: everything encrypted with it would be lost
+			return nil, fmt.Errorf("encryption key %s is corrupt (%d bytes, expected %d)", path, len(key), keySize)
+		}
 		return key, nil
 	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
 
 	// Generate new key
 	key = make([]byte, keySize)
@@ -33,11 +52,11 @@ func LoadOrGenerateKey() ([]byte, error) {
 	}
 
 	// Ensure directory exists
-	if err := os.MkdirAll(keyDir, 0755); err != nil {
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
 		return nil, err
 	}
 
-	if err := os.WriteFile(path, key, 0600); err != nil {
+	if err := fsutil.WriteFile(path, key, 0600); err != nil {
 		return nil, err
 	}
 
@@ -71,40 +90,43 @@ func Encrypt(plaintext string, key []byte) (string, error) {
 }
 
 // Decrypt decrypts a base64-encoded AES-256-GCM ciphertext.
-// Returns the plaintext string on success, or the original input if decryption fails
-// (for backward compatibility with previously stored plaintext passwords).
-func Decrypt(encoded string, key []byte) string {
+// Values that cannot be ciphertext (not base64, or shorter than nonce + tag)
+// are returned unchanged for backward compatibility with previously stored
+// plaintext passwords. A value that looks like ciphertext but fails to
+// authenticate returns ErrDecrypt, e.g. when the key has been replaced.
+// Files that may still hold such passwords use DecryptLegacy instead.
+func Decrypt(encoded string, key []byte) (string, error) {
 	if encoded == "" {
-		return ""
+		return "", nil
 	}
 
 	data, err := base64.StdEncoding.DecodeString(encoded)
 	if err != nil {
-		return encoded // Not base64 = plaintext
+		return encoded, nil // Not base64 = plaintext
 	}
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return encoded
+		return "", err
 	}
 
 	gcm, err := cipher.NewGCM(block)
 	if err != nil {
-		return encoded
+		return "", err
 	}
 
 	nonceSize := gcm.NonceSize()
-	if len(data) < nonceSize {
-		return encoded // Too short = plaintext
+	if len(data) < nonceSize+gcm.Overhead() {
+		return encoded, nil // Too short = plaintext
 	}
 
 	nonce, ciphertext := data[:nonceSize], data[nonceSize:]
 	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
 	if err != nil {
-		return encoded // Decryption failed = plaintext
+		return "", ErrDecrypt
 	}
 
-	return string(plaintext)
+	return string(plaintext), nil
 }
 
 // EncryptFields encrypts password and enablePassword fields, returning an error if encryption fails.
@@ -123,7 +145,17 @@ func EncryptFields(password, enablePassword string, key []byte) (encPassword, en
 }
 
 // DecryptFields decrypts password and enablePassword fields.
-// Falls back to plaintext if decryption fails (backward compatibility).
-func DecryptFields(password, enablePassword string, key []byte) (string, string) {
-	return Decrypt(password, key), Decrypt(enablePassword, key)
+// Plaintext values are passed through (backward compatibility).
+func DecryptFields(password, enablePassword string, key []byte) (string, string, error) {
+	decPassword, err := Decrypt(password, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	decEnablePassword, err := Decrypt(enablePassword, key)
+	if err != nil {
+		return "", "", err
+	}
+
+	return decPassword, decEnablePassword, nil
 }
@@ -0,0 +1,187 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/fsutil"
+)
+
+// EncryptedExt is appended to the name of files encrypted at rest
+const EncryptedExt = ".enc"
+
+// fileKeyFile holds the key for encrypted files (logs, Excel reports),
+// itself encrypted with the data key. Rotating the data key only re-wraps
+// this key, so existing files stay readable without being rewritten.
+const fileKeyFile = "file.key"
+
+// fileMagic prefixes every encrypted file
+var fileMagic = []byte("CPENC1\n")
+
+// ErrNotEncrypted is returned when a file does not have the encrypted file header
+var ErrNotEncrypted = errors.New("file is not encrypted")
+
+// FileKey returns the key for encrypted files, generating it on first use.
+// It requires the data key, so it fails with ErrVaultLocked while the vault is locked.
+func FileKey() ([]byte, error) {
+	defer LockStores()()
+
+	key, err := LoadOrGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(datadir.ConfigDir(), fileKeyFile)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		return unwrapFileKey(data, key)
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	fileKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, fileKey); err != nil {
+		return nil, err
+	}
+	wrapped, err := Encrypt(base64.StdEncoding.EncodeToString(fileKey), key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return nil, err
+	}
+	if err := fsutil.WriteFile(path, []byte(wrapped), 0600); err != nil {
+		return nil, err
+	}
+	return fileKey, nil
+}
+
+// unwrapFileKey decrypts the stored file key with the data key
+func unwrapFileKey(data, key []byte) ([]byte, error) {
+	encoded, err := Decrypt(string(data), key)
+	if err != nil {
+		return nil, err
+	}
+	fileKey, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(fileKey) != keySize {
+		return nil, fmt.Errorf("file key %s is corrupt", filepath.Join(datadir.ConfigDir(), fileKeyFile))
+	}
+	return fileKey, nil
+}
+
+// ReencryptFileKey re-wraps the file key from oldKey to newKey (see Reencoder)
+func ReencryptFileKey(oldKey, newKey []byte) (string, []byte, error) {
+	path := filepath.Join(datadir.ConfigDir(), fileKeyFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+
+	fileKey, err := unwrapFileKey(data, oldKey)
+	if err != nil {
+		return "", nil, err
+	}
+	wrapped, err := Encrypt(base64.StdEncoding.EncodeToString(fileKey), newKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, []byte(wrapped), nil
+}
+
+// EncryptBytes encrypts data with AES-256-GCM into the encrypted file format
+func EncryptBytes(data, key []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(fileMagic)+len(nonce)+len(data)+gcm.Overhead())
+	out = append(out, fileMagic...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, data, fileMagic), nil
+}
+
+// DecryptBytes decrypts data produced by EncryptBytes
+func DecryptBytes(data, key []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, fileMagic) {
+		return nil, ErrNotEncrypted
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = data[len(fileMagic):]
+	if len(data) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, ErrDecrypt
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, fileMagic)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// WriteEncryptedFile encrypts data with the file key and writes it to path (0600)
+func WriteEncryptedFile(path string, data []byte) error {
+	key, err := FileKey()
+	if err != nil {
+		return err
+	}
+	encrypted, err := EncryptBytes(data, key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, encrypted, 0600)
+}
+
+// ReadEncryptedFile reads and decrypts a file written by WriteEncryptedFile
+func ReadEncryptedFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := FileKey()
+	if err != nil {
+		return nil, err
+	}
+	return DecryptBytes(data, key)
+}
+
+// ReadFile reads path and decrypts it if it is an encrypted file
+// (name ending in EncryptedExt), so callers can read both kinds transparently
+func ReadFile(path string) ([]byte, error) {
+	if filepath.Ext(path) == EncryptedExt {
+		return ReadEncryptedFile(path)
+	}
+	return os.ReadFile(path)
+}
+
+// newGCM creates an AES-256-GCM cipher
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,49 @@
+package crypto
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LegacyField marks a config file that may still hold plaintext secrets
+// from before they were encrypted. It is set by a schema migration of the
+// files that existed then (see MarkLegacySecrets) and dropped on the next
+// save, which encrypts every secret.
+const LegacyField = "legacySecrets"
+
+// MarkLegacySecrets is a schema migration setting LegacyField
+func MarkLegacySecrets(doc interface{}) (interface{}, error) {
+	m, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected %T at top level", doc)
+	}
+	m[LegacyField] = true
+	return m, nil
+}
+
+// HasLegacySecrets reports whether a config file is marked with LegacyField
+func HasLegacySecrets(data []byte) bool {
+	var marker map[string]json.RawMessage
+	if json.Unmarshal(data, &marker) != nil {
+		return false
+	}
+	return string(marker[LegacyField]) == "true"
+}
+
+// DecryptLegacy decrypts a value of a file marked with LegacyField. Values
+// that cannot be decrypted are taken to be plaintext and returned unchanged,
+// also when they happen to be valid base64.
+func DecryptLegacy(encoded string, key []byte) (string, error) {
+	plaintext, err := Decrypt(encoded, key)
+	if err != nil {
+		return encoded, nil
+	}
+	return plaintext, nil
+}
+
+// DecryptLegacyFields is DecryptFields for files marked with LegacyField
+func DecryptLegacyFields(password, enablePassword string, key []byte) (string, string, error) {
+	decPassword, _ := DecryptLegacy(password, key)
+	decEnablePassword, _ := DecryptLegacy(enablePassword, key)
+	return decPassword, decEnablePassword, nil
+}
@@ -0,0 +1,83 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Upper bounds for KDF parameters read from a file, so a crafted file
+// cannot make Open allocate unbounded memory or spin for minutes
+const (
+	maxArgonTime   = 16
+	maxArgonMemory = 1024 * 1024 // KiB (1 GiB)
+)
+
+// PasswordSealed is data encrypted with a key derived from a password.
+// It carries its own KDF parameters, so it can be opened on any machine
+// that knows the password, independently of the local encryption key.
+type PasswordSealed struct {
+	KDF     string `json:"kdf"` // "argon2id"
+	Salt    string `json:"salt"`
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // KiB
+	Threads uint8  `json:"threads"`
+	Data    string `json:"data"` // base64 of the encrypted file format (see EncryptBytes)
+}
+
+// SealWithPassword encrypts plaintext with a key derived from password
+func SealWithPassword(plaintext []byte, password string) (*PasswordSealed, error) {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	s := &PasswordSealed{
+		KDF:     "argon2id",
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Time:    argonTime,
+		Memory:  argonMemory,
+		Threads: argonThreads,
+	}
+
+	data, err := EncryptBytes(plaintext, argonKey(password, salt, s.Time, s.Memory, s.Threads))
+	if err != nil {
+		return nil, err
+	}
+	s.Data = base64.StdEncoding.EncodeToString(data)
+	return s, nil
+}
+
+// Open decrypts the data. A wrong password returns ErrWrongPassword.
+func (s *PasswordSealed) Open(password string) ([]byte, error) {
+	if s.KDF != "argon2id" {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", s.KDF)
+	}
+	if s.Time == 0 || s.Time > maxArgonTime || s.Memory == 0 || s.Memory > maxArgonMemory || s.Threads == 0 {
+		return nil, errors.New("invalid key derivation parameters")
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(s.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(s.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data: %v", err)
+	}
+
+	plaintext, err := DecryptBytes(data, argonKey(password, salt, s.Time, s.Memory, s.Threads))
+	if err == ErrDecrypt {
+		return nil, ErrWrongPassword
+	}
+	return plaintext, err
+}
+
+// argonKey derives a key from a password with Argon2id
+func argonKey(password string, salt []byte, time, memory uint32, threads uint8) []byte {
+	return argon2.IDKey([]byte(password), salt, time, memory, threads, keySize)
+}
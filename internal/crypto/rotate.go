@@ -0,0 +1,269 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/fsutil"
+)
+
+// Reencoder re-encrypts one secret store from oldKey to newKey. It returns
+// the store's file path and new content without writing anything, or an
+// empty path if the store does not exist.
+type Reencoder func(oldKey, newKey []byte) (path string, data []byte, err error)
+
+// stagedFile is a store whose re-encrypted content is ready to be committed
+type stagedFile struct {
+	path    string
+	data    []byte
+	old     []byte
+	perm    os.FileMode
+	written bool
+}
+
+// storesMu keeps secret stores from being read or saved while a key
+// rotation re-encrypts them, so no save is lost to staged content or
+// written with the retired key. Loads and saves hold it for reading,
+// RotateKey and CompleteRotation for writing.
+var storesMu sync.RWMutex
+
+// LockStores locks the secret stores for a load or save and returns the
+// unlock function. Loads and saves must not nest.
+func LockStores() (unlock func()) {
+	storesMu.RLock()
+	return storesMu.RUnlock
+}
+
+// pendingSuffix marks the new key of a rotation in progress: encryption.key.new,
+// or vault.json.new in master-password mode
+const pendingSuffix = ".new"
+
+// RotateKey generates a new encryption key and re-encrypts every store with it.
+// All stores are re-encrypted in memory first, so a store that cannot be
+// decrypted aborts the rotation before anything is written. The new key is
+// then saved beside the current one, the files are replaced one by one and
+// the new key is swapped in last. If a step fails, files already replaced are
+// restored; if the app stops midway, CompleteRotation finishes the rotation
+// at the next start. In master-password mode the data key is re-wrapped with
+// masterPassword, which must be the current master password.
+func RotateKey(masterPassword string, stores ...Reencoder) error {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	// Stores may be half re-encrypted; only CompleteRotation can sort them out
+	if _, err := os.Stat(pendingKeyPath()); err == nil {
+		return errors.New("a previous key rotation was interrupted and has not been completed")
+	}
+
+	var v *vault
+	if VaultEnabled() {
+		var err error
+		if v, err = readVault(); err != nil {
+			return err
+		}
+		if _, err := v.unwrap(masterPassword); err != nil {
+			return err
+		}
+	}
+
+	oldKey, err := LoadOrGenerateKey()
+	if err != nil {
+		return err
+	}
+
+	newKey := make([]byte, keySize)
+	if _, err := io.ReadFull(rand.Reader, newKey); err != nil {
+		return err
+	}
+
+	// Re-encrypt everything in memory
+	staged := make([]*stagedFile, 0, len(stores))
+	for _, store := range stores {
+		path, data, err := store(oldKey, newKey)
+		if err != nil {
+			return err
+		}
+		if path == "" {
+			continue
+		}
+
+		old, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		perm := os.FileMode(0600)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+		staged = append(staged, &stagedFile{path: path, data: data, old: old, perm: perm})
+	}
+
+	// Save the new key before any store is encrypted with it
+	if v != nil {
+		err = writeVaultFile(vaultFile+pendingSuffix, newKey, masterPassword)
+	} else {
+		err = fsutil.WriteFile(pendingKeyPath(), newKey, 0600)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save new key: %v", err)
+	}
+
+	// The new key is kept for CompleteRotation unless every file is restored
+	rollback := func() {
+		restored := true
+		for _, f := range staged {
+			if f.written && fsutil.WriteFile(f.path, f.old, f.perm) != nil {
+				restored = false
+			}
+		}
+		if restored {
+			os.Remove(pendingKeyPath())
+		}
+	}
+
+	// Replace the store files
+	for _, f := range staged {
+		if err := fsutil.WriteFile(f.path, f.data, f.perm); err != nil {
+			rollback()
+			return fmt.Errorf("failed to write %s: %v", f.path, err)
+		}
+		f.written = true
+	}
+
+	// Swap the new key in last
+	if err := installPendingKey(newKey); err != nil {
+		rollback()
+		return fmt.Errorf("failed to install new key: %v", err)
+	}
+
+	// Backups are encrypted with the retired key and can no longer be read
+	for _, f := range staged {
+		fsutil.RemoveBackups(f.path)
+	}
+	return nil
+}
+
+// CompleteRotation finishes a key rotation that was interrupted after its
+// new key was saved: stores still encrypted with the old key are
+// re-encrypted and the new key is swapped in. A store is left as it is if
+// the new key already reads it and it is not marked with LegacyField: such
+// a store takes values it cannot decrypt for plaintext, and the rotation
+// never writes the marker. Does nothing if no rotation is pending; in
+// master-password mode the vault must be unlocked. Returns whether a
+// rotation was completed.
+func CompleteRotation(stores ...Reencoder) (bool, error) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	oldKey, newKey, err := pendingRotation()
+	if err != nil || newKey == nil {
+		return false, err
+	}
+
+	var paths []string
+	for _, store := range stores {
+		if path, _, err := store(newKey, newKey); err == nil && !hasLegacyFile(path) {
+			paths = append(paths, path)
+			continue
+		}
+
+		path, data, err := store(oldKey, newKey)
+		if err != nil {
+			return false, err
+		}
+		if path == "" {
+			continue
+		}
+		perm := os.FileMode(0600)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+		if err := fsutil.WriteFile(path, data, perm); err != nil {
+			return false, fmt.Errorf("failed to write %s: %v", path, err)
+		}
+		paths = append(paths, path)
+	}
+
+	if err := installPendingKey(newKey); err != nil {
+		return false, fmt.Errorf("failed to install new key: %v", err)
+	}
+	for _, path := range paths {
+		if path != "" {
+			fsutil.RemoveBackups(path)
+		}
+	}
+	return true, nil
+}
+
+// hasLegacyFile reports whether the store file at path is marked with LegacyField
+func hasLegacyFile(path string) bool {
+	if path == "" {
+		return false
+	}
+	data, err := os.ReadFile(path)
+	return err == nil && HasLegacySecrets(data)
+}
+
+// pendingKeyPath returns the path of the new key of a rotation in progress
+func pendingKeyPath() string {
+	if VaultEnabled() {
+		return filepath.Join(datadir.ConfigDir(), vaultFile+pendingSuffix)
+	}
+	return filepath.Join(datadir.ConfigDir(), keyFile+pendingSuffix)
+}
+
+// pendingRotation returns the current and new key of an interrupted
+// rotation, or nil keys if none is pending
+func pendingRotation() (oldKey, newKey []byte, err error) {
+	if _, err := os.Stat(pendingKeyPath()); os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+
+	if VaultEnabled() {
+		if oldKey, err = unlockedKey(); err != nil {
+			return nil, nil, err
+		}
+		vaultMu.RLock()
+		newKey = pendingKey
+		vaultMu.RUnlock()
+		if newKey == nil {
+			return nil, nil, errors.New("the new key of the interrupted key rotation could not be read")
+		}
+		return oldKey, newKey, nil
+	}
+
+	if oldKey, err = LoadOrGenerateKey(); err != nil {
+		return nil, nil, err
+	}
+	if newKey, err = os.ReadFile(pendingKeyPath()); err != nil {
+		return nil, nil, err
+	}
+	if len(newKey) != keySize {
+		return nil, nil, fmt.Errorf("new key %s is corrupt (%d bytes, expected %d)", pendingKeyPath(), len(newKey), keySize)
+	}
+	return oldKey, newKey, nil
+}
+
+// installPendingKey replaces the current key with the saved new key
+func installPendingKey(newKey []byte) error {
+	pending := pendingKeyPath()
+	current := strings.TrimSuffix(pending, pendingSuffix)
+	if err := os.Rename(pending, current); err != nil {
+		return err
+	}
+
+	if VaultEnabled() {
+		vaultMu.Lock()
+		vaultKey = newKey
+		pendingKey = nil
+		vaultMu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package crypto
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cisco-plink/internal/datadir"
+)
+
+const testPassword = "correct horse battery"
+
+func initDataDir(t *testing.T) {
+	t.Helper()
+	if err := datadir.Init(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	Relock()
+	t.Cleanup(Relock)
+}
+
+// testStore is a secret store like the config files: one encrypted password,
+// and the legacy marker until it is saved
+type testStore struct {
+	Legacy   bool   `json:"legacySecrets,omitempty"`
+	Password string `json:"password"`
+}
+
+func storePath(name string) string {
+	return filepath.Join(datadir.ConfigDir(), name)
+}
+
+func writeStore(t *testing.T, name string, s testStore) {
+	t.Helper()
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(storePath(name), data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// readPassword decodes a store the way the config packages do
+func readPassword(name string, key []byte) (string, error) {
+	data, err := os.ReadFile(storePath(name))
+	if err != nil {
+		return "", err
+	}
+	var s testStore
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", err
+	}
+	decrypt := Decrypt
+	if HasLegacySecrets(data) {
+		decrypt = DecryptLegacy
+	}
+	return decrypt(s.Password, key)
+}
+
+// reencoder re-encrypts a test store; the result has no legacy marker
+func reencoder(name string) Reencoder {
+	return func(oldKey, newKey []byte) (string, []byte, error) {
+		password, err := readPassword(name, oldKey)
+		if err != nil {
+			return "", nil, err
+		}
+		enc, err := Encrypt(password, newKey)
+		if err != nil {
+			return "", nil, err
+		}
+		data, err := json.Marshal(testStore{Password: enc})
+		return storePath(name), data, err
+	}
+}
+
+func encrypt(t *testing.T, plaintext string, key []byte) string {
+	t.Helper()
+	enc, err := Encrypt(plaintext, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enc
+}
+
+func TestRotateKey(t *testing.T) {
+	initDataDir(t)
+	oldKey, err := LoadOrGenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeStore(t, "a.json", testStore{Password: encrypt(t, "secret-a", oldKey)})
+	writeStore(t, "b.json", testStore{Legacy: true, Password: "plain-b"})
+
+	if err := RotateKey("", reencoder("a.json"), reencoder("b.json")); err != nil {
+		t.Fatalf("RotateKey: %v", err)
+	}
+
+	newKey, err := LoadOrGenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(newKey) == string(oldKey) {
+		t.Fatal("the key was not replaced")
+	}
+	if _, err := os.Stat(pendingKeyPath()); !os.IsNotExist(err) {
+		t.Errorf("pending key left behind: %v", err)
+	}
+	for name, want := range map[string]string{"a.json": "secret-a", "b.json": "plain-b"} {
+		if got, err := readPassword(name, newKey); err != nil || got != want {
+			t.Errorf("%s: password = %q, %v, want %q", name, got, err, want)
+		}
+	}
+}
+
+func TestRotateKeyAbortsOnUndecryptableStore(t *testing.T) {
+	initDataDir(t)
+	oldKey, err := LoadOrGenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := make([]byte, keySize)
+	writeStore(t, "a.json", testStore{Password: encrypt(t, "secret-a", other)})
+
+	if err := RotateKey("", reencoder("a.json")); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("RotateKey: err = %v, want ErrDecrypt", err)
+	}
+	if key, _ := LoadOrGenerateKey(); string(key) != string(oldKey) {
+		t.Error("the key was replaced by a failed rotation")
+	}
+	if _, err := os.Stat(pendingKeyPath()); !os.IsNotExist(err) {
+		t.Errorf("pending key left behind: %v", err)
+	}
+}
+
+func TestCompleteRotation(t *testing.T) {
+	initDataDir(t)
+	oldKey, err := LoadOrGenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey := make([]byte, keySize)
+	newKey[0] = 1
+
+	// Interrupted after a.json was replaced; b.json, still marked, and
+	// c.json are encrypted with the old key
+	if err := os.WriteFile(pendingKeyPath(), newKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	writeStore(t, "a.json", testStore{Password: encrypt(t, "secret-a", newKey)})
+	writeStore(t, "b.json", testStore{Legacy: true, Password: encrypt(t, "secret-b", oldKey)})
+	writeStore(t, "c.json", testStore{Password: encrypt(t, "secret-c", oldKey)})
+
+	completed, err := CompleteRotation(reencoder("a.json"), reencoder("b.json"), reencoder("c.json"))
+	if err != nil || !completed {
+		t.Fatalf("CompleteRotation = %v, %v, want completed", completed, err)
+	}
+
+	if key, _ := LoadOrGenerateKey(); string(key) != string(newKey) {
+		t.Error("the new key was not installed")
+	}
+	for name, want := range map[string]string{"a.json": "secret-a", "b.json": "secret-b", "c.json": "secret-c"} {
+		data, _ := os.ReadFile(storePath(name))
+		if HasLegacySecrets(data) {
+			t.Errorf("%s is still marked", name)
+		}
+		if got, err := readPassword(name, newKey); err != nil || got != want {
+			t.Errorf("%s: password = %q, %v, want %q", name, got, err, want)
+		}
+	}
+
+	// Nothing is pending any more
+	if completed, err := CompleteRotation(reencoder("a.json")); completed || err != nil {
+		t.Errorf("second CompleteRotation = %v, %v, want nothing to do", completed, err)
+	}
+}
+
+func TestUnlockWithPendingKey(t *testing.T) {
+	initDataDir(t)
+	if err := EnableVault(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	oldKey, err := LoadOrGenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	newKey := make([]byte, keySize)
+	newKey[0] = 1
+	if err := writeVaultFile(vaultFile+pendingSuffix, newKey, testPassword); err != nil {
+		t.Fatal(err)
+	}
+	writeStore(t, "a.json", testStore{Password: encrypt(t, "secret-a", oldKey)})
+
+	Relock()
+	if err := Unlock("wrong password"); !errors.Is(err, ErrWrongPassword) {
+		t.Errorf("Unlock with a wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if err := Unlock(testPassword); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if err := RotateKey(testPassword, reencoder("a.json")); err == nil {
+		t.Error("RotateKey succeeded with an interrupted rotation pending")
+	}
+
+	if completed, err := CompleteRotation(reencoder("a.json")); err != nil || !completed {
+		t.Fatalf("CompleteRotation = %v, %v, want completed", completed, err)
+	}
+	if key, _ := LoadOrGenerateKey(); string(key) != string(newKey) {
+		t.Error("the new key was not unlocked")
+	}
+	if got, err := readPassword("a.json", newKey); err != nil || got != "secret-a" {
+		t.Errorf("password = %q, %v, want secret-a", got, err)
+	}
+
+	// The new key is unwrapped from vault.json after a restart
+	Relock()
+	if err := Unlock(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	if key, _ := LoadOrGenerateKey(); string(key) != string(newKey) {
+		t.Error("vault.json does not hold the new key")
+	}
+}
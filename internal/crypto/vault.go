@@ -0,0 +1,325 @@
+package crypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"cisco-plink/internal/datadir"
+	"cisco-plink/internal/fsutil"
+)
+
+const (
+	vaultFile = "vault.json"
+
+	// MinMasterPasswordLength is the shortest accepted master password
+	MinMasterPasswordLength = 8
+
+	// Argon2id parameters (RFC 9106 second recommended option)
+	argonTime    = 3
+	argonMemory  = 64 * 1024 // KiB
+	argonThreads = 4
+	saltSize     = 16
+)
+
+var (
+	// ErrVaultLocked is returned while master-password mode is enabled and
+	// the master password has not been entered yet
+	ErrVaultLocked = errors.New("vault is locked: enter the master password")
+
+	// ErrWrongPassword is returned when the master password does not match
+	ErrWrongPassword = errors.New("wrong master password")
+
+	// ErrVaultNotEnabled is returned by vault operations when master-password mode is off
+	ErrVaultNotEnabled = errors.New("master password is not enabled")
+)
+
+// vault holds the data key, wrapped with a key derived from the master password.
+// The data key itself is only ever kept in memory.
+type vault struct {
+	KDF        string `json:"kdf"` // "argon2id"
+	Salt       string `json:"salt"`
+	Time       uint32 `json:"time"`
+	Memory     uint32 `json:"memory"` // KiB
+	Threads    uint8  `json:"threads"`
+	WrappedKey string `json:"wrappedKey"` // data key encrypted with the derived key
+}
+
+var (
+	vaultMu    sync.RWMutex
+	vaultKey   []byte // unlocked data key
+	pendingKey []byte // new data key of an interrupted rotation (see CompleteRotation)
+)
+
+// VaultEnabled reports whether master-password mode is enabled
+func VaultEnabled() bool {
+	_, err := os.Stat(filepath.Join(datadir.ConfigDir(), vaultFile))
+	return err == nil
+}
+
+// IsLocked reports whether master-password mode is enabled and not yet unlocked
+func IsLocked() bool {
+	if !VaultEnabled() {
+		return false
+	}
+	vaultMu.RLock()
+	defer vaultMu.RUnlock()
+	return vaultKey == nil
+}
+
+// unlockedKey returns the data key held in memory, or ErrVaultLocked
+func unlockedKey() ([]byte, error) {
+	vaultMu.RLock()
+	defer vaultMu.RUnlock()
+	if vaultKey == nil {
+		return nil, ErrVaultLocked
+	}
+	return vaultKey, nil
+}
+
+// Unlock derives the key from the master password and keeps the data key in memory
+func Unlock(password string) error {
+	v, err := readVault()
+	if err != nil {
+		return err
+	}
+
+	key, err := v.unwrap(password)
+	if err != nil {
+		return err
+	}
+
+	// A rotation interrupted after saving its new key wrapped it with the
+	// same password
+	var pending []byte
+	if p, err := readVaultFile(vaultFile + pendingSuffix); err == nil {
+		if pending, err = p.unwrap(password); err != nil {
+			return fmt.Errorf("new key of the interrupted key rotation: %v", err)
+		}
+	} else if !errors.Is(err, ErrVaultNotEnabled) {
+		return err
+	}
+
+	vaultMu.Lock()
+	vaultKey = key
+	pendingKey = pending
+	vaultMu.Unlock()
+	return nil
+}
+
+// Relock forgets the unlocked data key, so the master password has to be
+// entered again. Used when the configuration directory changes.
+func Relock() {
+	vaultMu.Lock()
+	vaultKey = nil
+	pendingKey = nil
+	vaultMu.Unlock()
+}
+
+// EnableVault turns on master-password mode. The existing key from
+// encryption.key is wrapped with the master password and the key file is
+// removed, so data encrypted so far stays readable without re-encryption.
+func EnableVault(password string) error {
+	if VaultEnabled() {
+		return errors.New("master password is already enabled")
+	}
+	if len(password) < MinMasterPasswordLength {
+		return fmt.Errorf("master password must be at least %d characters", MinMasterPasswordLength)
+	}
+
+	key, err := LoadOrGenerateKey()
+	if err != nil {
+		return err
+	}
+
+	if err := writeVault(key, password); err != nil {
+		return err
+	}
+
+	vaultMu.Lock()
+	vaultKey = key
+	vaultMu.Unlock()
+
+	return os.Remove(filepath.Join(datadir.ConfigDir(), keyFile))
+}
+
+// errRotationPending refuses vault changes while vault.json.new, wrapped with
+// the current password, holds the new key of an interrupted rotation
+var errRotationPending = errors.New("a key rotation was interrupted and has not been completed; restart the application to complete it first")
+
+// rotationPending reports whether an interrupted rotation left its new key
+func rotationPending() bool {
+	_, err := os.Stat(filepath.Join(datadir.ConfigDir(), vaultFile+pendingSuffix))
+	return err == nil
+}
+
+// ChangeMasterPassword re-wraps the data key with a new master password
+func ChangeMasterPassword(oldPassword, newPassword string) error {
+	v, err := readVault()
+	if err != nil {
+		return err
+	}
+	if rotationPending() {
+		return errRotationPending
+	}
+	if len(newPassword) < MinMasterPasswordLength {
+		return fmt.Errorf("master password must be at least %d characters", MinMasterPasswordLength)
+	}
+
+	key, err := v.unwrap(oldPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := writeVault(key, newPassword); err != nil {
+		return err
+	}
+
+	vaultMu.Lock()
+	vaultKey = key
+	vaultMu.Unlock()
+	return nil
+}
+
+// DisableVault turns off master-password mode and writes the data key back
+// to encryption.key
+func DisableVault(password string) error {
+	v, err := readVault()
+	if err != nil {
+		return err
+	}
+	if rotationPending() {
+		return errRotationPending
+	}
+
+	key, err := v.unwrap(password)
+	if err != nil {
+		return err
+	}
+
+	if err := fsutil.WriteFile(filepath.Join(datadir.ConfigDir(), keyFile), key, 0600); err != nil {
+		return err
+	}
+
+	vaultMu.Lock()
+	vaultKey = nil
+	vaultMu.Unlock()
+
+	return os.Remove(filepath.Join(datadir.ConfigDir(), vaultFile))
+}
+
+// readVault loads config/vault.json
+func readVault() (*vault, error) {
+	return readVaultFile(vaultFile)
+}
+
+// readVaultFile loads a vault file from the config directory
+func readVaultFile(name string) (*vault, error) {
+	data, err := os.ReadFile(filepath.Join(datadir.ConfigDir(), name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrVaultNotEnabled
+		}
+		return nil, err
+	}
+
+	var v vault
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("invalid vault file: %v", err)
+	}
+	if v.KDF != "argon2id" {
+		return nil, fmt.Errorf("unsupported key derivation function: %s", v.KDF)
+	}
+	// Bounded like PasswordSealed.Open, so a damaged file can't make
+	// startup allocate gigabytes or hang
+	if v.Time == 0 || v.Time > maxArgonTime || v.Memory == 0 || v.Memory > maxArgonMemory || v.Threads == 0 {
+		return nil, errors.New("invalid vault file: invalid key derivation parameters")
+	}
+	return &v, nil
+}
+
+// writeVault wraps key with a key derived from password (fresh salt) and
+// writes config/vault.json
+func writeVault(key []byte, password string) error {
+	return writeVaultFile(vaultFile, key, password)
+}
+
+// writeVaultFile wraps key with password and writes the named vault file
+func writeVaultFile(name string, key []byte, password string) error {
+	salt := make([]byte, saltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return err
+	}
+
+	v := &vault{
+		KDF:     "argon2id",
+		Salt:    base64.StdEncoding.EncodeToString(salt),
+		Time:    argonTime,
+		Memory:  argonMemory,
+		Threads: argonThreads,
+	}
+
+	wrapped, err := Encrypt(string(key), v.deriveKey(password, salt))
+	if err != nil {
+		return err
+	}
+	v.WrappedKey = wrapped
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+	return fsutil.WriteFile(filepath.Join(datadir.ConfigDir(), name), data, 0600)
+}
+
+// deriveKey derives the key-encryption key from the master password
+func (v *vault) deriveKey(password string, salt []byte) []byte {
+	return argonKey(password, salt, v.Time, v.Memory, v.Threads)
+}
+
+// unwrap decrypts the data key with the master password
+func (v *vault) unwrap(password string) ([]byte, error) {
+	salt, err := base64.StdEncoding.DecodeString(v.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault salt: %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(v.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault key: %v", err)
+	}
+
+	block, err := aes.NewCipher(v.deriveKey(password, salt))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid vault key")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	key, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	if len(key) != keySize {
+		return nil, errors.New("invalid vault key size")
+	}
+	return key, nil
+}
@@ -0,0 +1,66 @@
+package crypto
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cisco-plink/internal/datadir"
+)
+
+func TestVaultChangesRefusedWhileRotationPending(t *testing.T) {
+	initDataDir(t)
+	if err := EnableVault(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	newKey := make([]byte, keySize)
+	if err := writeVaultFile(vaultFile+pendingSuffix, newKey, testPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ChangeMasterPassword(testPassword, "another password"); err != errRotationPending {
+		t.Errorf("ChangeMasterPassword: err = %v, want errRotationPending", err)
+	}
+	if err := DisableVault(testPassword); err != errRotationPending {
+		t.Errorf("DisableVault: err = %v, want errRotationPending", err)
+	}
+
+	// Both keys still unlock with the current password
+	Relock()
+	if err := Unlock(testPassword); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(datadir.ConfigDir(), keyFile)); !os.IsNotExist(err) {
+		t.Errorf("encryption.key was written: %v", err)
+	}
+}
+
+func TestChangeMasterPassword(t *testing.T) {
+	initDataDir(t)
+	if err := EnableVault(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	key, err := LoadOrGenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const newPassword = "another password"
+	if err := ChangeMasterPassword("wrong password", newPassword); err != ErrWrongPassword {
+		t.Errorf("ChangeMasterPassword with a wrong password: err = %v, want ErrWrongPassword", err)
+	}
+	if err := ChangeMasterPassword(testPassword, newPassword); err != nil {
+		t.Fatal(err)
+	}
+
+	Relock()
+	if err := Unlock(testPassword); err != ErrWrongPassword {
+		t.Errorf("Unlock with the old password: err = %v, want ErrWrongPassword", err)
+	}
+	if err := Unlock(newPassword); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadOrGenerateKey(); string(got) != string(key) {
+		t.Error("the data key changed with the master password")
+	}
+}
@@ -0,0 +1,241 @@
+package datadir
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"cisco-plink/internal/fsutil"
+)
+
+const (
+	// Flag selects the data root on the command line (--data-dir <path> or --data-dir=<path>)
+	Flag = "--data-dir"
+
+	// EnvVar selects the data root if Flag is not given
+	EnvVar = "AUTOLOGCOLLECTOR_DATA_DIR"
+
+	// DefaultWorkspace is the workspace stored directly in the data root
+	DefaultWorkspace = "default"
+
+	appName       = "AutoLogCollector"
+	configDir     = "config"
+	logsDir       = "logs"
+	workspacesDir = "workspaces"
+	lockFile      = ".lock"
+)
+
+var (
+	mu      sync.RWMutex
+	root    string // empty = relative to the working directory
+	current = DefaultWorkspace
+)
+
+// Resolve returns the data root: the command-line flag, then the
+// environment variable, then the executable's directory if it already holds
+// a config directory (portable installs and data from earlier versions),
+// then the platform's user configuration directory.
+func Resolve(args []string) (string, error) {
+	for i, arg := range args {
+		if arg == Flag && i+1 < len(args) {
+			return filepath.Abs(args[i+1])
+		}
+		if v, ok := strings.CutPrefix(arg, Flag+"="); ok {
+			return filepath.Abs(v)
+		}
+	}
+
+	if v := os.Getenv(EnvVar); v != "" {
+		return filepath.Abs(v)
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		dir := filepath.Dir(exe)
+		if info, err := os.Stat(filepath.Join(dir, configDir)); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, appName), nil
+}
+
+// Init sets the data root and restores the workspace selected last time.
+// A workspace that no longer exists falls back to the default one.
+func Init(dir string) error {
+	if err := os.MkdirAll(filepath.Join(dir, configDir), 0755); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	root = dir
+	current = DefaultWorkspace
+	mu.Unlock()
+
+	name, err := loadCurrent()
+	if err != nil {
+		return err
+	}
+	if name != DefaultWorkspace && exists(name) {
+		mu.Lock()
+		current = name
+		mu.Unlock()
+	}
+	return nil
+}
+
+// Root returns the data root
+func Root() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return root
+}
+
+// Current returns the name of the active workspace
+func Current() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// ConfigDir returns the config directory of the active workspace
+func ConfigDir() string {
+	return filepath.Join(Dir(Current()), configDir)
+}
+
+// LogsDir returns the logs directory of the active workspace
+func LogsDir() string {
+	return filepath.Join(Dir(Current()), logsDir)
+}
+
+// Dir returns the directory of a workspace. The default workspace is the
+// data root itself, so data from before workspaces stays where it was.
+func Dir(name string) string {
+	r := Root()
+	if name == DefaultWorkspace {
+		return r
+	}
+	return filepath.Join(r, workspacesDir, name)
+}
+
+// Lock takes the advisory lock held by the running instance on the data
+// root. It returns fsutil.ErrLocked if another instance holds it.
+func Lock() (*fsutil.Lock, error) {
+	dir := filepath.Join(Root(), configDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return fsutil.TryLock(filepath.Join(dir, lockFile))
+}
+
+// List returns the workspace names, the default workspace first
+func List() ([]string, error) {
+	names := []string{DefaultWorkspace}
+
+	entries, err := os.ReadDir(filepath.Join(Root(), workspacesDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return names, nil
+		}
+		return nil, err
+	}
+
+	var others []string
+	for _, e := range entries {
+		if e.IsDir() && ValidateName(e.Name()) == nil {
+			others = append(others, e.Name())
+		}
+	}
+	sort.Strings(others)
+	return append(names, others...), nil
+}
+
+// ValidateName checks that name can be used as a workspace (directory) name
+func ValidateName(name string) error {
+	if name == "" {
+		return errors.New("workspace name is required")
+	}
+	if len(name) > 64 {
+		return errors.New("workspace name must be at most 64 characters")
+	}
+	if strings.Trim(name, ".") == "" || strings.TrimSpace(name) != name {
+		return fmt.Errorf("invalid workspace name '%s'", name)
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ' ':
+		default:
+			return fmt.Errorf("workspace name may only contain letters, digits, spaces, '-', '_' and '.'")
+		}
+	}
+	return nil
+}
+
+// Create adds an empty workspace
+func Create(name string) error {
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if name == DefaultWorkspace || exists(name) {
+		return fmt.Errorf("workspace '%s' already exists", name)
+	}
+	if fsutil.ReadOnly() {
+		return fsutil.ErrReadOnly
+	}
+	return os.MkdirAll(filepath.Join(Dir(name), configDir), 0755)
+}
+
+// Switch makes name the active workspace and remembers it for the next start.
+// A read-only instance switches without remembering.
+func Switch(name string) error {
+	if name != DefaultWorkspace && !exists(name) {
+		return fmt.Errorf("workspace '%s' does not exist", name)
+	}
+
+	mu.Lock()
+	current = name
+	mu.Unlock()
+
+	if fsutil.ReadOnly() {
+		return nil
+	}
+	return saveCurrent(name)
+}
+
+// Delete removes a workspace with all its configuration and logs. The
+// default and the active workspace cannot be deleted.
+func Delete(name string) error {
+	if name == DefaultWorkspace {
+		return errors.New("the default workspace cannot be deleted")
+	}
+	if name == Current() {
+		return errors.New("the active workspace cannot be deleted; switch to another workspace first")
+	}
+	if err := ValidateName(name); err != nil {
+		return err
+	}
+	if !exists(name) {
+		return fmt.Errorf("workspace '%s' does not exist", name)
+	}
+	if fsutil.ReadOnly() {
+		return fsutil.ErrReadOnly
+	}
+	return os.RemoveAll(Dir(name))
+}
+
+// exists reports whether a named workspace directory exists
+func exists(name string) bool {
+	if ValidateName(name) != nil {
+		return false
+	}
+	info, err := os.Stat(Dir(name))
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,48 @@
+package datadir
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"cisco-plink/internal/schema"
+)
+
+const stateFile = "workspace.json"
+
+// stateSchema describes workspace.json, kept in the data root's config directory
+var stateSchema = &schema.Schema{Name: stateFile, Version: 1, Migrations: []schema.Migration{schema.Unversioned}}
+
+// state is the persisted workspace selection
+type state struct {
+	Current string `json:"current"`
+}
+
+// loadCurrent returns the workspace selected last time
+func loadCurrent() (string, error) {
+	data, err := stateSchema.ReadFile(filepath.Join(Root(), configDir, stateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DefaultWorkspace, nil
+		}
+		return "", err
+	}
+
+	var s state
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", err
+	}
+	if s.Current == "" {
+		return DefaultWorkspace, nil
+	}
+	return s.Current, nil
+}
+
+// saveCurrent remembers the active workspace
+func saveCurrent(name string) error {
+	data, err := stateSchema.Marshal(&state{Current: name})
+	if err != nil {
+		return err
+	}
+	return stateSchema.WriteFile(filepath.Join(Root(), configDir, stateFile), data, 0644)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"fmt"
+	"html"
 	"mime/multipart"
 	"net/smtp"
 	"net/textproto"
@@ -178,3 +179,92 @@ func SendResultEmail(cfg Config, zipPath string, taskName string, summary Summar
 
 	return nil
 }
+
+// SendMissedRunEmail reports runs of a schedule that were due while the app
+// was closed. action describes what was done about them.
+func SendMissedRunEmail(cfg Config, taskName string, missed []time.Time, truncated bool, action string) error {
+	count := fmt.Sprintf("%d", len(missed))
+	if truncated {
+		count += "+"
+	}
+	subject := fmt.Sprintf("[AutoLogCollector] %s — %s missed run(s)", taskName, count)
+
+	var times strings.Builder
+	for _, t := range missed {
+		times.WriteString(html.EscapeString(t.Format("2006-01-02 15:04 MST")))
+		times.WriteString("<br>")
+	}
+	if truncated {
+		times.WriteString("…")
+	}
+
+	body := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"></head>
+<body style="margin:0;padding:0;background:#f4f4f5;font-family:'Segoe UI',Arial,sans-serif;">
+<table width="100%%" cellpadding="0" cellspacing="0" style="background:#f4f4f5;padding:32px 0;">
+<tr><td align="center">
+<table width="520" cellpadding="0" cellspacing="0" style="background:#ffffff;border-radius:12px;overflow:hidden;box-shadow:0 1px 3px rgba(0,0,0,0.1);">
+  <tr>
+    <td style="background:#18181b;padding:24px 32px;">
+      <span style="color:#ffffff;font-size:18px;font-weight:700;letter-spacing:-0.3px;">AutoLogCollector</span>
+    </td>
+  </tr>
+  <tr>
+    <td style="padding:28px 32px 12px;">
+      <span style="display:inline-block;background:#f59e0b;color:#fff;font-size:12px;font-weight:700;padding:4px 12px;border-radius:20px;letter-spacing:0.5px;">MISSED RUNS</span>
+    </td>
+  </tr>
+  <tr>
+    <td style="padding:16px 32px 24px;">
+      <table width="100%%" cellpadding="0" cellspacing="0" style="border-collapse:collapse;">
+        <tr>
+          <td style="padding:12px 0;border-bottom:1px solid #e4e4e7;color:#71717a;font-size:13px;width:100px;">Schedule</td>
+          <td style="padding:12px 0;border-bottom:1px solid #e4e4e7;color:#18181b;font-size:15px;font-weight:600;">%s</td>
+        </tr>
+        <tr>
+          <td style="padding:12px 0;border-bottom:1px solid #e4e4e7;color:#71717a;font-size:13px;vertical-align:top;">Missed</td>
+          <td style="padding:12px 0;border-bottom:1px solid #e4e4e7;color:#18181b;font-size:15px;">%s</td>
+        </tr>
+        <tr>
+          <td style="padding:12px 0;color:#71717a;font-size:13px;">Action</td>
+          <td style="padding:12px 0;color:#18181b;font-size:15px;">%s</td>
+        </tr>
+      </table>
+    </td>
+  </tr>
+  <tr>
+    <td style="padding:0 32px 24px;">
+      <p style="margin:0;color:#a1a1aa;font-size:12px;">The application was not running when these runs were due.</p>
+    </td>
+  </tr>
+</table>
+</td></tr>
+</table>
+</body>
+</html>`,
+		html.EscapeString(taskName), times.String(), html.EscapeString(action))
+
+	return sendHTML(cfg, subject, body)
+}
+
+// sendHTML sends a message with an HTML body and no attachments
+func sendHTML(cfg Config, subject, body string) error {
+	from := cfg.Username
+	to := strings.Split(cfg.To, ",")
+	for i := range to {
+		to[i] = strings.TrimSpace(to[i])
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/html; charset=utf-8\r\n\r\n",
+		from, strings.Join(to, ", "), subject)
+	msg.WriteString(body)
+
+	addr := fmt.Sprintf("%s:%d", cfg.SmtpServer, cfg.SmtpPort)
+	auth := smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.SmtpServer)
+	if err := smtp.SendMail(addr, auth, from, to, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
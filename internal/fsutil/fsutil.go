@@ -0,0 +1,115 @@
+package fsutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// Backups is the number of previous versions SaveFile keeps
+// (<path>.bak.1 is the most recent)
+const Backups = 3
+
+// ErrReadOnly is returned by writes while another instance holds the lock
+var ErrReadOnly = errors.New("configuration is open read-only because another instance of the application is running")
+
+var readOnly atomic.Bool
+
+// SetReadOnly makes WriteFile and SaveFile refuse to write
+func SetReadOnly(ro bool) {
+	readOnly.Store(ro)
+}
+
+// ReadOnly reports whether writes are refused
+func ReadOnly() bool {
+	return readOnly.Load()
+}
+
+// WriteFile writes data to a temporary file in the same directory, syncs it
+// and renames it over path, so path always holds either the old or the new
+// content, even after a crash or power loss
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	// Persist the rename itself; not supported everywhere, so best effort
+	syncDir(dir)
+	return nil
+}
+
+// SaveFile is WriteFile that first keeps the current content of path as
+// <path>.bak.1, shifting older backups up to Backups. Nothing is rotated
+// if the content is unchanged.
+func SaveFile(path string, data []byte, perm os.FileMode) error {
+	if ReadOnly() {
+		return ErrReadOnly
+	}
+
+	current, err := os.ReadFile(path)
+	if err == nil && !bytes.Equal(current, data) {
+		if err := rotateBackups(path, current, perm); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", filepath.Base(path), err)
+		}
+	}
+	return WriteFile(path, data, perm)
+}
+
+// rotateBackups shifts <path>.bak.N and writes current as <path>.bak.1
+func rotateBackups(path string, current []byte, perm os.FileMode) error {
+	for i := Backups - 1; i >= 1; i-- {
+		from := BackupPath(path, i)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := os.Rename(from, BackupPath(path, i+1)); err != nil {
+			return err
+		}
+	}
+	return WriteFile(BackupPath(path, 1), current, perm)
+}
+
+// RemoveBackups deletes the backups SaveFile kept of path
+func RemoveBackups(path string) {
+	for i := 1; i <= Backups; i++ {
+		os.Remove(BackupPath(path, i))
+	}
+}
+
+// BackupPath returns the path of the n-th most recent backup of path
+func BackupPath(path string, n int) string {
+	return fmt.Sprintf("%s.bak.%d", path, n)
+}
@@ -0,0 +1,34 @@
+package fsutil
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrLocked is returned by TryLock when another process holds the lock
+var ErrLocked = errors.New("locked by another process")
+
+// Lock is an advisory lock held on a file until Unlock or process exit
+type Lock struct {
+	file *os.File
+}
+
+// TryLock takes an exclusive lock on path, creating the file if needed.
+// It does not wait: if another process holds the lock, ErrLocked is returned.
+func TryLock(path string) (*Lock, error) {
+	f, err := lockFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{file: f}, nil
+}
+
+// Unlock releases the lock
+func (l *Lock) Unlock() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
@@ -0,0 +1,32 @@
+//go:build windows
+
+package fsutil
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errSharingViolation is ERROR_SHARING_VIOLATION, which package syscall does not define
+const errSharingViolation syscall.Errno = 32
+
+// lockFile opens path without sharing, so no other process can open it
+// until the handle is closed
+func lockFile(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil, syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		if errors.Is(err, errSharingViolation) {
+			return nil, ErrLocked
+		}
+		return nil, err
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
+
+// syncDir is a no-op: Windows cannot open directories for syncing
+func syncDir(dir string) {}
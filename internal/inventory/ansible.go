@@ -0,0 +1,550 @@
+package inventory
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"cisco-plink/internal/cisco"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Ansible host variables mapped onto server fields
+const (
+	ansibleHost      = "ansible_host"
+	ansiblePort      = "ansible_port"
+	ansibleNetworkOS = "ansible_network_os"
+	ansibleUser      = "ansible_user"
+)
+
+// networkOSNames maps device types to Ansible network OS names
+var networkOSNames = map[string]string{
+	"cisco_ios":  "cisco.ios.ios",
+	"cisco_xe":   "cisco.ios.ios",
+	"cisco_nxos": "cisco.nxos.nxos",
+	"cisco_xr":   "cisco.iosxr.iosxr",
+	"cisco_asa":  "cisco.asa.asa",
+	"arista_eos": "arista.eos.eos",
+	"juniper":    "junipernetworks.junos.junos",
+}
+
+// deviceTypeNames maps Ansible network OS names (FQCN or short) to device types
+var deviceTypeNames = map[string]string{
+	"cisco.ios.ios":               "cisco_ios",
+	"ios":                         "cisco_ios",
+	"cisco.nxos.nxos":             "cisco_nxos",
+	"nxos":                        "cisco_nxos",
+	"cisco.iosxr.iosxr":           "cisco_xr",
+	"iosxr":                       "cisco_xr",
+	"cisco.asa.asa":               "cisco_asa",
+	"asa":                         "cisco_asa",
+	"arista.eos.eos":              "arista_eos",
+	"eos":                         "arista_eos",
+	"junipernetworks.junos.junos": "juniper",
+	"junos":                       "juniper",
+}
+
+// ansibleInventory is the intermediate form shared by the INI and YAML readers
+type ansibleInventory struct {
+	hosts     map[string]map[string]string // host alias -> host vars
+	order     []string                     // host aliases in first-seen order
+	groups    map[string][]string          // group -> direct host members
+	children  map[string][]string          // group -> child groups
+	groupVars map[string]map[string]string // group -> vars
+}
+
+func newAnsibleInventory() *ansibleInventory {
+	return &ansibleInventory{
+		hosts:     make(map[string]map[string]string),
+		groups:    make(map[string][]string),
+		children:  make(map[string][]string),
+		groupVars: make(map[string]map[string]string),
+	}
+}
+
+// addHost registers a host, merging vars, and adds it to group (if not empty)
+func (a *ansibleInventory) addHost(alias, group string, vars map[string]string) {
+	if _, ok := a.hosts[alias]; !ok {
+		a.hosts[alias] = make(map[string]string)
+		a.order = append(a.order, alias)
+	}
+	for k, v := range vars {
+		a.hosts[alias][k] = v
+	}
+	if group != "" && !containsFold(a.groups[group], alias) {
+		a.groups[group] = append(a.groups[group], alias)
+	}
+}
+
+// ReadAnsible parses an Ansible inventory file. Files ending in .yml/.yaml
+// are read as YAML, everything else as INI. Hosts are validated and
+// deduplicated like ParseRows rows; skipped hosts are listed in the result's
+// Errors. Also returns the groups of the imported hosts.
+func ReadAnsible(path string, data []byte) (*ImportResult, []string, error) {
+	var inv *ansibleInventory
+	var err error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		inv, err = parseAnsibleYAML(data)
+	default:
+		inv, err = parseAnsibleINI(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	result, groups := inv.toServers()
+	return result, groups, nil
+}
+
+// parseAnsibleINI reads the INI inventory format
+func parseAnsibleINI(r io.Reader) (*ansibleInventory, error) {
+	inv := newAnsibleInventory()
+	section, kind := "ungrouped", "hosts"
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid section header", lineNum)
+			}
+			section, kind = line[1:len(line)-1], "hosts"
+			if i := strings.LastIndex(section, ":"); i >= 0 {
+				section, kind = section[:i], section[i+1:]
+			}
+			if kind != "hosts" && kind != "vars" && kind != "children" {
+				return nil, fmt.Errorf("line %d: unknown section type '%s'", lineNum, kind)
+			}
+			continue
+		}
+
+		fields, err := splitINIFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+
+		switch kind {
+		case "hosts":
+			vars := make(map[string]string)
+			for _, f := range fields[1:] {
+				k, v, ok := strings.Cut(f, "=")
+				if !ok {
+					return nil, fmt.Errorf("line %d: expected key=value, got '%s'", lineNum, f)
+				}
+				vars[k] = v
+			}
+			group := section
+			if group == "all" {
+				group = ""
+			}
+			inv.addHost(fields[0], group, vars)
+
+		case "vars":
+			k, v, ok := strings.Cut(line, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: expected key=value", lineNum)
+			}
+			if inv.groupVars[section] == nil {
+				inv.groupVars[section] = make(map[string]string)
+			}
+			inv.groupVars[section][strings.TrimSpace(k)] = unquote(strings.TrimSpace(v))
+
+		case "children":
+			inv.children[section] = append(inv.children[section], fields[0])
+		}
+	}
+
+	return inv, scanner.Err()
+}
+
+// splitINIFields splits a host line on whitespace, honoring quotes
+func splitINIFields(line string) ([]string, error) {
+	var fields []string
+	var cur strings.Builder
+	var quote rune
+loop:
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			// Inline comment
+			if cur.Len() == 0 {
+				break loop
+			}
+			cur.WriteRune(r)
+		case r == ' ' || r == '\t':
+			if cur.Len() > 0 {
+				fields = append(fields, cur.String())
+				cur.Reset()
+			}
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote")
+	}
+	if cur.Len() > 0 {
+		fields = append(fields, cur.String())
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty line")
+	}
+	return fields, nil
+}
+
+// unquote strips matching surrounding quotes
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// yamlGroup is a group node of the YAML inventory format
+type yamlGroup struct {
+	Hosts    map[string]map[string]interface{} `yaml:"hosts,omitempty"`
+	Vars     map[string]interface{}            `yaml:"vars,omitempty"`
+	Children map[string]*yamlGroup             `yaml:"children,omitempty"`
+}
+
+// parseAnsibleYAML reads the YAML inventory format
+func parseAnsibleYAML(data []byte) (*ansibleInventory, error) {
+	var root map[string]*yamlGroup
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("invalid YAML inventory: %v", err)
+	}
+
+	inv := newAnsibleInventory()
+	var walk func(name string, g *yamlGroup)
+	walk = func(name string, g *yamlGroup) {
+		if g == nil {
+			return
+		}
+		group := name
+		if group == "all" {
+			group = ""
+		}
+		for _, alias := range sortedKeys(g.Hosts) {
+			inv.addHost(alias, group, stringVars(g.Hosts[alias]))
+		}
+		if len(g.Vars) > 0 {
+			inv.groupVars[name] = stringVars(g.Vars)
+		}
+		for _, child := range sortedKeys(g.Children) {
+			inv.children[name] = append(inv.children[name], child)
+			walk(child, g.Children[child])
+		}
+	}
+	for _, name := range sortedKeys(root) {
+		walk(name, root[name])
+	}
+	return inv, nil
+}
+
+// stringVars converts scalar YAML values to strings, dropping complex ones
+func stringVars(vars map[string]interface{}) map[string]string {
+	result := make(map[string]string, len(vars))
+	for k, v := range vars {
+		switch val := v.(type) {
+		case string:
+			result[k] = val
+		case int, int64, float64, bool:
+			result[k] = fmt.Sprint(val)
+		}
+	}
+	return result
+}
+
+// sortedKeys returns map keys in sorted order for stable output
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toServers flattens the inventory into servers. A host belongs to every
+// group it is listed in and to all their parent groups; group vars apply
+// where the host doesn't set the variable itself.
+func (a *ansibleInventory) toServers() (*ImportResult, []string) {
+	parents := make(map[string][]string)
+	for parent, kids := range a.children {
+		for _, k := range kids {
+			parents[k] = append(parents[k], parent)
+		}
+	}
+
+	// ancestors returns the group and all its parents (cycle-safe)
+	ancestors := func(group string) []string {
+		var result []string
+		seen := make(map[string]bool)
+		stack := []string{group}
+		for len(stack) > 0 {
+			g := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if seen[g] {
+				continue
+			}
+			seen[g] = true
+			result = append(result, g)
+			stack = append(stack, parents[g]...)
+		}
+		return result
+	}
+
+	hostGroups := make(map[string][]string)
+	for _, group := range sortedKeys(a.groups) {
+		for _, alias := range a.groups[group] {
+			for _, g := range ancestors(group) {
+				if !containsFold(hostGroups[alias], g) {
+					hostGroups[alias] = append(hostGroups[alias], g)
+				}
+			}
+		}
+	}
+
+	var groupNames []string
+	result := newImportResult()
+	for _, alias := range a.order {
+		vars := make(map[string]string)
+		for _, g := range append([]string{"all"}, hostGroups[alias]...) {
+			for k, v := range a.groupVars[g] {
+				vars[k] = v
+			}
+		}
+		for k, v := range a.hosts[alias] {
+			vars[k] = v
+		}
+
+		srv := cisco.Server{
+			IP:       alias,
+			Hostname: alias,
+			Username: vars[ansibleUser],
+		}
+		if host := vars[ansibleHost]; host != "" {
+			srv.IP = host
+		}
+		errPrefix := "host " + alias + ": "
+		if err := validateAddress(srv.IP); err != nil {
+			result.Errors = append(result.Errors, RowError{Message: errPrefix + err.Error()})
+			continue
+		}
+		if p := vars[ansiblePort]; p != "" {
+			port, err := strconv.Atoi(p)
+			if err != nil || port < 1 || port > 65535 {
+				result.Errors = append(result.Errors, RowError{Message: fmt.Sprintf("%sinvalid port '%s'", errPrefix, p)})
+				continue
+			}
+			srv.Port = port
+		}
+		if netOS := vars[ansibleNetworkOS]; netOS != "" {
+			srv.DeviceType = netOS
+			if dt, ok := deviceTypeNames[strings.ToLower(netOS)]; ok {
+				srv.DeviceType = dt
+			}
+		}
+		for _, g := range hostGroups[alias] {
+			if g != "all" && g != "ungrouped" {
+				srv.Groups = append(srv.Groups, g)
+			}
+		}
+
+		n := len(result.Servers)
+		result.addServer(0, errPrefix, srv)
+		if len(result.Servers) == n {
+			continue
+		}
+		for _, g := range srv.Groups {
+			if !containsFold(groupNames, g) {
+				groupNames = append(groupNames, g)
+			}
+		}
+	}
+
+	return result, groupNames
+}
+
+// ansibleHostVars returns the Ansible host vars for a server
+func ansibleHostVars(s cisco.Server) [][2]string {
+	vars := [][2]string{{ansibleHost, s.IP}}
+	if s.Port != 0 {
+		vars = append(vars, [2]string{ansiblePort, strconv.Itoa(s.Port)})
+	}
+	if s.DeviceType != "" {
+		netOS := s.DeviceType
+		if name, ok := networkOSNames[strings.ToLower(netOS)]; ok {
+			netOS = name
+		}
+		vars = append(vars, [2]string{ansibleNetworkOS, netOS})
+	}
+	if s.Username != "" {
+		vars = append(vars, [2]string{ansibleUser, s.Username})
+	}
+	return vars
+}
+
+// ansibleAliases returns a unique inventory alias per server: the hostname
+// with spaces replaced, or the IP when hostnames collide
+func ansibleAliases(servers []cisco.Server) []string {
+	aliases := make([]string, len(servers))
+	used := make(map[string]bool)
+	for i, s := range servers {
+		alias := strings.Join(strings.Fields(s.Hostname), "_")
+		if alias == "" || used[strings.ToLower(alias)] {
+			alias = s.IP
+		}
+		used[strings.ToLower(alias)] = true
+		aliases[i] = alias
+	}
+	return aliases
+}
+
+// ansibleGroupName makes a group name readable as an INI section header.
+// Only whitespace and characters with a meaning in the INI format are
+// replaced, so names like "site-seoul" are kept as they are.
+func ansibleGroupName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || strings.ContainsRune(`[]:#;="'`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// WriteAnsible writes servers as an Ansible inventory. Files ending in
+// .yml/.yaml are written as YAML, everything else as INI.
+// Passwords are never written. Also returns the groups whose names had to
+// be changed (old name -> written name), which a re-import would not match.
+func WriteAnsible(path string, servers []cisco.Server) ([]byte, map[string]string, error) {
+	ungrouped, groups, renamed := groupedAliases(servers)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		data, err := writeAnsibleYAML(servers, ungrouped, groups)
+		return data, renamed, err
+	default:
+		return writeAnsibleINI(servers, ungrouped, groups), renamed, nil
+	}
+}
+
+// groupedAliases returns ungrouped host indexes, group -> host indexes and
+// the renamed groups
+func groupedAliases(servers []cisco.Server) ([]int, map[string][]int, map[string]string) {
+	var ungrouped []int
+	groups := make(map[string][]int)
+	renamed := make(map[string]string)
+	for i, s := range servers {
+		if len(s.Groups) == 0 {
+			ungrouped = append(ungrouped, i)
+			continue
+		}
+		for _, g := range s.Groups {
+			name := ansibleGroupName(g)
+			if name != g {
+				renamed[g] = name
+			}
+			if !slices.Contains(groups[name], i) {
+				groups[name] = append(groups[name], i)
+			}
+		}
+	}
+	return ungrouped, groups, renamed
+}
+
+// writeAnsibleINI renders the INI format
+func writeAnsibleINI(servers []cisco.Server, ungrouped []int, groups map[string][]int) []byte {
+	aliases := ansibleAliases(servers)
+
+	var b strings.Builder
+	hostLine := func(i int) {
+		b.WriteString(aliases[i])
+		for _, kv := range ansibleHostVars(servers[i]) {
+			v := kv[1]
+			if strings.ContainsAny(v, " \t#") {
+				v = strconv.Quote(v)
+			}
+			fmt.Fprintf(&b, " %s=%s", kv[0], v)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, i := range ungrouped {
+		hostLine(i)
+	}
+	for _, g := range sortedKeys(groups) {
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "[%s]\n", g)
+		for _, i := range groups[g] {
+			hostLine(i)
+		}
+	}
+	return []byte(b.String())
+}
+
+// writeAnsibleYAML renders the YAML format
+func writeAnsibleYAML(servers []cisco.Server, ungrouped []int, groups map[string][]int) ([]byte, error) {
+	aliases := ansibleAliases(servers)
+
+	hostMap := func(indexes []int) map[string]map[string]interface{} {
+		hosts := make(map[string]map[string]interface{}, len(indexes))
+		for _, i := range indexes {
+			vars := make(map[string]interface{})
+			for _, kv := range ansibleHostVars(servers[i]) {
+				if kv[0] == ansiblePort {
+					vars[kv[0]] = servers[i].Port
+				} else {
+					vars[kv[0]] = kv[1]
+				}
+			}
+			hosts[aliases[i]] = vars
+		}
+		return hosts
+	}
+
+	all := &yamlGroup{}
+	if len(ungrouped) > 0 {
+		all.Hosts = hostMap(ungrouped)
+	}
+	if len(groups) > 0 {
+		all.Children = make(map[string]*yamlGroup, len(groups))
+		for g, indexes := range groups {
+			all.Children[g] = &yamlGroup{Hosts: hostMap(indexes)}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]*yamlGroup{"all": all}); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
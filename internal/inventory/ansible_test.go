@@ -0,0 +1,198 @@
+package inventory
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"cisco-plink/internal/cisco"
+)
+
+func TestReadAnsibleINI(t *testing.T) {
+	data := `# comment
+standalone ansible_host=10.0.0.9
+
+[core]
+core-1 ansible_host=10.0.0.1 ansible_port=2222 ansible_user=admin
+core-2 ansible_host=10.0.0.2 ansible_network_os=nxos
+
+[access]
+sw1 ansible_host="10.0.1.1"
+
+[site-seoul:children]
+core
+access
+
+[site-seoul:vars]
+ansible_network_os=cisco.ios.ios
+`
+	result, groups, err := ReadAnsible("hosts", []byte(data))
+	if err != nil {
+		t.Fatalf("ReadAnsible: %v", err)
+	}
+
+	want := []cisco.Server{
+		{IP: "10.0.0.9", Hostname: "standalone"},
+		{IP: "10.0.0.1", Hostname: "core-1", Port: 2222, Username: "admin", DeviceType: "cisco_ios", Groups: []string{"core", "site-seoul"}},
+		{IP: "10.0.0.2", Hostname: "core-2", DeviceType: "cisco_nxos", Groups: []string{"core", "site-seoul"}},
+		{IP: "10.0.1.1", Hostname: "sw1", DeviceType: "cisco_ios", Groups: []string{"access", "site-seoul"}},
+	}
+	if !reflect.DeepEqual(result.Servers, want) {
+		t.Errorf("servers = %+v, want %+v", result.Servers, want)
+	}
+	if len(result.Errors) != 0 {
+		t.Errorf("errors = %+v, want none", result.Errors)
+	}
+	wantGroups := []string{"core", "site-seoul", "access"}
+	if !slices.Equal(groups, wantGroups) {
+		t.Errorf("groups = %q, want %q", groups, wantGroups)
+	}
+}
+
+func TestReadAnsibleYAML(t *testing.T) {
+	data := `all:
+  hosts:
+    standalone:
+      ansible_host: 10.0.0.9
+  children:
+    core:
+      hosts:
+        core-1:
+          ansible_host: 10.0.0.1
+          ansible_port: 2222
+      vars:
+        ansible_network_os: cisco.iosxr.iosxr
+`
+	result, groups, err := ReadAnsible("inventory.yml", []byte(data))
+	if err != nil {
+		t.Fatalf("ReadAnsible: %v", err)
+	}
+
+	want := []cisco.Server{
+		{IP: "10.0.0.9", Hostname: "standalone"},
+		{IP: "10.0.0.1", Hostname: "core-1", Port: 2222, DeviceType: "cisco_xr", Groups: []string{"core"}},
+	}
+	if !reflect.DeepEqual(result.Servers, want) {
+		t.Errorf("servers = %+v, want %+v", result.Servers, want)
+	}
+	if !slices.Equal(groups, []string{"core"}) {
+		t.Errorf("groups = %q, want core", groups)
+	}
+}
+
+func TestReadAnsibleSkipsInvalidHosts(t *testing.T) {
+	data := `[core]
+core-1 ansible_host=10.0.0.1
+core-1b ansible_host=10.0.0.1
+bad ansible_host=not_valid!
+badport ansible_host=10.0.0.3 ansible_port=70000
+
+[dup]
+core-1b
+`
+	result, groups, err := ReadAnsible("hosts.ini", []byte(data))
+	if err != nil {
+		t.Fatalf("ReadAnsible: %v", err)
+	}
+
+	if len(result.Servers) != 1 || result.Servers[0].Hostname != "core-1" {
+		t.Errorf("servers = %+v, want only core-1", result.Servers)
+	}
+	if !slices.Equal(result.DuplicateIPs, []string{"10.0.0.1"}) {
+		t.Errorf("duplicate IPs = %q, want 10.0.0.1", result.DuplicateIPs)
+	}
+	if len(result.Errors) != 3 {
+		t.Fatalf("errors = %+v, want 3", result.Errors)
+	}
+	for i, host := range []string{"core-1b", "bad", "badport"} {
+		if !strings.HasPrefix(result.Errors[i].Message, "host "+host+": ") {
+			t.Errorf("error %d = %q, want one for %s", i, result.Errors[i].Message, host)
+		}
+	}
+	// Groups of skipped hosts are not created
+	if !slices.Equal(groups, []string{"core"}) {
+		t.Errorf("groups = %q, want core", groups)
+	}
+}
+
+func TestReadAnsibleErrors(t *testing.T) {
+	tests := []struct {
+		path string
+		data string
+	}{
+		{"hosts", "[core\nhost1\n"},
+		{"hosts", "[core:hostvars]\nhost1\n"},
+		{"hosts", "host1 ansible_port\n"},
+		{"hosts", "host1 ansible_host=\"10.0.0.1\n"},
+		{"hosts.yml", "all: [unclosed\n"},
+	}
+	for _, tt := range tests {
+		if _, _, err := ReadAnsible(tt.path, []byte(tt.data)); err == nil {
+			t.Errorf("ReadAnsible(%q) succeeded, want an error", tt.data)
+		}
+	}
+}
+
+func TestAnsibleRoundTrip(t *testing.T) {
+	servers := []cisco.Server{
+		{IP: "10.0.0.1", Hostname: "core 1", Port: 2222, DeviceType: "cisco_ios", Username: "admin", Password: "secret", Groups: []string{"site-seoul", "core"}},
+		{IP: "10.0.0.2", Hostname: "edge", DeviceType: "juniper", Groups: []string{"site-seoul"}},
+		{IP: "10.0.0.3", Hostname: "lab"},
+	}
+
+	for _, path := range []string{"inventory.ini", "inventory.yml"} {
+		data, renamed, err := WriteAnsible(path, servers)
+		if err != nil {
+			t.Fatalf("WriteAnsible(%s): %v", path, err)
+		}
+		if len(renamed) != 0 {
+			t.Errorf("%s: renamed = %v, want none", path, renamed)
+		}
+		if strings.Contains(string(data), "secret") {
+			t.Errorf("%s: password was written:\n%s", path, data)
+		}
+
+		result, _, err := ReadAnsible(path, data)
+		if err != nil {
+			t.Fatalf("ReadAnsible(%s): %v\n%s", path, err, data)
+		}
+		if len(result.Servers) != len(servers) {
+			t.Fatalf("%s: servers = %+v, want %d", path, result.Servers, len(servers))
+		}
+		for _, want := range servers {
+			var got *cisco.Server
+			for i := range result.Servers {
+				if result.Servers[i].IP == want.IP {
+					got = &result.Servers[i]
+				}
+			}
+			if got == nil {
+				t.Errorf("%s: %s missing", path, want.IP)
+				continue
+			}
+			wantGroups := slices.Sorted(slices.Values(want.Groups))
+			gotGroups := slices.Sorted(slices.Values(got.Groups))
+			if got.Port != want.Port || got.DeviceType != want.DeviceType || got.Username != want.Username || !slices.Equal(gotGroups, wantGroups) {
+				t.Errorf("%s: server = %+v, want %+v", path, *got, want)
+			}
+		}
+	}
+}
+
+func TestWriteAnsibleRenamedGroups(t *testing.T) {
+	servers := []cisco.Server{
+		{IP: "10.0.0.1", Hostname: "core", Groups: []string{"Seoul DC", "core:main"}},
+	}
+	data, renamed, err := WriteAnsible("hosts", servers)
+	if err != nil {
+		t.Fatalf("WriteAnsible: %v", err)
+	}
+	want := map[string]string{"Seoul DC": "Seoul_DC", "core:main": "core_main"}
+	if !reflect.DeepEqual(renamed, want) {
+		t.Errorf("renamed = %v, want %v", renamed, want)
+	}
+	if !strings.Contains(string(data), "[Seoul_DC]") || !strings.Contains(string(data), "[core_main]") {
+		t.Errorf("output missing renamed sections:\n%s", data)
+	}
+}
@@ -0,0 +1,96 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"cisco-plink/internal/cisco"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// serverRow returns the export cells of a server in Fields order.
+// Credentials are never exported.
+func serverRow(s cisco.Server) []string {
+	port := ""
+	if s.Port != 0 {
+		port = strconv.Itoa(s.Port)
+	}
+	return []string{
+		s.IP,
+		s.Hostname,
+		port,
+		s.DeviceType,
+		strings.Join(s.Groups, ";"),
+		strings.Join(s.Tags, ";"),
+		s.CredentialProfile,
+		s.Site,
+		s.Description,
+	}
+}
+
+// Export writes servers to a .csv or .xlsx file depending on the extension
+func Export(servers []cisco.Server, path string) error {
+	if strings.EqualFold(filepath.Ext(path), ".xlsx") {
+		return exportExcel(servers, path)
+	}
+	return exportCSV(servers, path)
+}
+
+// exportCSV writes a header row and one row per server
+func exportCSV(servers []cisco.Server, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write(Fields); err != nil {
+		return err
+	}
+	for _, s := range servers {
+		if err := w.Write(serverRow(s)); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// exportExcel writes the inventory to a single "Servers" sheet
+func exportExcel(servers []cisco.Server, path string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheet := "Servers"
+	f.SetSheetName("Sheet1", sheet)
+
+	headerStyle, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Color: []string{"1a73e8"}, Pattern: 1},
+	})
+
+	for i, field := range Fields {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, field)
+		f.SetCellStyle(sheet, cell, cell, headerStyle)
+	}
+
+	for r, s := range servers {
+		for i, value := range serverRow(s) {
+			cell, _ := excelize.CoordinatesToCellName(i+1, r+2)
+			f.SetCellStr(sheet, cell, value)
+		}
+	}
+
+	f.SetColWidth(sheet, "A", "B", 20)
+	f.SetColWidth(sheet, "C", "C", 8)
+	f.SetColWidth(sheet, "D", "H", 18)
+	f.SetColWidth(sheet, "I", "I", 40)
+
+	return f.SaveAs(path)
+}
@@ -0,0 +1,404 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"cisco-plink/internal/cisco"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Inventory fields that can be imported and exported
+const (
+	FieldIP                = "ip"
+	FieldHostname          = "hostname"
+	FieldPort              = "port"
+	FieldDeviceType        = "deviceType"
+	FieldGroups            = "groups"
+	FieldTags              = "tags"
+	FieldCredentialProfile = "credentialProfile"
+	FieldSite              = "site"
+	FieldDescription       = "description"
+)
+
+// Fields lists all importable fields in export column order
+var Fields = []string{
+	FieldIP,
+	FieldHostname,
+	FieldPort,
+	FieldDeviceType,
+	FieldGroups,
+	FieldTags,
+	FieldCredentialProfile,
+	FieldSite,
+	FieldDescription,
+}
+
+// Import modes
+const (
+	ModeMerge   = "merge"   // Update matching servers by IP, add new ones
+	ModeReplace = "replace" // Replace the server list with the imported one
+)
+
+// headerAliases maps normalized column headers to inventory fields
+var headerAliases = map[string]string{
+	"ip":                FieldIP,
+	"ipaddress":         FieldIP,
+	"address":           FieldIP,
+	"host":              FieldIP,
+	"mgmtip":            FieldIP,
+	"managementip":      FieldIP,
+	"primaryip":         FieldIP,
+	"hostname":          FieldHostname,
+	"name":              FieldHostname,
+	"device":            FieldHostname,
+	"devicename":        FieldHostname,
+	"port":              FieldPort,
+	"sshport":           FieldPort,
+	"devicetype":        FieldDeviceType,
+	"type":              FieldDeviceType,
+	"platform":          FieldDeviceType,
+	"os":                FieldDeviceType,
+	"groups":            FieldGroups,
+	"group":             FieldGroups,
+	"tags":              FieldTags,
+	"tag":               FieldTags,
+	"credentialprofile": FieldCredentialProfile,
+	"credential":        FieldCredentialProfile,
+	"credentials":       FieldCredentialProfile,
+	"profile":           FieldCredentialProfile,
+	"site":              FieldSite,
+	"location":          FieldSite,
+	"description":       FieldDescription,
+	"desc":              FieldDescription,
+	"comment":           FieldDescription,
+	"comments":          FieldDescription,
+}
+
+// hostnamePattern matches DNS names accepted in place of an IP address
+var hostnamePattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9-]{0,62})(\.[A-Za-z0-9]([A-Za-z0-9-]{0,62}))*$`)
+
+// RowError describes a row that could not be imported
+type RowError struct {
+	Row     int    `json:"row"` // 1-based row number in the source file, 0 for Ansible hosts
+	Message string `json:"message"`
+}
+
+// ImportResult is the outcome of parsing an import file
+type ImportResult struct {
+	Headers            []string          `json:"headers"`
+	Mapping            map[string]string `json:"mapping"` // column header -> field
+	Servers            []cisco.Server    `json:"servers"`
+	Errors             []RowError        `json:"errors"`
+	DuplicateIPs       []string          `json:"duplicateIPs"`
+	DuplicateHostnames []string          `json:"duplicateHostnames"`
+
+	seenIPs       map[string]bool
+	seenHostnames map[string]bool
+}
+
+func newImportResult() *ImportResult {
+	return &ImportResult{
+		Servers:            make([]cisco.Server, 0),
+		Errors:             make([]RowError, 0),
+		DuplicateIPs:       make([]string, 0),
+		DuplicateHostnames: make([]string, 0),
+		seenIPs:            make(map[string]bool),
+		seenHostnames:      make(map[string]bool),
+	}
+}
+
+// addServer adds a validated server unless its IP was already imported.
+// errPrefix is prepended to the duplicate error message.
+func (r *ImportResult) addServer(row int, errPrefix string, srv cisco.Server) {
+	ipKey := strings.ToLower(srv.IP)
+	if r.seenIPs[ipKey] {
+		r.DuplicateIPs = append(r.DuplicateIPs, srv.IP)
+		r.Errors = append(r.Errors, RowError{Row: row, Message: errPrefix + "duplicate IP " + srv.IP})
+		return
+	}
+	r.seenIPs[ipKey] = true
+
+	hostKey := strings.ToLower(srv.Hostname)
+	if r.seenHostnames[hostKey] {
+		// Duplicate hostnames are reported but still imported
+		r.DuplicateHostnames = append(r.DuplicateHostnames, srv.Hostname)
+	}
+	r.seenHostnames[hostKey] = true
+
+	r.Servers = append(r.Servers, srv)
+}
+
+// ReadTable reads rows from a .csv or .xlsx file (first sheet)
+func ReadTable(path string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xlsx", ".xlsm":
+		f, err := excelize.OpenFile(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, fmt.Errorf("workbook has no sheets")
+		}
+		return f.GetRows(sheets[0])
+
+	default:
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return readCSV(file)
+	}
+}
+
+// readCSV reads all CSV records, tolerating a UTF-8 BOM and ragged rows
+func readCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+	}
+	return rows, nil
+}
+
+// DetectMapping maps header cells to inventory fields by name.
+// Unknown headers are left unmapped.
+func DetectMapping(headers []string) map[string]string {
+	mapping := make(map[string]string)
+	used := make(map[string]bool)
+	for _, h := range headers {
+		field, ok := headerAliases[normalizeHeader(h)]
+		if ok && !used[field] {
+			mapping[h] = field
+			used[field] = true
+		}
+	}
+	return mapping
+}
+
+// normalizeHeader lowercases a header and strips separators
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	return strings.NewReplacer(" ", "", "_", "", "-", "", ".", "").Replace(h)
+}
+
+// ParseRows converts table rows into servers.
+// If mapping is empty, it is detected from the first row. A file whose first
+// row has no recognizable headers is read as legacy "ip,hostname" data.
+func ParseRows(rows [][]string, mapping map[string]string) *ImportResult {
+	result := newImportResult()
+	if len(rows) == 0 {
+		result.Mapping = map[string]string{}
+		return result
+	}
+
+	// Resolve column indexes
+	headerRow := true
+	headers := rows[0]
+	if len(mapping) == 0 {
+		mapping = DetectMapping(headers)
+	}
+	columns := make(map[string]int)
+	for i, h := range headers {
+		if field, ok := mapping[h]; ok && field != "" {
+			columns[field] = i
+		}
+	}
+	if _, ok := columns[FieldIP]; !ok {
+		// Legacy headerless "ip,hostname" file
+		headerRow = false
+		headers = []string{"ip", "hostname"}
+		mapping = map[string]string{"ip": FieldIP, "hostname": FieldHostname}
+		columns = map[string]int{FieldIP: 0, FieldHostname: 1}
+	}
+	result.Headers = headers
+	result.Mapping = mapping
+
+	for i, row := range rows {
+		if headerRow && i == 0 {
+			continue
+		}
+		rowNum := i + 1
+
+		cell := func(field string) string {
+			idx, ok := columns[field]
+			if !ok || idx >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[idx])
+		}
+
+		if isBlankRow(row) {
+			continue
+		}
+
+		srv, err := rowToServer(cell)
+		if err != nil {
+			result.Errors = append(result.Errors, RowError{Row: rowNum, Message: err.Error()})
+			continue
+		}
+
+		result.addServer(rowNum, "", srv)
+	}
+
+	return result
+}
+
+// rowToServer validates a row and converts it to a server
+func rowToServer(cell func(field string) string) (cisco.Server, error) {
+	ip := cell(FieldIP)
+	if err := validateAddress(ip); err != nil {
+		return cisco.Server{}, err
+	}
+
+	srv := cisco.Server{
+		IP:                ip,
+		Hostname:          cell(FieldHostname),
+		DeviceType:        cell(FieldDeviceType),
+		Groups:            splitCell(cell(FieldGroups)),
+		Tags:              splitCell(cell(FieldTags)),
+		CredentialProfile: cell(FieldCredentialProfile),
+		Site:              cell(FieldSite),
+		Description:       cell(FieldDescription),
+	}
+	if srv.Hostname == "" {
+		srv.Hostname = ip
+	}
+
+	if p := cell(FieldPort); p != "" {
+		port, err := strconv.Atoi(p)
+		if err != nil || port < 1 || port > 65535 {
+			return cisco.Server{}, fmt.Errorf("invalid port '%s'", p)
+		}
+		srv.Port = port
+	}
+
+	return srv, nil
+}
+
+// validateAddress checks that ip is an IP address or a host name
+func validateAddress(ip string) error {
+	if ip == "" {
+		return fmt.Errorf("missing IP")
+	}
+	if net.ParseIP(ip) == nil && !hostnamePattern.MatchString(ip) {
+		return fmt.Errorf("invalid IP or host name '%s'", ip)
+	}
+	return nil
+}
+
+// splitCell splits a multi-value cell on ';' or ','
+func splitCell(s string) []string {
+	return SplitList(strings.ReplaceAll(s, ";", ","))
+}
+
+// isBlankRow reports whether every cell of the row is empty
+func isBlankRow(row []string) bool {
+	for _, c := range row {
+		if strings.TrimSpace(c) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply merges or replaces imported servers into the inventory.
+// Passwords are never part of an import, so existing per-server
+// credentials are kept for servers whose IP is still present.
+func (inv *Inventory) Apply(servers []cisco.Server, mode string) (added, updated int, err error) {
+	switch mode {
+	case ModeReplace:
+		replaced := make([]cisco.Server, 0, len(servers))
+		for _, srv := range servers {
+			if existing := inv.FindServer(srv.IP); existing != nil {
+				copyCredentials(&srv, existing)
+				updated++
+			} else {
+				added++
+			}
+			replaced = append(replaced, srv)
+		}
+		inv.Servers = replaced
+
+	case ModeMerge, "":
+		for _, srv := range servers {
+			if existing := inv.FindServer(srv.IP); existing != nil {
+				mergeServer(existing, srv)
+				updated++
+			} else {
+				inv.Servers = append(inv.Servers, srv)
+				added++
+			}
+		}
+
+	default:
+		return 0, 0, fmt.Errorf("unknown import mode: %s", mode)
+	}
+
+	return added, updated, nil
+}
+
+// copyCredentials copies per-server credentials from src to dst, each field
+// on its own: a server logging in through a credential profile may still
+// carry an enable password. Fields that came with the import are kept if
+// src has none.
+func copyCredentials(dst, src *cisco.Server) {
+	if src.Username != "" {
+		dst.Username = src.Username
+	}
+	if src.Password != "" {
+		dst.Password = src.Password
+	}
+	if src.EnablePassword != "" {
+		dst.EnablePassword = src.EnablePassword
+	}
+}
+
+// mergeServer overwrites dst attributes with the non-empty ones from src
+func mergeServer(dst *cisco.Server, src cisco.Server) {
+	if src.Hostname != "" && src.Hostname != src.IP {
+		dst.Hostname = src.Hostname
+	}
+	if src.Port != 0 {
+		dst.Port = src.Port
+	}
+	if src.DeviceType != "" {
+		dst.DeviceType = src.DeviceType
+	}
+	if len(src.Groups) > 0 {
+		dst.Groups = src.Groups
+	}
+	if len(src.Tags) > 0 {
+		dst.Tags = src.Tags
+	}
+	if src.CredentialProfile != "" {
+		dst.CredentialProfile = src.CredentialProfile
+	}
+	if src.Username != "" && dst.Username == "" {
+		dst.Username = src.Username
+	}
+	if src.Site != "" {
+		dst.Site = src.Site
+	}
+	if src.Description != "" {
+		dst.Description = src.Description
+	}
+}
@@ -0,0 +1,286 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/crypto"
+	"cisco-plink/internal/datadir"
+)
+
+const serversFile = "servers.json"
+
+// Group is a named collection of servers. Membership is stored on each
+// server (cisco.Server.Groups) so a server can belong to several groups.
+type Group struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Credential profile for member servers without their own credentials
+	CredentialProfile string `json:"credentialProfile,omitempty"`
+}
+
+// Inventory is the saved server list with its group definitions
+type Inventory struct {
+	Servers []cisco.Server `json:"servers"`
+	Groups  []Group        `json:"groups"`
+}
+
+// Load reads config/servers.json and decrypts per-server credentials.
+// A missing file yields an empty inventory. Older files, including the
+// legacy format (a bare JSON array of servers), are upgraded first.
+func Load() (*Inventory, error) {
+	defer crypto.LockStores()()
+
+	data, err := serversSchema.ReadFile(filepath.Join(datadir.ConfigDir(), serversFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Inventory{Servers: make([]cisco.Server, 0), Groups: make([]Group, 0)}, nil
+		}
+		return nil, err
+	}
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return decode(data, key)
+}
+
+// Save writes the inventory to config/servers.json with per-server
+// credentials encrypted. The in-memory inventory is not modified.
+func Save(inv *Inventory) error {
+	defer crypto.LockStores()()
+
+	key, err := crypto.LoadOrGenerateKey()
+	if err != nil {
+		return err
+	}
+
+	data, err := encode(inv, key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(datadir.ConfigDir(), 0755); err != nil {
+		return err
+	}
+	return serversSchema.WriteFile(filepath.Join(datadir.ConfigDir(), serversFile), data, 0600)
+}
+
+// Reencrypt re-encrypts servers.json for key rotation (see crypto.Reencoder)
+func Reencrypt(oldKey, newKey []byte) (string, []byte, error) {
+	data, err := serversSchema.ReadFile(filepath.Join(datadir.ConfigDir(), serversFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, nil
+		}
+		return "", nil, err
+	}
+
+	inv, err := decode(data, oldKey)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", serversFile, err)
+	}
+	data, err = encode(inv, newKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Join(datadir.ConfigDir(), serversFile), data, nil
+}
+
+// decode parses servers.json and decrypts per-server credentials
+func decode(data, key []byte) (*Inventory, error) {
+	inv := &Inventory{
+		Servers: make([]cisco.Server, 0),
+		Groups:  make([]Group, 0),
+	}
+
+	if strings.TrimSpace(string(data)) != "" {
+		if err := json.Unmarshal(data, inv); err != nil {
+			return nil, err
+		}
+	}
+
+	decrypt := crypto.DecryptFields
+	if crypto.HasLegacySecrets(data) {
+		decrypt = crypto.DecryptLegacyFields
+	}
+
+	// Each field is decrypted on its own: a server logging in through a
+	// credential profile may still carry an enable password
+	for i := range inv.Servers {
+		srv := &inv.Servers[i]
+		var err error
+		if srv.Password, srv.EnablePassword, err = decrypt(srv.Password, srv.EnablePassword, key); err != nil {
+			return nil, fmt.Errorf("server %s: %w", srv.IP, err)
+		}
+	}
+
+	return inv, nil
+}
+
+// encode serializes the inventory with per-server credentials encrypted
+func encode(inv *Inventory, key []byte) ([]byte, error) {
+	saveInv := &Inventory{
+		Servers: make([]cisco.Server, len(inv.Servers)),
+		Groups:  inv.Groups,
+	}
+	if saveInv.Groups == nil {
+		saveInv.Groups = make([]Group, 0)
+	}
+
+	for i, srv := range inv.Servers {
+		saveInv.Servers[i] = srv
+		encPwd, encEnPwd, err := crypto.EncryptFields(srv.Password, srv.EnablePassword, key)
+		if err != nil {
+			return nil, err
+		}
+		saveInv.Servers[i].Password = encPwd
+		saveInv.Servers[i].EnablePassword = encEnPwd
+	}
+
+	return serversSchema.Marshal(saveInv)
+}
+
+// FindServer returns the server with the given IP, or nil
+func (inv *Inventory) FindServer(ip string) *cisco.Server {
+	for i := range inv.Servers {
+		if inv.Servers[i].IP == ip {
+			return &inv.Servers[i]
+		}
+	}
+	return nil
+}
+
+// FindGroup returns the group with the given name (case-insensitive), or nil
+func (inv *Inventory) FindGroup(name string) *Group {
+	for i := range inv.Groups {
+		if strings.EqualFold(inv.Groups[i].Name, name) {
+			return &inv.Groups[i]
+		}
+	}
+	return nil
+}
+
+// SetGroup creates or updates a group definition
+func (inv *Inventory) SetGroup(group Group) {
+	if g := inv.FindGroup(group.Name); g != nil {
+		*g = group
+		return
+	}
+	inv.Groups = append(inv.Groups, group)
+}
+
+// DeleteGroup removes a group definition and its membership from all servers
+func (inv *Inventory) DeleteGroup(name string) {
+	groups := inv.Groups[:0]
+	for _, g := range inv.Groups {
+		if !strings.EqualFold(g.Name, name) {
+			groups = append(groups, g)
+		}
+	}
+	inv.Groups = groups
+
+	for i := range inv.Servers {
+		inv.Servers[i].Groups = removeFold(inv.Servers[i].Groups, name)
+	}
+}
+
+// SetMembers makes the named group contain exactly the servers with the given IPs
+func (inv *Inventory) SetMembers(group string, ips []string) {
+	for i := range inv.Servers {
+		srv := &inv.Servers[i]
+		srv.Groups = removeFold(srv.Groups, group)
+		for _, ip := range ips {
+			if srv.IP == ip {
+				srv.Groups = append(srv.Groups, group)
+				break
+			}
+		}
+	}
+}
+
+// GroupNames returns all group names, including groups that are only
+// referenced by servers and have no explicit definition
+func (inv *Inventory) GroupNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		key := strings.ToLower(name)
+		if name == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+
+	for _, g := range inv.Groups {
+		add(g.Name)
+	}
+	for _, s := range inv.Servers {
+		for _, g := range s.Groups {
+			add(g)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	return names
+}
+
+// Members returns the servers belonging to the named group
+func (inv *Inventory) Members(group string) []cisco.Server {
+	var members []cisco.Server
+	for _, s := range inv.Servers {
+		if containsFold(s.Groups, group) {
+			members = append(members, s)
+		}
+	}
+	return members
+}
+
+// SplitList parses a comma-separated list (as sent by the UI) into trimmed,
+// de-duplicated, non-empty values
+func SplitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" && !containsFold(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// JoinList is the inverse of SplitList
+func JoinList(values []string) string {
+	return strings.Join(values, ",")
+}
+
+// containsFold reports whether list contains s (case-insensitive)
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeFold returns list without s (case-insensitive)
+func removeFold(list []string, s string) []string {
+	var out []string
+	for _, v := range list {
+		if !strings.EqualFold(v, s) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,78 @@
+package inventory
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/crypto"
+)
+
+func TestEncodeDecodeCredentials(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	inv := &Inventory{Servers: []cisco.Server{
+		{IP: "10.0.0.1", Username: "admin", Password: "login", EnablePassword: "enable"},
+		{IP: "10.0.0.2", CredentialProfile: "core", EnablePassword: "inline-enable"},
+	}}
+
+	data, err := encode(inv, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored Inventory
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range stored.Servers {
+		if s.EnablePassword == "" || s.EnablePassword == inv.Servers[i].EnablePassword {
+			t.Errorf("server %s: stored enable password %q, want it encrypted", s.IP, s.EnablePassword)
+		}
+	}
+
+	got, err := decode(data, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range got.Servers {
+		want := inv.Servers[i]
+		if s.Password != want.Password || s.EnablePassword != want.EnablePassword {
+			t.Errorf("server %s: got %q/%q, want %q/%q", s.IP, s.Password, s.EnablePassword, want.Password, want.EnablePassword)
+		}
+	}
+}
+
+func TestDecodeLegacyPlaintext(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	// A plaintext password that is valid base64 longer than nonce + tag
+	const password = "QWxhZGRpbjpvcGVuIHNlc2FtZSBwbGVhc2UsIGFuZCBxdWlja2x5IG5vdw=="
+	legacy := `{"schemaVersion":1,"servers":[{"ip":"10.0.0.1","username":"admin","password":"` + password + `"}],"groups":[]}`
+
+	path := filepath.Join(t.TempDir(), serversFile)
+	if err := os.WriteFile(path, []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+	data, err := serversSchema.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inv, err := decode(data, key)
+	if err != nil {
+		t.Fatalf("decode of an upgraded file: %v", err)
+	}
+	if got := inv.Servers[0].Password; got != password {
+		t.Errorf("password = %q, want the plaintext unchanged", got)
+	}
+
+	// Once saved, every secret is encrypted and a value that does not
+	// decrypt is an error
+	if data, err = encode(inv, key); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := decode(data, bytes.Repeat([]byte{8}, 32)); !errors.Is(err, crypto.ErrDecrypt) {
+		t.Errorf("decode with another key: err = %v, want ErrDecrypt", err)
+	}
+}
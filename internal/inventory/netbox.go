@@ -0,0 +1,293 @@
+package inventory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"cisco-plink/internal/cisco"
+)
+
+// SourceNetBox marks servers synchronized from a NetBox-compatible API
+const SourceNetBox = "netbox"
+
+// netBoxPageSize is the number of devices requested per page
+const netBoxPageSize = 500
+
+// NetBoxSource pulls devices from a NetBox-compatible REST API
+// (GET /api/dcim/devices/). Filters are passed through as query parameters.
+type NetBoxSource struct {
+	URL      string
+	Token    string
+	Sites    []string // site slugs
+	Roles    []string // device role slugs
+	Tags     []string // tag slugs
+	Statuses []string // e.g. "active"
+	Client   *http.Client
+}
+
+// netBoxRef is a nested object reference (site, role, platform, tag)
+type netBoxRef struct {
+	Slug  string `json:"slug"`
+	Name  string `json:"name"`
+	Value string `json:"value"` // status
+}
+
+// netBoxIP is a nested IP address reference
+type netBoxIP struct {
+	Address string `json:"address"` // "10.0.0.1/24"
+}
+
+// netBoxDevice is the subset of a device object that is mapped to a server
+type netBoxDevice struct {
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	PrimaryIP   *netBoxIP   `json:"primary_ip"`
+	PrimaryIP4  *netBoxIP   `json:"primary_ip4"`
+	Platform    *netBoxRef  `json:"platform"`
+	Site        *netBoxRef  `json:"site"`
+	Role        *netBoxRef  `json:"role"`        // NetBox 4.x
+	DeviceRole  *netBoxRef  `json:"device_role"` // NetBox 3.x
+	Status      *netBoxRef  `json:"status"`
+	Tags        []netBoxRef `json:"tags"`
+	Description string      `json:"description"`
+}
+
+// netBoxPage is one page of a paginated list response
+type netBoxPage struct {
+	Count   int            `json:"count"`
+	Next    string         `json:"next"`
+	Results []netBoxDevice `json:"results"`
+}
+
+// Fetch returns all matching devices as servers. Devices without a primary
+// IP cannot be reached and are returned as skipped device names.
+func (n *NetBoxSource) Fetch() (servers []cisco.Server, skipped []string, err error) {
+	if strings.TrimSpace(n.URL) == "" {
+		return nil, nil, fmt.Errorf("NetBox URL is required")
+	}
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	next, err := n.firstPageURL()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool)
+	for next != "" {
+		if seen[next] {
+			return nil, nil, fmt.Errorf("pagination loop at %s", next)
+		}
+		seen[next] = true
+
+		page, err := n.getPage(client, next)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, d := range page.Results {
+			srv, ok := d.toServer()
+			if !ok {
+				skipped = append(skipped, d.Name)
+				continue
+			}
+			servers = append(servers, srv)
+		}
+		if next, err = n.nextPageURL(next, page.Next); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return servers, skipped, nil
+}
+
+// nextPageURL resolves the next page link of the page at current. The token
+// is sent with every request, so only links to the configured scheme and
+// host are followed.
+func (n *NetBoxSource) nextPageURL(current, next string) (string, error) {
+	if next == "" {
+		return "", nil
+	}
+	base, err := url.Parse(current)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("invalid next page link %q: %v", next, err)
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != base.Scheme || !strings.EqualFold(u.Host, base.Host) {
+		return "", fmt.Errorf("next page link %s leaves %s://%s", u.Redacted(), base.Scheme, base.Host)
+	}
+	return u.String(), nil
+}
+
+// firstPageURL builds the device list URL with filters
+func (n *NetBoxSource) firstPageURL() (string, error) {
+	base, err := url.Parse(strings.TrimRight(strings.TrimSpace(n.URL), "/") + "/api/dcim/devices/")
+	if err != nil {
+		return "", fmt.Errorf("invalid NetBox URL: %v", err)
+	}
+	if base.Scheme != "http" && base.Scheme != "https" {
+		return "", fmt.Errorf("invalid NetBox URL: %s", n.URL)
+	}
+
+	q := url.Values{}
+	q.Set("limit", strconv.Itoa(netBoxPageSize))
+	for _, v := range n.Sites {
+		q.Add("site", v)
+	}
+	for _, v := range n.Roles {
+		q.Add("role", v)
+	}
+	for _, v := range n.Tags {
+		q.Add("tag", v)
+	}
+	for _, v := range n.Statuses {
+		q.Add("status", v)
+	}
+	base.RawQuery = q.Encode()
+	return base.String(), nil
+}
+
+// getPage fetches and decodes a single page
+func (n *NetBoxSource) getPage(client *http.Client, pageURL string) (*netBoxPage, error) {
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Token "+n.Token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("NetBox request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("NetBox returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var page netBoxPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to parse NetBox response: %v", err)
+	}
+	return &page, nil
+}
+
+// toServer maps a device to a server. Site and role are also added as
+// "site:<slug>" and "role:<slug>" tags so they can be used in target expressions.
+func (d netBoxDevice) toServer() (cisco.Server, bool) {
+	ip := d.PrimaryIP
+	if ip == nil || ip.Address == "" {
+		ip = d.PrimaryIP4
+	}
+	if ip == nil || ip.Address == "" {
+		return cisco.Server{}, false
+	}
+
+	addr := ip.Address
+	if i := strings.Index(addr, "/"); i >= 0 {
+		addr = addr[:i]
+	}
+
+	srv := cisco.Server{
+		IP:          addr,
+		Hostname:    d.Name,
+		Description: d.Description,
+		Source:      SourceNetBox,
+	}
+	if srv.Hostname == "" {
+		srv.Hostname = addr
+	}
+	if d.Platform != nil {
+		srv.DeviceType = d.Platform.Slug
+	}
+
+	var tags []string
+	if d.Site != nil && d.Site.Slug != "" {
+		srv.Site = d.Site.Slug
+		tags = append(tags, "site:"+d.Site.Slug)
+	}
+	role := d.Role
+	if role == nil {
+		role = d.DeviceRole
+	}
+	if role != nil && role.Slug != "" {
+		tags = append(tags, "role:"+role.Slug)
+	}
+	for _, t := range d.Tags {
+		if t.Slug != "" {
+			tags = append(tags, t.Slug)
+		}
+	}
+	srv.Tags = SplitList(strings.Join(tags, ","))
+
+	return srv, true
+}
+
+// SyncSource replaces the servers that came from the given source with a
+// freshly fetched set. Servers added by hand or by another source are
+// untouched, also when a fetched device has the same IP. Local settings
+// (credentials, groups, credential profile, port) are kept for devices that
+// are still present.
+func (inv *Inventory) SyncSource(source string, servers []cisco.Server) (added, updated, removed int) {
+	fetched := make(map[string]cisco.Server, len(servers))
+	order := make([]string, 0, len(servers))
+	for _, s := range servers {
+		if _, dup := fetched[s.IP]; !dup {
+			order = append(order, s.IP)
+		}
+		fetched[s.IP] = s
+	}
+
+	result := make([]cisco.Server, 0, len(inv.Servers)+len(servers))
+	for _, existing := range inv.Servers {
+		if existing.Source != source {
+			// Keep it as it is, and don't add the device a second time
+			delete(fetched, existing.IP)
+			result = append(result, existing)
+			continue
+		}
+		srv, ok := fetched[existing.IP]
+		if !ok {
+			removed++
+			continue
+		}
+
+		// Refresh source-owned attributes, keep local ones
+		copyCredentials(&srv, &existing)
+		srv.Groups = existing.Groups
+		srv.CredentialProfile = existing.CredentialProfile
+		if existing.Port != 0 {
+			srv.Port = existing.Port
+		}
+		result = append(result, srv)
+		delete(fetched, existing.IP)
+		updated++
+	}
+
+	for _, ip := range order {
+		if srv, ok := fetched[ip]; ok {
+			result = append(result, srv)
+			added++
+		}
+	}
+
+	inv.Servers = result
+	return added, updated, removed
+}
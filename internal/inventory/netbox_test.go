@@ -0,0 +1,158 @@
+package inventory
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"cisco-plink/internal/cisco"
+)
+
+func TestNetBoxFetch(t *testing.T) {
+	pages := map[string]string{
+		"": `{"count":3,"next":"/api/dcim/devices/?limit=500&offset=500","results":[
+			{"id":1,"name":"core-1","primary_ip":{"address":"10.0.0.1/24"},"platform":{"slug":"cisco_ios"},
+			 "site":{"slug":"seoul"},"role":{"slug":"core"},"tags":[{"slug":"pci"}],"description":"Core switch"},
+			{"id":2,"name":"no-ip","primary_ip":null}
+		]}`,
+		"500": `{"count":3,"next":null,"results":[
+			{"id":3,"name":"","primary_ip4":{"address":"10.0.0.2/32"},"device_role":{"slug":"edge"}}
+		]}`,
+	}
+	wantQuery := url.Values{
+		"limit":  {"500"},
+		"site":   {"seoul", "busan"},
+		"role":   {"core"},
+		"tag":    {"pci"},
+		"status": {"active"},
+	}
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/api/dcim/devices/" {
+			t.Errorf("path = %s, want /api/dcim/devices/", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Token nb-token" {
+			t.Errorf("Authorization = %q, want Token nb-token", got)
+		}
+		q := r.URL.Query()
+		offset := q.Get("offset")
+		q.Del("offset")
+		if offset == "" && !reflect.DeepEqual(q, wantQuery) {
+			t.Errorf("query = %v, want %v", q, wantQuery)
+		}
+		page, ok := pages[offset]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+
+	n := &NetBoxSource{
+		URL:      srv.URL + "/",
+		Token:    "nb-token",
+		Sites:    []string{"seoul", "busan"},
+		Roles:    []string{"core"},
+		Tags:     []string{"pci"},
+		Statuses: []string{"active"},
+	}
+	servers, skipped, err := n.Fetch()
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	want := []cisco.Server{
+		{IP: "10.0.0.1", Hostname: "core-1", DeviceType: "cisco_ios", Site: "seoul", Description: "Core switch",
+			Tags: []string{"site:seoul", "role:core", "pci"}, Source: SourceNetBox},
+		{IP: "10.0.0.2", Hostname: "10.0.0.2", Tags: []string{"role:edge"}, Source: SourceNetBox},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("servers = %+v, want %+v", servers, want)
+	}
+	if !slices.Equal(skipped, []string{"no-ip"}) {
+		t.Errorf("skipped = %q, want no-ip", skipped)
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2 pages", requests)
+	}
+}
+
+func TestNetBoxFetchError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"detail":"Invalid token"}`))
+	}))
+	defer srv.Close()
+
+	_, _, err := (&NetBoxSource{URL: srv.URL, Token: "wrong"}).Fetch()
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Errorf("err = %v, want a status 403 error", err)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	const current = "https://netbox.example.com/api/dcim/devices/?limit=500"
+	tests := []struct {
+		next    string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"https://netbox.example.com/api/dcim/devices/?limit=500&offset=500", "https://netbox.example.com/api/dcim/devices/?limit=500&offset=500", false},
+		{"https://NETBOX.example.com/api/dcim/devices/?offset=500", "https://NETBOX.example.com/api/dcim/devices/?offset=500", false},
+		{"/api/dcim/devices/?offset=500", "https://netbox.example.com/api/dcim/devices/?offset=500", false},
+		{"http://netbox.example.com/api/dcim/devices/?offset=500", "", true},
+		{"https://attacker.example.net/collect", "", true},
+		{"https://netbox.example.com:8443/api/dcim/devices/", "", true},
+		{"//attacker.example.net/collect", "", true},
+	}
+	n := &NetBoxSource{}
+	for _, tt := range tests {
+		got, err := n.nextPageURL(current, tt.next)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("nextPageURL(%q) = %q, want an error", tt.next, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("nextPageURL(%q) = %q, %v, want %q", tt.next, got, err, tt.want)
+		}
+	}
+}
+
+func TestSyncSourceKeepsOtherServers(t *testing.T) {
+	inv := &Inventory{Servers: []cisco.Server{
+		{IP: "10.0.0.1", Hostname: "manual", Username: "admin"},
+		{IP: "10.0.0.2", Hostname: "old", Source: SourceNetBox, Groups: []string{"core"}, CredentialProfile: "core", EnablePassword: "enable"},
+		{IP: "10.0.0.3", Hostname: "gone", Source: SourceNetBox},
+	}}
+	added, updated, removed := inv.SyncSource(SourceNetBox, []cisco.Server{
+		{IP: "10.0.0.1", Hostname: "from-netbox", Source: SourceNetBox},
+		{IP: "10.0.0.2", Hostname: "renamed", Source: SourceNetBox},
+		{IP: "10.0.0.4", Hostname: "new", Source: SourceNetBox},
+	})
+
+	if added != 1 || updated != 1 || removed != 1 {
+		t.Errorf("added, updated, removed = %d, %d, %d, want 1, 1, 1", added, updated, removed)
+	}
+	if len(inv.Servers) != 3 {
+		t.Fatalf("servers = %+v, want 3", inv.Servers)
+	}
+	if s := inv.FindServer("10.0.0.1"); s.Hostname != "manual" || s.Source != "" || s.Username != "admin" {
+		t.Errorf("hand-added server = %+v, want it untouched", s)
+	}
+	if s := inv.FindServer("10.0.0.2"); s.Hostname != "renamed" || len(s.Groups) != 1 || s.CredentialProfile != "core" || s.EnablePassword != "enable" {
+		t.Errorf("synced server = %+v, want it renamed with its groups, profile and enable password kept", s)
+	}
+	if inv.FindServer("10.0.0.3") != nil || inv.FindServer("10.0.0.4") == nil {
+		t.Errorf("servers = %+v, want 10.0.0.3 removed and 10.0.0.4 added", inv.Servers)
+	}
+}
@@ -0,0 +1,33 @@
+package inventory
+
+import (
+	"fmt"
+
+	"cisco-plink/internal/crypto"
+	"cisco-plink/internal/schema"
+)
+
+// serversSchema describes servers.json. Version 0 files may be the legacy
+// format, a bare JSON array of servers. Files up to version 1 may hold
+// passwords saved in plaintext before they were encrypted.
+var serversSchema = &schema.Schema{
+	Name:    serversFile,
+	Version: 2,
+	Migrations: []schema.Migration{
+		{From: 0, Description: "wrap the legacy server array", Apply: wrapServerArray},
+		{From: 1, Description: "mark possibly plaintext secrets", Apply: crypto.MarkLegacySecrets},
+	},
+}
+
+// wrapServerArray converts a bare server array to {"servers": [...], "groups": []}
+func wrapServerArray(doc interface{}) (interface{}, error) {
+	switch d := doc.(type) {
+	case nil:
+		return map[string]interface{}{"servers": []interface{}{}, "groups": []interface{}{}}, nil
+	case []interface{}:
+		return map[string]interface{}{"servers": d, "groups": []interface{}{}}, nil
+	case map[string]interface{}:
+		return d, nil
+	}
+	return nil, fmt.Errorf("unexpected %T at top level", doc)
+}
@@ -0,0 +1,99 @@
+package inventory
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWrapServerArray(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    string
+		wantErr bool
+	}{
+		{"legacy array", `[{"ip":"10.0.0.1"}]`, `{"servers":[{"ip":"10.0.0.1"}],"groups":[]}`, false},
+		{"empty array", `[]`, `{"servers":[],"groups":[]}`, false},
+		{"null", `null`, `{"servers":[],"groups":[]}`, false},
+		{"unversioned object", `{"servers":[{"ip":"10.0.0.1"}],"groups":[{"name":"core"}]}`, `{"servers":[{"ip":"10.0.0.1"}],"groups":[{"name":"core"}]}`, false},
+		{"string", `"servers"`, "", true},
+		{"number", `1`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := wrapServerArray(doc)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %v, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want interface{}
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestServersSchemaUpgrade(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		servers int
+		wantErr string
+	}{
+		{"legacy array", `[{"ip":"10.0.0.1"},{"ip":"10.0.0.2"}]`, 2, ""},
+		{"unversioned object", `{"servers":[{"ip":"10.0.0.1"}],"groups":[]}`, 1, ""},
+		{"version 1", `{"schemaVersion":1,"servers":[{"ip":"10.0.0.1"}],"groups":[]}`, 1, ""},
+		{"current", `{"schemaVersion":2,"servers":[{"ip":"10.0.0.1"}],"groups":[]}`, 1, ""},
+		{"newer", `{"schemaVersion":3,"servers":[]}`, 0, "newer version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), serversFile)
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := serversSchema.ReadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got struct {
+				Version int               `json:"schemaVersion"`
+				Servers []json.RawMessage `json:"servers"`
+				Groups  []json.RawMessage `json:"groups"`
+			}
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Version != serversSchema.Version || len(got.Servers) != tt.servers || got.Groups == nil {
+				t.Errorf("got %s, want version %d with %d servers and groups", data, serversSchema.Version, tt.servers)
+			}
+		})
+	}
+}
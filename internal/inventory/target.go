@@ -0,0 +1,281 @@
+package inventory
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+
+	"cisco-plink/internal/cisco"
+)
+
+// Target selects servers from the inventory at run time.
+// A server is selected if it belongs to any of Groups, carries any of Tags,
+// or matches Expression. An empty target selects nothing.
+//
+// Expression syntax:
+//
+//	site:seoul AND role:core
+//	(group:core OR group:dist) AND NOT status:spare
+//	site:* AND NOT role:lab
+//
+// A term matches a server tag (case-insensitive, glob patterns allowed).
+// The "group:" prefix matches group membership instead of a tag; "site:"
+// and "type:" terms also match the server's site and device type.
+// Operators AND, OR, NOT are case-insensitive; AND binds tighter than OR.
+type Target struct {
+	Groups     []string `json:"groups,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Expression string   `json:"expression,omitempty"`
+}
+
+// IsEmpty reports whether the target selects nothing
+func (t *Target) IsEmpty() bool {
+	return t == nil || (len(t.Groups) == 0 && len(t.Tags) == 0 && strings.TrimSpace(t.Expression) == "")
+}
+
+// Validate checks that the expression (if any) can be parsed
+func (t *Target) Validate() error {
+	if t == nil || strings.TrimSpace(t.Expression) == "" {
+		return nil
+	}
+	_, err := ParseExpression(t.Expression)
+	return err
+}
+
+// String returns a short human-readable description of the target
+func (t *Target) String() string {
+	if t.IsEmpty() {
+		return ""
+	}
+	var parts []string
+	for _, g := range t.Groups {
+		parts = append(parts, "group:"+g)
+	}
+	parts = append(parts, t.Tags...)
+	if e := strings.TrimSpace(t.Expression); e != "" {
+		parts = append(parts, "("+e+")")
+	}
+	return strings.Join(parts, " OR ")
+}
+
+// Resolve returns the servers selected by the target, in inventory order
+func (t *Target) Resolve(servers []cisco.Server) ([]cisco.Server, error) {
+	if t.IsEmpty() {
+		return nil, nil
+	}
+
+	var expr Expr
+	if strings.TrimSpace(t.Expression) != "" {
+		var err error
+		expr, err = ParseExpression(t.Expression)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var selected []cisco.Server
+	for _, s := range servers {
+		if t.matches(s, expr) {
+			selected = append(selected, s)
+		}
+	}
+	return selected, nil
+}
+
+// matches reports whether a single server is selected by the target
+func (t *Target) matches(s cisco.Server, expr Expr) bool {
+	for _, g := range t.Groups {
+		if containsFold(s.Groups, g) {
+			return true
+		}
+	}
+	for _, tag := range t.Tags {
+		if matchTerm(s, tag) {
+			return true
+		}
+	}
+	return expr != nil && expr.Match(s)
+}
+
+// Expr is a parsed tag expression
+type Expr interface {
+	Match(s cisco.Server) bool
+}
+
+type termExpr string
+
+type notExpr struct{ x Expr }
+
+type andExpr struct{ l, r Expr }
+
+type orExpr struct{ l, r Expr }
+
+func (e termExpr) Match(s cisco.Server) bool { return matchTerm(s, string(e)) }
+func (e notExpr) Match(s cisco.Server) bool  { return !e.x.Match(s) }
+func (e andExpr) Match(s cisco.Server) bool  { return e.l.Match(s) && e.r.Match(s) }
+func (e orExpr) Match(s cisco.Server) bool   { return e.l.Match(s) || e.r.Match(s) }
+
+// matchTerm matches a single term against a server's tags or groups.
+// "site:<name>" and "type:<device type>" also match the Site and DeviceType
+// attributes, so servers imported from a file can be targeted like those
+// synced from NetBox.
+func matchTerm(s cisco.Server, term string) bool {
+	if hasPrefixFold(term, "group:") {
+		return matchValues(s.Groups, term[6:])
+	}
+
+	values := slices.Clip(s.Tags)
+	if hasPrefixFold(term, "site:") && s.Site != "" {
+		values = append(values, "site:"+s.Site)
+	}
+	if hasPrefixFold(term, "type:") && s.DeviceType != "" {
+		values = append(values, "type:"+s.DeviceType)
+	}
+	return matchValues(values, term)
+}
+
+// hasPrefixFold reports whether term starts with prefix (case-insensitive)
+// followed by a value
+func hasPrefixFold(term, prefix string) bool {
+	return len(term) > len(prefix) && strings.EqualFold(term[:len(prefix)], prefix)
+}
+
+// matchValues matches a term, which may be a wildcard pattern, against values
+func matchValues(values []string, term string) bool {
+	pattern := strings.ToLower(term)
+	for _, v := range values {
+		v = strings.ToLower(v)
+		if v == pattern {
+			return true
+		}
+		if ok, err := path.Match(pattern, v); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseExpression parses a tag expression
+func ParseExpression(input string) (Expr, error) {
+	p := &exprParser{tokens: tokenize(input)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("empty expression")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected '%s' in expression", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// tokenize splits an expression into parentheses and words
+func tokenize(input string) []string {
+	var tokens []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range input {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case unicode.IsSpace(r):
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type exprParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *exprParser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "OR") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for strings.EqualFold(p.peek(), "AND") {
+		p.pos++
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{left, right}
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseNot() (Expr, error) {
+	if strings.EqualFold(p.peek(), "NOT") {
+		p.pos++
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	switch {
+	case tok == "":
+		return nil, fmt.Errorf("unexpected end of expression")
+	case tok == "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ')' in expression")
+		}
+		p.pos++
+		return expr, nil
+	case tok == ")" || strings.EqualFold(tok, "AND") || strings.EqualFold(tok, "OR"):
+		return nil, fmt.Errorf("unexpected '%s' in expression", tok)
+	}
+	if _, err := path.Match(strings.ToLower(tok), ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern '%s': %v", tok, err)
+	}
+	p.pos++
+	return termExpr(tok), nil
+}
@@ -0,0 +1,44 @@
+package inventory
+
+import (
+	"slices"
+	"testing"
+
+	"cisco-plink/internal/cisco"
+)
+
+func TestTargetResolve(t *testing.T) {
+	servers := []cisco.Server{
+		{IP: "10.0.0.1", Site: "seoul", DeviceType: "cisco_ios", Groups: []string{"core"}},
+		{IP: "10.0.0.2", Tags: []string{"site:busan", "role:core"}},
+		{IP: "10.0.0.3", Site: "Busan", DeviceType: "cisco_nxos", Tags: []string{"role:lab"}},
+	}
+	tests := []struct {
+		target Target
+		want   []string
+	}{
+		{Target{Expression: "site:seoul"}, []string{"10.0.0.1"}},
+		{Target{Expression: "site:busan"}, []string{"10.0.0.2", "10.0.0.3"}},
+		{Target{Expression: "site:* AND NOT role:lab"}, []string{"10.0.0.1", "10.0.0.2"}},
+		{Target{Expression: "type:cisco_*"}, []string{"10.0.0.1", "10.0.0.3"}},
+		{Target{Expression: "group:core OR role:core"}, []string{"10.0.0.1", "10.0.0.2"}},
+		{Target{Tags: []string{"site:seoul"}}, []string{"10.0.0.1"}},
+		{Target{Groups: []string{"CORE"}}, []string{"10.0.0.1"}},
+		{Target{Expression: "seoul"}, nil},
+		{Target{}, nil},
+	}
+	for _, tt := range tests {
+		got, err := tt.target.Resolve(servers)
+		if err != nil {
+			t.Errorf("%s: %v", tt.target.String(), err)
+			continue
+		}
+		var ips []string
+		for _, s := range got {
+			ips = append(ips, s.IP)
+		}
+		if !slices.Equal(ips, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.target.String(), ips, tt.want)
+		}
+	}
+}
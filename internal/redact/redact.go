@@ -0,0 +1,144 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Mask replaces every redacted secret
+const Mask = "<removed>"
+
+// Rule is a named regular expression. If the pattern has a capture group,
+// only the first group is masked (so "snmp-server community <removed>" keeps
+// its context); otherwise the whole match is masked.
+type Rule struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// compiledRule is a Rule ready to be applied
+type compiledRule struct {
+	Rule
+	re *regexp.Regexp
+}
+
+// Builtin are the default rules for common Cisco IOS/NX-OS/ASA, Arista and
+// Juniper configuration secrets
+var Builtin = []Rule{
+	{
+		Name:    "Passwords and secrets",
+		Pattern: `(?mi)(?:^|\s)(?:password|secret|passwd)(?: (?:level \d+(?: \d+)?|\d+|sha512|sha256|md5))? (\S+)`,
+	},
+	{
+		Name:    "SNMP communities",
+		Pattern: `(?mi)^\s*snmp-server community (\S+)`,
+	},
+	{
+		Name:    "SNMPv3 auth keys",
+		Pattern: `(?mi)^\s*snmp-server user .*?\bauth (?:md5|sha\S*) (\S+)`,
+	},
+	{
+		Name:    "SNMPv3 priv keys",
+		Pattern: `(?mi)^\s*snmp-server user .*?\bpriv(?: (?:des|3des|aes(?:[- ]?\d+)?))? (\S+)`,
+	},
+	{
+		Name:    "TACACS+/RADIUS keys",
+		Pattern: `(?mi)^\s*(?:tacacs-server|radius-server|server-private|aaa-server)\b.*?\bkey(?: \d+)? (\S+)`,
+	},
+	{
+		// "key 7 <hash>" under tacacs/radius server, not the "key 1" of a key chain
+		Name:    "Server keys",
+		Pattern: `(?mi)^\s*(?:server-key(?: [0-9])?|key [0-9]) (\S+)\s*$`,
+	},
+	{
+		Name:    "Key strings",
+		Pattern: `(?mi)^\s*key-string(?: \d+)? (\S+)`,
+	},
+	{
+		Name:    "IKE pre-shared keys",
+		Pattern: `(?mi)(?:crypto isakmp key|pre-shared-key)(?: (?:local|remote|address))?(?: \d+)? (\S+)`,
+	},
+	{
+		Name:    "NTP authentication keys",
+		Pattern: `(?mi)^\s*ntp authentication-key \d+ md5 (\S+)`,
+	},
+	{
+		Name:    "Routing protocol authentication keys",
+		Pattern: `(?mi)(?:message-digest-key \d+ md5|ip ospf authentication-key|isis password)(?: [0-9])? (\S+)`,
+	},
+	{
+		Name:    "Juniper quoted secrets",
+		Pattern: `(?mi)\b(?:encrypted-password|secret|authentication-key|ascii-text|simple-password|hash) "([^"]+)"`,
+	},
+	{
+		Name:    "Juniper $9$ secrets",
+		Pattern: `(\$9\$[^\s";]+)`,
+	},
+	{
+		Name:    "Juniper SNMP communities",
+		Pattern: `(?mi)^\s*(?:set snmp )?community "?([^\s";{]+)`,
+	},
+}
+
+// Redactor masks secrets in text
+type Redactor struct {
+	rules []compiledRule
+}
+
+// New compiles the rules. An invalid pattern returns an error naming the rule.
+func New(rules []Rule) (*Redactor, error) {
+	r := &Redactor{rules: make([]compiledRule, 0, len(rules))}
+	for i, rule := range rules {
+		if strings.TrimSpace(rule.Pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			name := rule.Name
+			if name == "" {
+				name = fmt.Sprintf("rule %d", i+1)
+			}
+			return nil, fmt.Errorf("%s: invalid pattern: %v", name, err)
+		}
+		r.rules = append(r.rules, compiledRule{Rule: rule, re: re})
+	}
+	return r, nil
+}
+
+// Redact returns text with every match masked. A nil Redactor returns text unchanged.
+func (r *Redactor) Redact(text string) string {
+	if r == nil {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = rule.apply(text)
+	}
+	return text
+}
+
+// apply masks the first capture group of every match, or the whole match
+// if the pattern has no groups
+func (c compiledRule) apply(text string) string {
+	matches := c.re.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if len(m) >= 4 && m[2] >= 0 {
+			start, end = m[2], m[3]
+		}
+		if start < last || start == end {
+			continue
+		}
+		b.WriteString(text[last:start])
+		b.WriteString(Mask)
+		last = end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
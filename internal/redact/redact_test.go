@@ -0,0 +1,66 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuiltinKeys(t *testing.T) {
+	r, err := New(Builtin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		// Key chains: the key ID stays, the key string is masked
+		{"key chain OSPF", "key chain OSPF"},
+		{" key 1", " key 1"},
+		{" key 12", " key 12"},
+		{"  key-string 7 0822455D0A16", "  key-string 7 " + Mask},
+		{"  key-string secret", "  key-string " + Mask},
+
+		// Keys of tacacs/radius server sub-modes
+		{" key 7 0822455D0A16", " key 7 " + Mask},
+		{" key 0 plainsecret", " key 0 " + Mask},
+		{" server-key 7 0822455D0A16", " server-key 7 " + Mask},
+		{" server-key plainsecret", " server-key " + Mask},
+		{"tacacs-server host 10.0.0.1 key 7 0822455D0A16", "tacacs-server host 10.0.0.1 key 7 " + Mask},
+
+		// Other lines starting with "key" are left alone
+		{" key config-key password-encrypt", " key config-key password-encrypt"},
+		{"crypto key generate rsa modulus 2048", "crypto key generate rsa modulus 2048"},
+	}
+	for _, tt := range tests {
+		if got := r.Redact(tt.line); got != tt.want {
+			t.Errorf("Redact(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestKeyChainConfig(t *testing.T) {
+	r, err := New(Builtin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := strings.Join([]string{
+		"key chain EIGRP",
+		" key 1",
+		"  key-string 7 0822455D0A16",
+		" key 2",
+		"  key-string 7 13061E010803",
+	}, "\n")
+	got := r.Redact(config)
+
+	for _, keep := range []string{" key 1\n", " key 2\n"} {
+		if !strings.Contains(got, keep) {
+			t.Errorf("%q was masked:\n%s", keep, got)
+		}
+	}
+	if strings.Contains(got, "0822455D0A16") || strings.Contains(got, "13061E010803") {
+		t.Errorf("key strings were not masked:\n%s", got)
+	}
+}
@@ -0,0 +1,215 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Blackout types
+const (
+	BlackoutWeekly = "weekly" // recurring window on days of the week
+	BlackoutRange  = "range"  // one-off period
+)
+
+// Blackout policies for runs that fall into a blackout
+const (
+	BlackoutSkip  = "skip"  // default
+	BlackoutDefer = "defer" // run when the blackout ends
+)
+
+// Blackout is a period in which schedules must not run, such as a change
+// freeze, a maintenance window or a holiday
+type Blackout struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // "weekly" or "range"
+
+	// Weekly
+	DaysOfWeek []int  `json:"daysOfWeek,omitempty"` // 0-6 (Sunday-Saturday) the window starts on; empty = every day
+	StartTime  string `json:"startTime,omitempty"`  // "HH:MM"
+	EndTime    string `json:"endTime,omitempty"`    // "HH:MM"; not after StartTime = ends the next day
+
+	// Range
+	Start string `json:"start,omitempty"` // "YYYY-MM-DDTHH:MM"
+	End   string `json:"end,omitempty"`   // "YYYY-MM-DDTHH:MM", exclusive
+
+	Source string `json:"source,omitempty"` // calendar file it was imported from
+}
+
+// maxChainedBlackouts limits how many adjacent windows BlackoutEnd follows
+const maxChainedBlackouts = 100
+
+// Validate checks the blackout's settings
+func (b *Blackout) Validate() error {
+	switch b.Type {
+	case BlackoutWeekly:
+		for _, d := range b.DaysOfWeek {
+			if d < 0 || d > 6 {
+				return fmt.Errorf("blackout '%s': invalid day of week: %d", b.Name, d)
+			}
+		}
+		if _, _, err := parseClock(b.StartTime); err != nil {
+			return fmt.Errorf("blackout '%s': start: %v", b.Name, err)
+		}
+		if _, _, err := parseClock(b.EndTime); err != nil {
+			return fmt.Errorf("blackout '%s': end: %v", b.Name, err)
+		}
+	case BlackoutRange:
+		start, end, err := b.rangeIn(time.Local)
+		if err != nil {
+			return err
+		}
+		if !start.Before(end) {
+			return fmt.Errorf("blackout '%s': end is not after start", b.Name)
+		}
+	default:
+		return fmt.Errorf("blackout '%s': unknown type: %s", b.Name, b.Type)
+	}
+	return nil
+}
+
+// Describe returns a human-readable description of the blackout
+func (b *Blackout) Describe() string {
+	switch b.Type {
+	case BlackoutWeekly:
+		days := "Every day"
+		if len(b.DaysOfWeek) > 0 && len(b.DaysOfWeek) < 7 {
+			names := make([]string, 0, len(b.DaysOfWeek))
+			for _, d := range b.DaysOfWeek {
+				if d >= 0 && d < len(weekdayNames) {
+					names = append(names, weekdayNames[d])
+				}
+			}
+			days = strings.Join(names, ", ")
+		}
+		return fmt.Sprintf("%s %s-%s", days, b.StartTime, b.EndTime)
+	case BlackoutRange:
+		return strings.Replace(b.Start, "T", " ", 1) + " to " + strings.Replace(b.End, "T", " ", 1)
+	default:
+		return b.Type
+	}
+}
+
+// window returns the occurrence of the blackout containing t, evaluated in loc
+func (b *Blackout) window(t time.Time, loc *time.Location) (start, end time.Time, ok bool) {
+	switch b.Type {
+	case BlackoutWeekly:
+		sh, sm, err := parseClock(b.StartTime)
+		if err != nil {
+			return start, end, false
+		}
+		eh, em, err := parseClock(b.EndTime)
+		if err != nil {
+			return start, end, false
+		}
+
+		local := t.In(loc)
+		// A window that started yesterday may last past midnight
+		for offset := 0; offset >= -1; offset-- {
+			day := time.Date(local.Year(), local.Month(), local.Day()+offset, 0, 0, 0, 0, loc)
+			if !b.onDay(day.Weekday()) {
+				continue
+			}
+			start = time.Date(day.Year(), day.Month(), day.Day(), sh, sm, 0, 0, loc)
+			end = time.Date(day.Year(), day.Month(), day.Day(), eh, em, 0, 0, loc)
+			if !end.After(start) {
+				end = time.Date(day.Year(), day.Month(), day.Day()+1, eh, em, 0, 0, loc)
+			}
+			if !t.Before(start) && t.Before(end) {
+				return start, end, true
+			}
+		}
+		return start, end, false
+
+	case BlackoutRange:
+		start, end, err := b.rangeIn(loc)
+		if err != nil {
+			return start, end, false
+		}
+		return start, end, !t.Before(start) && t.Before(end)
+	}
+	return start, end, false
+}
+
+// onDay reports whether a weekly window starts on day
+func (b *Blackout) onDay(day time.Weekday) bool {
+	if len(b.DaysOfWeek) == 0 {
+		return true
+	}
+	for _, d := range b.DaysOfWeek {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeIn parses the start and end of a range blackout in loc
+func (b *Blackout) rangeIn(loc *time.Location) (start, end time.Time, err error) {
+	start, err = time.ParseInLocation(DateTimeLayout, b.Start, loc)
+	if err != nil {
+		return start, end, fmt.Errorf("blackout '%s': invalid start: %s", b.Name, b.Start)
+	}
+	end, err = time.ParseInLocation(DateTimeLayout, b.End, loc)
+	if err != nil {
+		return start, end, fmt.Errorf("blackout '%s': invalid end: %s", b.Name, b.End)
+	}
+	return start, end, nil
+}
+
+// zonedBlackouts is a list of blackouts and the zone their times refer to
+type zonedBlackouts struct {
+	list []Blackout
+	loc  *time.Location
+}
+
+// ActiveBlackout returns the blackout at falls into, checking the task's own
+// blackouts (in the task's time zone) and the global ones (in the local
+// zone), together with the end of the blackout period. The end follows
+// adjacent and overlapping windows, so a deferred run starts only once all
+// of them are over. It returns nil if at is outside every blackout.
+func (t *ScheduledTask) ActiveBlackout(at time.Time, global []Blackout) (*Blackout, time.Time) {
+	loc, err := t.Location()
+	if err != nil {
+		loc = time.Local
+	}
+	lists := []zonedBlackouts{{t.Blackouts, loc}, {global, time.Local}}
+
+	var found *Blackout
+	end := at
+	for i := 0; i < maxChainedBlackouts; i++ {
+		b, wend := findBlackout(end, lists)
+		if b == nil {
+			break
+		}
+		if found == nil {
+			found = b
+		}
+		end = wend
+	}
+	if found == nil {
+		return nil, time.Time{}
+	}
+	return found, end
+}
+
+// findBlackout returns the first blackout containing at and the latest end
+// of the windows containing it
+func findBlackout(at time.Time, lists []zonedBlackouts) (*Blackout, time.Time) {
+	var found *Blackout
+	var end time.Time
+	for _, l := range lists {
+		for i := range l.list {
+			b := &l.list[i]
+			if _, wend, ok := b.window(at, l.loc); ok {
+				if found == nil {
+					found = b
+				}
+				if wend.After(end) {
+					end = wend
+				}
+			}
+		}
+	}
+	return found, end
+}
@@ -0,0 +1,225 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TypeAfter tasks have no time schedule; they run when the tasks in After
+// have completed
+const TypeAfter = "after"
+
+// Dependency conditions
+const (
+	ConditionSuccess = "success" // default: every device succeeded
+	ConditionFailure = "failure" // at least one device failed
+	ConditionAlways  = "always"
+)
+
+// Dependency is a task whose completion triggers the dependent task
+type Dependency struct {
+	TaskID    string `json:"taskId"`
+	Condition string `json:"condition,omitempty"` // "success" (default), "failure" or "always"
+}
+
+// completion is the result of a task's latest run
+type completion struct {
+	at      time.Time
+	success bool
+}
+
+// met reports whether a run with the given result satisfies the condition
+func (d Dependency) met(success bool) bool {
+	switch d.Condition {
+	case ConditionFailure:
+		return !success
+	case ConditionAlways:
+		return true
+	default:
+		return success
+	}
+}
+
+// never is the schedule of TypeAfter tasks
+type never struct{}
+
+// Next implements cron.Schedule
+func (never) Next(time.Time) time.Time {
+	return time.Time{}
+}
+
+// validateDependencies checks the dependency list of a task on its own
+func validateDependencies(task *ScheduledTask) error {
+	if task.ScheduleType != TypeAfter {
+		return nil
+	}
+	if len(task.After) == 0 {
+		return fmt.Errorf("select at least one schedule to run after")
+	}
+	seen := make(map[string]bool)
+	for _, d := range task.After {
+		switch d.Condition {
+		case "", ConditionSuccess, ConditionFailure, ConditionAlways:
+		default:
+			return fmt.Errorf("unknown dependency condition: %s", d.Condition)
+		}
+		if d.TaskID == task.ID {
+			return fmt.Errorf("a schedule cannot run after itself")
+		}
+		if seen[d.TaskID] {
+			return fmt.Errorf("a schedule is listed twice")
+		}
+		seen[d.TaskID] = true
+	}
+	return nil
+}
+
+// checkDependencies checks that the tasks task runs after exist and that
+// adding or updating task does not create a cycle (must be called with
+// lock held)
+func (s *Scheduler) checkDependencies(task *ScheduledTask) error {
+	for _, d := range task.After {
+		if _, ok := s.tasks[d.TaskID]; !ok {
+			return fmt.Errorf("schedule to run after not found: %s", d.TaskID)
+		}
+	}
+	if path := s.cyclePath(task); path != nil {
+		return fmt.Errorf("dependency cycle: %s", strings.Join(path, " → "))
+	}
+	return nil
+}
+
+// cyclePath returns the names along a dependency cycle through task, or
+// nil. The other tasks are acyclic, so any cycle passes through task.
+func (s *Scheduler) cyclePath(task *ScheduledTask) []string {
+	after := func(id string) []Dependency {
+		if id == task.ID {
+			return task.After
+		}
+		if t := s.tasks[id]; t != nil {
+			return t.After
+		}
+		return nil
+	}
+
+	visited := make(map[string]bool)
+	var visit func(id string, path []string) []string
+	visit = func(id string, path []string) []string {
+		for _, d := range after(id) {
+			next := append(append([]string{}, path...), s.taskName(d.TaskID, task))
+			if d.TaskID == task.ID {
+				return next
+			}
+			if visited[d.TaskID] {
+				continue
+			}
+			visited[d.TaskID] = true
+			if p := visit(d.TaskID, next); p != nil {
+				return p
+			}
+		}
+		return nil
+	}
+	return visit(task.ID, []string{task.Name})
+}
+
+// taskName returns the name of a task, preferring the pending version of
+// task (must be called with lock held)
+func (s *Scheduler) taskName(id string, task *ScheduledTask) string {
+	if id == task.ID {
+		return task.Name
+	}
+	if t := s.tasks[id]; t != nil {
+		return t.Name
+	}
+	return "(deleted schedule)"
+}
+
+// dependents returns the names of the tasks that run after id (must be
+// called with lock held)
+func (s *Scheduler) dependents(id string) []string {
+	var names []string
+	for _, t := range s.tasks {
+		for _, d := range t.After {
+			if d.TaskID == id {
+				names = append(names, t.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Completed records the result of a run of task id and returns the enabled
+// tasks that are now due: every task they run after has completed, meeting
+// its condition, since they last ran. The returned tasks are marked as run.
+func (s *Scheduler) Completed(id string, success bool, at time.Time) []*ScheduledTask {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.completed[id] = completion{at: at, success: success}
+
+	var due []*ScheduledTask
+	for _, t := range s.tasks {
+		if !t.Enabled || t.ScheduleType != TypeAfter || !t.runsAfter(id) || !s.dependenciesMet(t) {
+			continue
+		}
+		last := at
+		t.LastRun = &last
+		due = append(due, t)
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].Name < due[j].Name
+	})
+	return due
+}
+
+// runsAfter reports whether the task depends on id
+func (t *ScheduledTask) runsAfter(id string) bool {
+	for _, d := range t.After {
+		if d.TaskID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// dependenciesMet reports whether every dependency of t has completed
+// since t last ran and meets its condition (must be called with lock held)
+func (s *Scheduler) dependenciesMet(t *ScheduledTask) bool {
+	for _, d := range t.After {
+		c, ok := s.completed[d.TaskID]
+		if !ok || (t.LastRun != nil && !c.at.After(*t.LastRun)) || !d.met(c.success) {
+			return false
+		}
+	}
+	return true
+}
+
+// Describe returns a human-readable description of the task's schedule.
+// Unlike ScheduledTask.Describe, it names the tasks a TypeAfter task runs
+// after.
+func (s *Scheduler) Describe(task *ScheduledTask) string {
+	if task.ScheduleType != TypeAfter {
+		return task.Describe()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	parts := make([]string, len(task.After))
+	for i, d := range task.After {
+		verb := "succeeds"
+		switch d.Condition {
+		case ConditionFailure:
+			verb = "fails"
+		case ConditionAlways:
+			verb = "finishes"
+		}
+		parts[i] = fmt.Sprintf("'%s' %s", s.taskName(d.TaskID, task), verb)
+	}
+	return "After " + strings.Join(parts, " and ")
+}
@@ -0,0 +1,194 @@
+package scheduler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// icsYears is how many years ahead yearly recurring events are expanded
+const icsYears = 5
+
+// icsEvent is the part of a VEVENT that ParseICS uses
+type icsEvent struct {
+	summary    string
+	start, end time.Time
+	allDay     bool
+	rrule      string
+}
+
+// ParseICS reads the events of an iCalendar (.ics) file, such as a holiday
+// or change-freeze calendar, as range blackouts in the local time zone.
+// All-day events cover whole local days. Yearly recurring events are
+// expanded for the next few years; other recurrences are not supported
+// and only their first occurrence is used.
+func ParseICS(data []byte, source string) ([]Blackout, error) {
+	var blackouts []Blackout
+	var ev *icsEvent
+	now := time.Now()
+
+	for n, line := range unfoldICS(data) {
+		name, params, value := splitICSLine(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			ev = &icsEvent{}
+		case name == "END" && value == "VEVENT":
+			if ev == nil || ev.start.IsZero() {
+				ev = nil
+				continue
+			}
+			blackouts = append(blackouts, ev.blackouts(source, now)...)
+			ev = nil
+		case ev == nil:
+			continue
+		case name == "SUMMARY":
+			ev.summary = unescapeICS(value)
+		case name == "DTSTART":
+			t, allDay, err := parseICSTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: DTSTART: %v", n+1, err)
+			}
+			ev.start, ev.allDay = t, allDay
+		case name == "DTEND":
+			t, _, err := parseICSTime(value, params)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: DTEND: %v", n+1, err)
+			}
+			ev.end = t
+		case name == "RRULE":
+			ev.rrule = value
+		}
+	}
+
+	if blackouts == nil && !bytes.Contains(data, []byte("BEGIN:VCALENDAR")) {
+		return nil, fmt.Errorf("not an iCalendar file")
+	}
+	return blackouts, nil
+}
+
+// blackouts returns the occurrences of the event
+func (ev *icsEvent) blackouts(source string, now time.Time) []Blackout {
+	end := ev.end
+	if end.IsZero() || !end.After(ev.start) {
+		if !ev.allDay {
+			return nil
+		}
+		end = ev.start.AddDate(0, 0, 1)
+	}
+
+	name := ev.summary
+	if name == "" {
+		name = "Calendar event"
+	}
+	occurrence := func(years int) Blackout {
+		return Blackout{
+			Name:   name,
+			Type:   BlackoutRange,
+			Start:  ev.start.AddDate(years, 0, 0).Format(DateTimeLayout),
+			End:    end.AddDate(years, 0, 0).Format(DateTimeLayout),
+			Source: source,
+		}
+	}
+
+	rule := parseRRule(ev.rrule)
+	if rule["FREQ"] != "YEARLY" {
+		return []Blackout{occurrence(0)}
+	}
+
+	// Yearly: from the first occurrence until a few years from now
+	count := -1
+	if c, err := strconv.Atoi(rule["COUNT"]); err == nil {
+		count = c
+	}
+	var until time.Time
+	if rule["UNTIL"] != "" {
+		until, _, _ = parseICSTime(rule["UNTIL"], nil)
+	}
+	var list []Blackout
+	for y := 0; ev.start.Year()+y <= now.Year()+icsYears; y++ {
+		if count >= 0 && y >= count {
+			break
+		}
+		start := ev.start.AddDate(y, 0, 0)
+		if !until.IsZero() && start.After(until) {
+			break
+		}
+		if start.AddDate(0, 0, 1).Before(now.AddDate(-1, 0, 0)) {
+			continue // long past
+		}
+		list = append(list, occurrence(y))
+	}
+	return list
+}
+
+// unfoldICS splits data into lines, joining folded continuation lines
+func unfoldICS(data []byte) []string {
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitICSLine splits "NAME;PARAM=x:value" into its parts
+func splitICSLine(line string) (name string, params map[string]string, value string) {
+	head, value, _ := strings.Cut(line, ":")
+	parts := strings.Split(head, ";")
+	name = strings.ToUpper(parts[0])
+	params = make(map[string]string)
+	for _, p := range parts[1:] {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			params[strings.ToUpper(k)] = strings.Trim(v, `"`)
+		}
+	}
+	return name, params, value
+}
+
+// parseICSTime parses a DATE or DATE-TIME value and converts it to the
+// local time zone. Dates stay on the same calendar day.
+func parseICSTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t.In(time.Local), false, err
+	}
+
+	loc := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t.In(time.Local), false, err
+}
+
+// parseRRule splits "FREQ=YEARLY;COUNT=3" into its parts
+func parseRRule(rule string) map[string]string {
+	parts := make(map[string]string)
+	for _, p := range strings.Split(rule, ";") {
+		if k, v, ok := strings.Cut(p, "="); ok {
+			parts[strings.ToUpper(k)] = v
+		}
+	}
+	return parts
+}
+
+// unescapeICS decodes iCalendar TEXT escapes
+func unescapeICS(s string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(s)
+}
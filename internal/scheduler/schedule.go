@@ -0,0 +1,781 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // time zones also on systems without a zone database (Windows)
+
+	"github.com/robfig/cron/v3"
+)
+
+// Schedule types
+const (
+	TypeDaily    = "daily"
+	TypeWeekly   = "weekly"
+	TypeMonthly  = "monthly"
+	TypeCron     = "cron"     // CronExpression
+	TypeInterval = "interval" // every Interval IntervalUnit, aligned to Time
+	TypeOnce     = "once"     // at RunAt
+)
+
+// Date formats of RunAt, StartDate and EndDate
+const (
+	DateTimeLayout = "2006-01-02T15:04"
+	DateLayout     = "2006-01-02"
+)
+
+// Missed run policies
+const (
+	MissedSkip    = "skip" // default
+	MissedRunOnce = "once" // run once immediately
+	MissedRunAll  = "all"  // run every missed occurrence, up to MaxCatchUpRuns
+)
+
+// Overlap policies for runs that fire while the app is busy with another run
+const (
+	OverlapQueue   = "queue"   // default: queue every run
+	OverlapSkip    = "skip"    // skip if the task is already running or queued
+	OverlapReplace = "replace" // replace the queued run of the task, if any
+)
+
+// Queue priorities: queued runs of a higher priority run first, runs of the
+// same priority in the order they were queued
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal" // default
+	PriorityLow    = "low"
+)
+
+// PriorityRank orders priorities; unknown priorities rank as normal
+func PriorityRank(priority string) int {
+	switch priority {
+	case PriorityHigh:
+		return 1
+	case PriorityLow:
+		return -1
+	default:
+		return 0
+	}
+}
+
+// ValidPriority reports whether priority is a known priority or empty (normal)
+func ValidPriority(priority string) bool {
+	switch priority {
+	case "", PriorityHigh, PriorityNormal, PriorityLow:
+		return true
+	}
+	return false
+}
+
+// MaxQueueWaitMinutes limits MaxQueueWait (one week)
+const MaxQueueWaitMinutes = 7 * 24 * 60
+
+const (
+	// MaxMissedRuns limits how many missed runs of a task are listed
+	MaxMissedRuns = 100
+
+	// MaxCatchUpRuns limits how many missed runs of a task the "all" policy runs
+	MaxCatchUpRuns = 10
+)
+
+// Interval units
+const (
+	UnitMinutes = "minutes"
+	UnitHours   = "hours"
+)
+
+// cronParser accepts standard 5-field expressions, an optional leading
+// seconds field and descriptors such as @hourly or @every 90m
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+var weekdayNames = []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+// Schedule returns when the task fires in its time zone, limited to its date range
+func (t *ScheduledTask) Schedule() (cron.Schedule, error) {
+	switch t.ScheduleType {
+	case TypeOnce:
+		at, err := t.runAt()
+		if err != nil {
+			return nil, err
+		}
+		return once(at), nil
+	case TypeAfter:
+		return never{}, validateDependencies(t)
+	}
+
+	sched, err := t.recurring()
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := t.dateRange()
+	if err != nil {
+		return nil, err
+	}
+	if !start.IsZero() || !end.IsZero() {
+		sched = &bounded{base: sched, start: start, end: end}
+	}
+
+	if t.TimeZone != "" {
+		loc, err := t.Location()
+		if err != nil {
+			return nil, err
+		}
+		sched = &zoned{base: sched, loc: loc}
+	}
+	return sched, nil
+}
+
+// Location returns the task's time zone; tasks without one use the local zone
+func (t *ScheduledTask) Location() (*time.Location, error) {
+	if t.TimeZone == "" {
+		return time.Local, nil
+	}
+	loc, err := time.LoadLocation(t.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone: %s", t.TimeZone)
+	}
+	return loc, nil
+}
+
+// recurring returns the schedule of a recurring task without its date range
+func (t *ScheduledTask) recurring() (cron.Schedule, error) {
+	switch t.ScheduleType {
+	case TypeCron:
+		return parseCron(t.CronExpression)
+	case TypeInterval:
+		sched, err := t.intervalSchedule()
+		if err != nil {
+			return nil, err
+		}
+		return sched, nil
+	default:
+		expr, err := buildCronExpression(t)
+		if err != nil {
+			return nil, err
+		}
+		return cronParser.Parse(expr)
+	}
+}
+
+// Expired reports whether the task will never fire again: a one-time task
+// that has fired or whose time has passed, or a task past its end date
+func (t *ScheduledTask) Expired(now time.Time) bool {
+	if t.ScheduleType == TypeOnce {
+		if t.LastRun != nil {
+			return true
+		}
+		at, err := t.runAt()
+		return err == nil && !at.After(now)
+	}
+
+	_, end, err := t.dateRange()
+	return err == nil && !end.IsZero() && !end.After(now)
+}
+
+// NextRuns returns the next n fire times after from
+func (t *ScheduledTask) NextRuns(from time.Time, n int) ([]time.Time, error) {
+	sched, err := t.Schedule()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]time.Time, 0, n)
+	for next := from; len(runs) < n; {
+		next = sched.Next(next)
+		if next.IsZero() {
+			break
+		}
+		runs = append(runs, next)
+	}
+	return runs, nil
+}
+
+// describeRecurrence describes when the task fires, without its date range
+func (t *ScheduledTask) describeRecurrence() string {
+	switch t.ScheduleType {
+	case TypeDaily:
+		return "Every day at " + t.Time
+	case TypeWeekly:
+		days := make([]string, 0, len(t.DaysOfWeek))
+		for _, d := range t.DaysOfWeek {
+			if d >= 0 && d < len(weekdayNames) {
+				days = append(days, weekdayNames[d])
+			}
+		}
+		return "Every " + strings.Join(days, ", ") + " at " + t.Time
+	case TypeMonthly:
+		return fmt.Sprintf("Day %d of every month at %s", t.DayOfMonth, t.Time)
+	case TypeInterval:
+		return t.describeInterval()
+	case TypeCron:
+		return describeCron(t.CronExpression)
+	case TypeOnce:
+		return "Once at " + strings.Replace(t.RunAt, "T", " ", 1)
+	case TypeAfter:
+		return "After other schedules"
+	default:
+		return t.ScheduleType
+	}
+}
+
+// Describe returns a human-readable description of the schedule, its time
+// zone and its date range
+func (t *ScheduledTask) Describe() string {
+	desc := t.describeRecurrence()
+	if t.ScheduleType == TypeAfter {
+		return desc
+	}
+	if t.TimeZone != "" {
+		desc += ", " + t.TimeZone + " time"
+	}
+	if t.ScheduleType == TypeOnce {
+		return desc
+	}
+	switch {
+	case t.StartDate != "" && t.EndDate != "":
+		desc += fmt.Sprintf(" (%s to %s)", t.StartDate, t.EndDate)
+	case t.StartDate != "":
+		desc += fmt.Sprintf(" (from %s)", t.StartDate)
+	case t.EndDate != "":
+		desc += fmt.Sprintf(" (until %s)", t.EndDate)
+	}
+	return desc
+}
+
+// buildCronExpression creates a cron expression for daily, weekly and monthly tasks
+func buildCronExpression(task *ScheduledTask) (string, error) {
+	hour, minute, err := parseClock(task.Time)
+	if err != nil {
+		return "", err
+	}
+
+	switch task.ScheduleType {
+	case TypeDaily:
+		// Run every day at specified time
+		return fmt.Sprintf("%d %d * * *", minute, hour), nil
+
+	case TypeWeekly:
+		// Run on specified days of week
+		if len(task.DaysOfWeek) == 0 {
+			return "", fmt.Errorf("no days specified for weekly schedule")
+		}
+		days := make([]string, len(task.DaysOfWeek))
+		for i, d := range task.DaysOfWeek {
+			days[i] = strconv.Itoa(d)
+		}
+		return fmt.Sprintf("%d %d * * %s", minute, hour, strings.Join(days, ",")), nil
+
+	case TypeMonthly:
+		// Run on specified day of month
+		if task.DayOfMonth < 1 || task.DayOfMonth > 31 {
+			return "", fmt.Errorf("invalid day of month: %d", task.DayOfMonth)
+		}
+		return fmt.Sprintf("%d %d %d * *", minute, hour, task.DayOfMonth), nil
+
+	default:
+		return "", fmt.Errorf("unknown schedule type: %s", task.ScheduleType)
+	}
+}
+
+// parseClock parses "HH:MM"
+func parseClock(s string) (hour, minute int, err error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid time format: %s", s)
+	}
+
+	hour, err = strconv.Atoi(parts[0])
+	if err != nil || hour < 0 || hour > 23 {
+		return 0, 0, fmt.Errorf("invalid hour: %s", parts[0])
+	}
+
+	minute, err = strconv.Atoi(parts[1])
+	if err != nil || minute < 0 || minute > 59 {
+		return 0, 0, fmt.Errorf("invalid minute: %s", parts[1])
+	}
+	return hour, minute, nil
+}
+
+// ==================== Once and Date Range ====================
+
+// runAt returns the time of a one-time task
+func (t *ScheduledTask) runAt() (time.Time, error) {
+	if t.RunAt == "" {
+		return time.Time{}, fmt.Errorf("date and time are required for a one-time schedule")
+	}
+	loc, err := t.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+	at, err := time.ParseInLocation(DateTimeLayout, strings.Replace(t.RunAt, " ", "T", 1), loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date and time: %s", t.RunAt)
+	}
+	return at, nil
+}
+
+// dateRange returns the start of StartDate and the end of EndDate (exclusive).
+// Unset bounds are zero.
+func (t *ScheduledTask) dateRange() (start, end time.Time, err error) {
+	loc, err := t.Location()
+	if err != nil {
+		return start, end, err
+	}
+	if t.StartDate != "" {
+		start, err = time.ParseInLocation(DateLayout, t.StartDate, loc)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid start date: %s", t.StartDate)
+		}
+	}
+	if t.EndDate != "" {
+		end, err = time.ParseInLocation(DateLayout, t.EndDate, loc)
+		if err != nil {
+			return start, end, fmt.Errorf("invalid end date: %s", t.EndDate)
+		}
+		end = end.AddDate(0, 0, 1)
+	}
+	if !start.IsZero() && !end.IsZero() && !start.Before(end) {
+		return start, end, fmt.Errorf("end date is before start date")
+	}
+	return start, end, nil
+}
+
+// once fires a single time
+type once time.Time
+
+// Next implements cron.Schedule
+func (s once) Next(after time.Time) time.Time {
+	if at := time.Time(s); at.After(after) {
+		return at
+	}
+	return time.Time{}
+}
+
+// bounded limits a schedule to [start, end)
+type bounded struct {
+	base       cron.Schedule
+	start, end time.Time // zero = unbounded
+}
+
+// Next implements cron.Schedule
+func (s *bounded) Next(after time.Time) time.Time {
+	if !s.start.IsZero() && after.Before(s.start) {
+		// Schedules round up to the next second, so a run exactly at the start is included
+		after = s.start.Add(-time.Second)
+	}
+	next := s.base.Next(after)
+	if !s.end.IsZero() && !next.Before(s.end) {
+		return time.Time{}
+	}
+	return next
+}
+
+// zoned evaluates a schedule in another time zone, so wall-clock times such
+// as "03:00" and DST transitions follow that zone
+type zoned struct {
+	base cron.Schedule
+	loc  *time.Location
+}
+
+// Next implements cron.Schedule
+func (s *zoned) Next(after time.Time) time.Time {
+	next := s.base.Next(after.In(s.loc))
+	if next.IsZero() {
+		return next
+	}
+	return next.In(after.Location())
+}
+
+// ==================== Interval ====================
+
+// intervalEpoch is the date runs are counted from when an interval task has
+// no start date
+var intervalEpoch = time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// interval fires every `every` minutes of wall-clock time, counted from the
+// anchor time on the task's start date (or intervalEpoch), and continues
+// evenly across midnight. An interval that divides a day fires at the same
+// times every day: "every 4 hours at 01:00" runs at 01:00, 05:00, ...,
+// 21:00, also across DST changes. "Every 7 hours" runs at 01:00, 08:00,
+// 15:00, 22:00, 05:00 (the next day), ...
+type interval struct {
+	every int // minutes
+	start int // a run, in wall-clock minutes since intervalEpoch
+}
+
+func (t *ScheduledTask) intervalSchedule() (*interval, error) {
+	every := t.Interval
+	switch t.IntervalUnit {
+	case UnitMinutes:
+		if every < 1 || every > 24*60 {
+			return nil, fmt.Errorf("interval must be between 1 and 1440 minutes")
+		}
+	case UnitHours:
+		if every < 1 || every > 24 {
+			return nil, fmt.Errorf("interval must be between 1 and 24 hours")
+		}
+		every *= 60
+	default:
+		return nil, fmt.Errorf("unknown interval unit: %s", t.IntervalUnit)
+	}
+
+	anchor := 0
+	if t.Time != "" {
+		hour, minute, err := parseClock(t.Time)
+		if err != nil {
+			return nil, err
+		}
+		anchor = hour*60 + minute
+	}
+	start, _, err := t.dateRange()
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		start = intervalEpoch
+	}
+	return &interval{every: every, start: wallMinutes(start) + anchor}, nil
+}
+
+// wallMinutes returns the wall-clock minutes of t since intervalEpoch,
+// counting every day as 24 hours
+func wallMinutes(t time.Time) int {
+	date := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(date.Sub(intervalEpoch) / (24 * time.Hour))
+	return days*24*60 + t.Hour()*60 + t.Minute()
+}
+
+// daily reports whether the interval divides a day, so it fires at the same
+// times every day
+func (s *interval) daily() bool {
+	return 24*60%s.every == 0
+}
+
+// Next implements cron.Schedule
+func (s *interval) Next(after time.Time) time.Time {
+	// The first run in a later minute than after
+	m := wallMinutes(after) + 1
+	if r := mod(m-s.start, s.every); r != 0 {
+		m += s.every - r
+	}
+	// Skip runs that are not after it when the wall-clock time repeats
+	// (DST ends)
+	for end := m + 2*24*60; m < end; m += s.every {
+		next := time.Date(intervalEpoch.Year(), intervalEpoch.Month(), intervalEpoch.Day(), 0, m, 0, 0, after.Location())
+		if next.After(after) {
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// mod returns a modulo b in [0, b)
+func mod(a, b int) int {
+	return (a%b + b) % b
+}
+
+func (t *ScheduledTask) describeInterval() string {
+	s, err := t.intervalSchedule()
+	if err != nil {
+		return "Invalid interval: " + err.Error()
+	}
+
+	unit := "minutes"
+	n := s.every
+	if s.every%60 == 0 {
+		unit, n = "hours", s.every/60
+	}
+	phase := mod(s.start, 24*60)
+	if !s.daily() {
+		return fmt.Sprintf("Every %d %s from %02d:%02d, continuing across days", n, unit, phase/60, phase%60)
+	}
+
+	phase = mod(s.start, s.every)
+	if n == 1 {
+		unit = strings.TrimSuffix(unit, "s")
+		return fmt.Sprintf("Every %s at minute %d", unit, phase%60)
+	}
+
+	var times []string
+	for m := phase; m < 24*60; m += s.every {
+		times = append(times, fmt.Sprintf("%02d:%02d", m/60, m%60))
+	}
+	if len(times) <= 6 {
+		return fmt.Sprintf("Every %d %s at %s", n, unit, strings.Join(times, ", "))
+	}
+	return fmt.Sprintf("Every %d %s from %s", n, unit, times[0])
+}
+
+// ==================== Cron ====================
+
+// parseCron parses a cron expression. Besides the robfig/cron syntax, the
+// day-of-week field accepts "D#N" for the N-th weekday D of the month
+// (e.g. "1#1" = first Monday).
+func parseCron(expr string) (cron.Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("cron expression is required")
+	}
+
+	fields := strings.Fields(expr)
+	if strings.HasPrefix(expr, "@") || !strings.Contains(fields[len(fields)-1], "#") {
+		sched, err := cronParser.Parse(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression: %v", err)
+		}
+		return sched, nil
+	}
+
+	if len(fields) < 5 {
+		return nil, fmt.Errorf("invalid cron expression: expected 5 or 6 fields")
+	}
+	if dom := fields[len(fields)-3]; dom != "*" && dom != "?" {
+		return nil, fmt.Errorf("invalid cron expression: '#' in day of week cannot be combined with a day of month")
+	}
+
+	nth, err := parseNthWeekdays(fields[len(fields)-1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %v", err)
+	}
+	fields[len(fields)-1] = "*"
+	base, err := cronParser.Parse(strings.Join(fields, " "))
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression: %v", err)
+	}
+	return &nthWeekday{base: base, days: nth}, nil
+}
+
+// parseNthWeekdays parses a list such as "1#1,5#3" into weekday -> occurrences
+func parseNthWeekdays(field string) (map[time.Weekday][]int, error) {
+	days := make(map[time.Weekday][]int)
+	for _, item := range strings.Split(field, ",") {
+		d, n, ok := strings.Cut(item, "#")
+		if !ok {
+			return nil, fmt.Errorf("cannot mix '%s' with 'D#N' items", item)
+		}
+		day, err := parseWeekday(d)
+		if err != nil {
+			return nil, err
+		}
+		occurrence, err := strconv.Atoi(n)
+		if err != nil || occurrence < 1 || occurrence > 5 {
+			return nil, fmt.Errorf("invalid occurrence '%s' (1-5)", n)
+		}
+		days[day] = append(days[day], occurrence)
+	}
+	return days, nil
+}
+
+// parseWeekday parses 0-7 (0 and 7 = Sunday) or a three-letter name
+func parseWeekday(s string) (time.Weekday, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 7 {
+			return 0, fmt.Errorf("invalid day of week '%s'", s)
+		}
+		return time.Weekday(n % 7), nil
+	}
+	for i, name := range weekdayNames {
+		if strings.EqualFold(s, name) {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid day of week '%s'", s)
+}
+
+// nthWeekday restricts base to the N-th occurrences of weekdays in the month
+type nthWeekday struct {
+	base cron.Schedule
+	days map[time.Weekday][]int
+}
+
+// Next implements cron.Schedule
+func (s *nthWeekday) Next(after time.Time) time.Time {
+	// Every month has each weekday, so 5 years always contain a match
+	for next := s.base.Next(after); !next.IsZero() && next.Before(after.AddDate(5, 0, 0)); {
+		occurrence := (next.Day()-1)/7 + 1
+		for _, n := range s.days[next.Weekday()] {
+			if n == occurrence {
+				return next
+			}
+		}
+		// Skip the rest of the day
+		endOfDay := time.Date(next.Year(), next.Month(), next.Day(), 23, 59, 59, 0, next.Location())
+		next = s.base.Next(endOfDay)
+	}
+	return time.Time{}
+}
+
+var ordinals = []string{"", "first", "second", "third", "fourth", "fifth"}
+
+// describeCron describes common cron expressions in words, falling back
+// to the expression itself
+func describeCron(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "@") {
+		switch {
+		case expr == "@yearly" || expr == "@annually":
+			return "Every year on Jan 1 at 00:00"
+		case expr == "@monthly":
+			return "Day 1 of every month at 00:00"
+		case expr == "@weekly":
+			return "Every Sun at 00:00"
+		case expr == "@daily" || expr == "@midnight":
+			return "Every day at 00:00"
+		case expr == "@hourly":
+			return "Every hour at minute 0"
+		case strings.HasPrefix(expr, "@every "):
+			return "Every " + strings.TrimSpace(strings.TrimPrefix(expr, "@every"))
+		}
+		return "Cron: " + expr
+	}
+
+	fields := strings.Fields(expr)
+	second := "0"
+	switch len(fields) {
+	case 5:
+	case 6:
+		second, fields = fields[0], fields[1:]
+	default:
+		return "Cron: " + expr
+	}
+	minute, hour, dom, month, dow := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	when := describeTime(second, minute, hour)
+	if when == "" {
+		return "Cron: " + expr
+	}
+
+	var days string
+	switch {
+	case isAny(dom) && isAny(dow):
+		days = "every day"
+	case isAny(dom):
+		d := describeWeekdays(dow)
+		if d == "" {
+			return "Cron: " + expr
+		}
+		days = d
+	case isAny(dow) && isNumber(dom):
+		days = "on day " + dom
+	case isAny(dow):
+		days = "on days " + dom
+	default:
+		return "Cron: " + expr
+	}
+	if !isAny(month) {
+		days += " in month " + month
+	}
+	return capitalize(when + ", " + days)
+}
+
+// describeTime describes the second, minute and hour fields, or returns ""
+func describeTime(second, minute, hour string) string {
+	if second != "0" {
+		if strings.HasPrefix(second, "*/") && minute == "*" && hour == "*" {
+			return "every " + strings.TrimPrefix(second, "*/") + " seconds"
+		}
+		return ""
+	}
+	switch {
+	case isNumber(minute) && isNumber(hour):
+		h, _ := strconv.Atoi(hour)
+		m, _ := strconv.Atoi(minute)
+		return fmt.Sprintf("at %02d:%02d", h, m)
+	case isNumber(minute) && strings.Contains(hour, ",") && allNumbers(hour):
+		m, _ := strconv.Atoi(minute)
+		var times []string
+		for _, h := range strings.Split(hour, ",") {
+			n, _ := strconv.Atoi(h)
+			times = append(times, fmt.Sprintf("%02d:%02d", n, m))
+		}
+		return "at " + strings.Join(times, ", ")
+	case isNumber(minute) && hour == "*":
+		return "every hour at minute " + minute
+	case isNumber(minute) && strings.HasPrefix(hour, "*/"):
+		return "every " + strings.TrimPrefix(hour, "*/") + " hours at minute " + minute
+	case minute == "*" && hour == "*":
+		return "every minute"
+	case strings.HasPrefix(minute, "*/") && hour == "*":
+		return "every " + strings.TrimPrefix(minute, "*/") + " minutes"
+	case strings.HasPrefix(minute, "*/") && isRange(hour):
+		from, to, _ := strings.Cut(hour, "-")
+		return fmt.Sprintf("every %s minutes between %s:00 and %s:59", strings.TrimPrefix(minute, "*/"), pad(from), pad(to))
+	}
+	return ""
+}
+
+// describeWeekdays describes the day-of-week field, or returns ""
+func describeWeekdays(dow string) string {
+	if strings.Contains(dow, "#") {
+		if _, err := parseNthWeekdays(dow); err != nil {
+			return ""
+		}
+		var parts []string
+		for _, item := range strings.Split(dow, ",") {
+			d, n, _ := strings.Cut(item, "#")
+			day, _ := parseWeekday(d)
+			occurrence, _ := strconv.Atoi(n)
+			parts = append(parts, ordinals[occurrence]+" "+weekdayNames[day])
+		}
+		return "on the " + strings.Join(parts, " and ") + " of the month"
+	}
+
+	var days []string
+	for _, item := range strings.Split(dow, ",") {
+		if from, to, ok := strings.Cut(item, "-"); ok {
+			f, err1 := parseWeekday(from)
+			t, err2 := parseWeekday(to)
+			if err1 != nil || err2 != nil {
+				return ""
+			}
+			days = append(days, weekdayNames[f]+"-"+weekdayNames[t])
+			continue
+		}
+		d, err := parseWeekday(item)
+		if err != nil {
+			return ""
+		}
+		days = append(days, weekdayNames[d])
+	}
+	return "on " + strings.Join(days, ", ")
+}
+
+func isAny(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func isNumber(field string) bool {
+	_, err := strconv.Atoi(field)
+	return err == nil
+}
+
+func allNumbers(field string) bool {
+	for _, f := range strings.Split(field, ",") {
+		if !isNumber(f) {
+			return false
+		}
+	}
+	return true
+}
+
+func isRange(field string) bool {
+	from, to, ok := strings.Cut(field, "-")
+	return ok && isNumber(from) && isNumber(to)
+}
+
+func pad(n string) string {
+	if len(n) == 1 {
+		return "0" + n
+	}
+	return n
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,123 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIntervalNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone data not available:", err)
+	}
+
+	tests := []struct {
+		name string
+		task ScheduledTask
+		from time.Time
+		want []string // fire times, "2006-01-02 15:04" in from's zone
+	}{
+		{
+			name: "divides a day",
+			task: ScheduledTask{Interval: 4, IntervalUnit: UnitHours, Time: "01:00"},
+			from: time.Date(2026, 3, 2, 18, 30, 0, 0, time.UTC),
+			want: []string{"2026-03-02 21:00", "2026-03-03 01:00", "2026-03-03 05:00"},
+		},
+		{
+			name: "minutes",
+			task: ScheduledTask{Interval: 15, IntervalUnit: UnitMinutes, Time: "00:05"},
+			from: time.Date(2026, 3, 2, 23, 50, 0, 0, time.UTC),
+			want: []string{"2026-03-03 00:05", "2026-03-03 00:20"},
+		},
+		{
+			name: "on a run",
+			task: ScheduledTask{Interval: 30, IntervalUnit: UnitMinutes},
+			from: time.Date(2026, 3, 2, 10, 30, 0, 0, time.UTC),
+			want: []string{"2026-03-02 11:00"},
+		},
+		{
+			name: "seconds past a run",
+			task: ScheduledTask{Interval: 30, IntervalUnit: UnitMinutes},
+			from: time.Date(2026, 3, 2, 10, 29, 59, 0, time.UTC),
+			want: []string{"2026-03-02 10:30"},
+		},
+		{
+			// 7 hours does not divide a day: the runs continue across
+			// midnight instead of restarting at the anchor
+			name: "does not divide a day",
+			task: ScheduledTask{Interval: 7, IntervalUnit: UnitHours, Time: "01:00", StartDate: "2026-03-01"},
+			from: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+			want: []string{"2026-03-01 01:00", "2026-03-01 08:00", "2026-03-01 15:00", "2026-03-01 22:00", "2026-03-02 05:00", "2026-03-02 12:00"},
+		},
+		{
+			name: "does not divide a day, counted from the epoch",
+			task: ScheduledTask{Interval: 90, IntervalUnit: UnitMinutes, Time: "00:00"},
+			from: time.Date(2000, 1, 1, 22, 0, 0, 0, time.UTC),
+			want: []string{"2000-01-01 22:30", "2000-01-02 00:00", "2000-01-02 01:30"},
+		},
+		{
+			name: "DST starts",
+			task: ScheduledTask{Interval: 4, IntervalUnit: UnitHours, Time: "01:00", TimeZone: "America/New_York"},
+			from: time.Date(2026, 3, 7, 22, 0, 0, 0, newYork),
+			want: []string{"2026-03-08 01:00", "2026-03-08 05:00", "2026-03-08 09:00"},
+		},
+		{
+			name: "DST ends",
+			task: ScheduledTask{Interval: 30, IntervalUnit: UnitMinutes, TimeZone: "America/New_York"},
+			from: time.Date(2026, 11, 1, 1, 40, 0, 0, newYork),
+			want: []string{"2026-11-01 02:00", "2026-11-01 02:30"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.task.ScheduleType = TypeInterval
+			runs, err := tt.task.NextRuns(tt.from, len(tt.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, len(runs))
+			for i, r := range runs {
+				got[i] = r.In(tt.from.Location()).Format("2006-01-02 15:04")
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIntervalEvenAcrossMidnight(t *testing.T) {
+	for every := 1; every <= 24; every++ {
+		task := ScheduledTask{ScheduleType: TypeInterval, Interval: every, IntervalUnit: UnitHours, Time: "03:00"}
+		runs, err := task.NextRuns(time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC), 3*24/every+2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 1; i < len(runs); i++ {
+			if gap := runs[i].Sub(runs[i-1]); gap != time.Duration(every)*time.Hour {
+				t.Fatalf("every %d hours: %s -> %s is %s", every, runs[i-1], runs[i], gap)
+			}
+		}
+	}
+}
+
+func TestDescribeInterval(t *testing.T) {
+	tests := []struct {
+		task ScheduledTask
+		want string
+	}{
+		{ScheduledTask{Interval: 4, IntervalUnit: UnitHours, Time: "01:00"}, "Every 4 hours at 01:00, 05:00, 09:00, 13:00, 17:00, 21:00"},
+		{ScheduledTask{Interval: 1, IntervalUnit: UnitHours, Time: "00:05"}, "Every hour at minute 5"},
+		{ScheduledTask{Interval: 7, IntervalUnit: UnitHours, Time: "01:00"}, "Every 7 hours from 01:00, continuing across days"},
+	}
+	for _, tt := range tests {
+		if got := tt.task.describeInterval(); got != tt.want {
+			t.Errorf("%d %s at %s: got %q, want %q", tt.task.Interval, tt.task.IntervalUnit, tt.task.Time, got, tt.want)
+		}
+	}
+}
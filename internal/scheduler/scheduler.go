@@ -2,7 +2,7 @@ package scheduler
 
 import (
 	"fmt"
-	"strconv"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,6 +16,7 @@ type Scheduler struct {
 	cron      *cron.Cron
 	tasks     map[string]*ScheduledTask
 	cronIDs   map[string]cron.EntryID
+	completed map[string]completion // latest result of each task, for dependencies
 	mu        sync.RWMutex
 	onExecute ScheduleCallback
 }
@@ -26,6 +27,7 @@ func NewScheduler(onExecute ScheduleCallback) *Scheduler {
 		cron:      cron.New(),
 		tasks:     make(map[string]*ScheduledTask),
 		cronIDs:   make(map[string]cron.EntryID),
+		completed: make(map[string]completion),
 		onExecute: onExecute,
 	}
 }
@@ -49,12 +51,19 @@ func (s *Scheduler) AddTask(task *ScheduledTask) error {
 		task.ID = uuid.New().String()
 	}
 
+	if err := validateTask(task); err != nil {
+		return err
+	}
+
 	// Check for duplicate name
 	for _, t := range s.tasks {
 		if t.ID != task.ID && t.Name == task.Name {
 			return fmt.Errorf("schedule name '%s' already exists", task.Name)
 		}
 	}
+	if err := s.checkDependencies(task); err != nil {
+		return err
+	}
 
 	s.tasks[task.ID] = task
 
@@ -72,12 +81,19 @@ func (s *Scheduler) UpdateTask(task *ScheduledTask) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if err := validateTask(task); err != nil {
+		return err
+	}
+
 	// Check for duplicate name
 	for _, t := range s.tasks {
 		if t.ID != task.ID && t.Name == task.Name {
 			return fmt.Errorf("schedule name '%s' already exists", task.Name)
 		}
 	}
+	if err := s.checkDependencies(task); err != nil {
+		return err
+	}
 
 	// Remove old cron job if exists
 	if entryID, exists := s.cronIDs[task.ID]; exists {
@@ -101,6 +117,10 @@ func (s *Scheduler) DeleteTask(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if names := s.dependents(id); len(names) > 0 {
+		return fmt.Errorf("'%s' runs after this schedule", strings.Join(names, "', '"))
+	}
+
 	if entryID, exists := s.cronIDs[id]; exists {
 		s.cron.Remove(entryID)
 		delete(s.cronIDs, id)
@@ -130,6 +150,8 @@ func (s *Scheduler) GetTasks() []*ScheduledTask {
 			if !entry.Next.IsZero() {
 				next := entry.Next
 				task.NextRun = &next
+			} else {
+				task.NextRun = nil
 			}
 		}
 		tasks = append(tasks, task)
@@ -178,76 +200,138 @@ func (s *Scheduler) LoadTasks(tasks []*ScheduledTask) {
 	}
 }
 
-// scheduleTask adds a cron job for the task (must be called with lock held)
-func (s *Scheduler) scheduleTask(task *ScheduledTask) error {
-	cronExpr, err := s.buildCronExpression(task)
-	if err != nil {
-		return err
-	}
+// RunStates returns the run state of every task. While the scheduler runs,
+// all runs up to now have fired, so every task is checked up to now.
+func (s *Scheduler) RunStates(now time.Time) map[string]RunState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	taskID := task.ID
-	entryID, err := s.cron.AddFunc(cronExpr, func() {
-		s.executeTask(taskID)
-	})
-	if err != nil {
-		return fmt.Errorf("failed to schedule task: %v", err)
+	states := make(map[string]RunState, len(s.tasks))
+	for id, task := range s.tasks {
+		states[id] = RunState{LastRun: task.LastRun, Checked: now}
 	}
+	return states
+}
 
-	s.cronIDs[task.ID] = entryID
+// CatchUp restores the last run times from states and returns the runs of
+// enabled tasks that were due between their state's Checked time and now.
+// Tasks without a state (new or never saved) have missed nothing.
+func (s *Scheduler) CatchUp(states map[string]RunState, now time.Time) []MissedRun {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	// Update next run time
-	entry := s.cron.Entry(entryID)
-	if !entry.Next.IsZero() {
-		next := entry.Next
-		task.NextRun = &next
+	var missed []MissedRun
+	for id, task := range s.tasks {
+		state, ok := states[id]
+		if !ok {
+			continue
+		}
+		if state.LastRun != nil && (task.LastRun == nil || state.LastRun.After(*task.LastRun)) {
+			task.LastRun = state.LastRun
+		}
+		if !task.Enabled || state.Checked.IsZero() {
+			continue
+		}
+
+		sched, err := task.Schedule()
+		if err != nil {
+			continue
+		}
+		m := MissedRun{Task: task}
+		for next := sched.Next(state.Checked); !next.IsZero() && !next.After(now); next = sched.Next(next) {
+			if len(m.Times) == MaxMissedRuns {
+				m.Truncated = true
+				break
+			}
+			m.Times = append(m.Times, next)
+		}
+		if len(m.Times) > 0 {
+			missed = append(missed, m)
+		}
 	}
 
-	return nil
+	sort.Slice(missed, func(i, j int) bool {
+		return missed[i].Times[0].Before(missed[j].Times[0])
+	})
+	return missed
 }
 
-// buildCronExpression creates a cron expression from task schedule
-func (s *Scheduler) buildCronExpression(task *ScheduledTask) (string, error) {
-	parts := strings.Split(task.Time, ":")
-	if len(parts) != 2 {
-		return "", fmt.Errorf("invalid time format: %s", task.Time)
-	}
+// Clear removes all tasks (used when switching to another workspace)
+func (s *Scheduler) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
-	hour, err := strconv.Atoi(parts[0])
-	if err != nil || hour < 0 || hour > 23 {
-		return "", fmt.Errorf("invalid hour: %s", parts[0])
+	for id, entryID := range s.cronIDs {
+		s.cron.Remove(entryID)
+		delete(s.cronIDs, id)
 	}
+	s.tasks = make(map[string]*ScheduledTask)
+	s.completed = make(map[string]completion)
+}
 
-	minute, err := strconv.Atoi(parts[1])
-	if err != nil || minute < 0 || minute > 59 {
-		return "", fmt.Errorf("invalid minute: %s", parts[1])
+// validateTask checks task settings, including the schedule of disabled tasks
+func validateTask(task *ScheduledTask) error {
+	if _, err := task.Schedule(); err != nil {
+		return err
+	}
+	if task.ScheduleType != TypeAfter && len(task.After) > 0 {
+		return fmt.Errorf("only 'after' schedules can run after other schedules")
+	}
+	switch task.MissedRunPolicy {
+	case "", MissedSkip, MissedRunOnce, MissedRunAll:
+	default:
+		return fmt.Errorf("unknown missed run policy: %s", task.MissedRunPolicy)
+	}
+	switch task.OverlapPolicy {
+	case "", OverlapQueue, OverlapSkip, OverlapReplace:
+	default:
+		return fmt.Errorf("unknown overlap policy: %s", task.OverlapPolicy)
 	}
+	if task.MaxQueueWait < 0 || task.MaxQueueWait > MaxQueueWaitMinutes {
+		return fmt.Errorf("max queue wait must be between 0 and %d minutes", MaxQueueWaitMinutes)
+	}
+	if !ValidPriority(task.Priority) {
+		return fmt.Errorf("unknown queue priority: %s", task.Priority)
+	}
+	switch task.BlackoutPolicy {
+	case "", BlackoutSkip, BlackoutDefer:
+	default:
+		return fmt.Errorf("unknown blackout policy: %s", task.BlackoutPolicy)
+	}
+	for i := range task.Blackouts {
+		if err := task.Blackouts[i].Validate(); err != nil {
+			return err
+		}
+	}
+	if err := task.Target.Validate(); err != nil {
+		return fmt.Errorf("invalid target: %v", err)
+	}
+	return nil
+}
 
-	switch task.ScheduleType {
-	case "daily":
-		// Run every day at specified time
-		return fmt.Sprintf("%d %d * * *", minute, hour), nil
+// scheduleTask adds a cron job for the task (must be called with lock held)
+func (s *Scheduler) scheduleTask(task *ScheduledTask) error {
+	sched, err := task.Schedule()
+	if err != nil {
+		return fmt.Errorf("failed to schedule task: %v", err)
+	}
 
-	case "weekly":
-		// Run on specified days of week
-		if len(task.DaysOfWeek) == 0 {
-			return "", fmt.Errorf("no days specified for weekly schedule")
-		}
-		days := make([]string, len(task.DaysOfWeek))
-		for i, d := range task.DaysOfWeek {
-			days[i] = strconv.Itoa(d)
-		}
-		return fmt.Sprintf("%d %d * * %s", minute, hour, strings.Join(days, ",")), nil
+	taskID := task.ID
+	entryID := s.cron.Schedule(sched, cron.FuncJob(func() {
+		s.executeTask(taskID)
+	}))
 
-	case "monthly":
-		// Run on specified day of month
-		if task.DayOfMonth < 1 || task.DayOfMonth > 31 {
-			return "", fmt.Errorf("invalid day of month: %d", task.DayOfMonth)
-		}
-		return fmt.Sprintf("%d %d %d * *", minute, hour, task.DayOfMonth), nil
+	s.cronIDs[task.ID] = entryID
 
-	default:
-		return "", fmt.Errorf("unknown schedule type: %s", task.ScheduleType)
+	// Update next run time; expired tasks have none
+	entry := s.cron.Entry(entryID)
+	task.NextRun = nil
+	if !entry.Next.IsZero() {
+		next := entry.Next
+		task.NextRun = &next
 	}
+
+	return nil
 }
 
 // executeTask runs a scheduled task
@@ -262,6 +346,16 @@ func (s *Scheduler) executeTask(taskID string) {
 	// Update last run time
 	now := time.Now()
 	task.LastRun = &now
+
+	// One-time tasks disable themselves after firing
+	if task.ScheduleType == TypeOnce {
+		task.Enabled = false
+		task.NextRun = nil
+		if entryID, exists := s.cronIDs[taskID]; exists {
+			s.cron.Remove(entryID)
+			delete(s.cronIDs, taskID)
+		}
+	}
 	s.mu.Unlock()
 
 	// Call the execution callback
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"cisco-plink/internal/cisco"
+	"cisco-plink/internal/inventory"
 )
 
 // ScheduledTask represents a scheduled execution task
@@ -13,32 +14,75 @@ type ScheduledTask struct {
 	Enabled bool   `json:"enabled"`
 
 	// Schedule configuration
-	ScheduleType string `json:"scheduleType"` // "daily", "weekly", "monthly"
-	Time         string `json:"time"`         // "HH:MM" (24-hour format)
-	DaysOfWeek   []int  `json:"daysOfWeek"`   // 0-6 (Sunday-Saturday) for weekly
-	DayOfMonth   int    `json:"dayOfMonth"`   // 1-31 for monthly
+	ScheduleType   string `json:"scheduleType"`             // "daily", "weekly", "monthly", "cron", "interval", "once", "after"
+	Time           string `json:"time"`                     // "HH:MM" (24-hour format); anchor time for interval
+	DaysOfWeek     []int  `json:"daysOfWeek"`               // 0-6 (Sunday-Saturday) for weekly
+	DayOfMonth     int    `json:"dayOfMonth"`               // 1-31 for monthly
+	CronExpression string `json:"cronExpression,omitempty"` // 5 or 6 fields (optional seconds) for cron
+	Interval       int    `json:"interval,omitempty"`       // Every N IntervalUnit for interval
+	IntervalUnit   string `json:"intervalUnit,omitempty"`   // "minutes" or "hours"
+	RunAt          string `json:"runAt,omitempty"`          // "YYYY-MM-DDTHH:MM" for once; the task disables itself after firing
+	StartDate      string `json:"startDate,omitempty"`      // "YYYY-MM-DD"; no runs before this day
+	EndDate        string `json:"endDate,omitempty"`        // "YYYY-MM-DD"; no runs after this day
+	TimeZone       string `json:"timeZone,omitempty"`       // IANA name (e.g. "Asia/Seoul") all times refer to; empty = local
+
+	// Tasks whose completion triggers this task, for "after"; all must complete
+	After []Dependency `json:"after,omitempty"`
+
+	// Runs missed while the app was closed
+	MissedRunPolicy string `json:"missedRunPolicy,omitempty"` // "skip" (default), "once" or "all"
+	NotifyMissed    bool   `json:"notifyMissed,omitempty"`    // Email EmailTo about missed runs
+
+	// Runs that fire while another run is in progress
+	OverlapPolicy string `json:"overlapPolicy,omitempty"` // "queue" (default), "skip" or "replace"
+	MaxQueueWait  int    `json:"maxQueueWait,omitempty"`  // Minutes a run may wait in the queue; 0 = no limit
+	Priority      string `json:"priority,omitempty"`      // Queue priority: "high", "normal" (default) or "low"
+
+	// Blackout windows (in addition to the global ones)
+	Blackouts      []Blackout `json:"blackouts,omitempty"`
+	BlackoutPolicy string     `json:"blackoutPolicy,omitempty"` // "skip" (default) or "defer"
 
 	// Credentials
-	Username       string `json:"username"`
-	Password       string `json:"password"`
-	EnablePassword string `json:"enablePassword,omitempty"`
+	Username          string `json:"username"`
+	Password          string `json:"password"`
+	EnablePassword    string `json:"enablePassword,omitempty"`
+	CredentialProfile string `json:"credentialProfile,omitempty"` // Used for servers without their own credentials
 
 	// Execution configuration
-	Servers         []cisco.Server `json:"servers"`
-	Commands        []string       `json:"commands"`
-	Timeout         int            `json:"timeout"`
-	EnableMode      bool           `json:"enableMode"`
-	DisablePaging   bool           `json:"disablePaging"`
-	AutoExportExcel bool           `json:"autoExportExcel"`
+	Servers         []cisco.Server    `json:"servers"`
+	Target          *inventory.Target `json:"target,omitempty"` // Resolved against the inventory at run time; overrides Servers
+	Commands        []string          `json:"commands"`
+	Timeout         int               `json:"timeout"`
+	EnableMode      bool              `json:"enableMode"`
+	DisablePaging   bool              `json:"disablePaging"`
+	AutoExportExcel bool              `json:"autoExportExcel"`
 
 	// Email notification
 	EmailEnabled bool   `json:"emailEnabled"`
 	EmailTo      string `json:"emailTo"`
 
+	// Secret redaction
+	RedactLogs     bool `json:"redactLogs,omitempty"`     // Redact saved logs and Excel (and therefore email attachments)
+	RedactEmail    bool `json:"redactEmail,omitempty"`    // Redact email attachments only
+	KeepUnredacted bool `json:"keepUnredacted,omitempty"` // With RedactLogs, also keep an encrypted unredacted copy
+
 	// Metadata
 	LastRun *time.Time `json:"lastRun,omitempty"`
 	NextRun *time.Time `json:"nextRun,omitempty"`
 }
 
+// RunState is the run bookkeeping of a task kept across restarts
+type RunState struct {
+	LastRun *time.Time `json:"lastRun,omitempty"`
+	Checked time.Time  `json:"checked"` // runs up to this time have fired or were handled as missed
+}
+
+// MissedRun lists the runs of a task that were due while the app was closed
+type MissedRun struct {
+	Task      *ScheduledTask
+	Times     []time.Time // oldest first, at most MaxMissedRuns
+	Truncated bool        // more runs were missed than listed
+}
+
 // ScheduleCallback is called when a scheduled task should be executed
 type ScheduleCallback func(task *ScheduledTask)
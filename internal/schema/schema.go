@@ -0,0 +1,219 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"cisco-plink/internal/fsutil"
+)
+
+// VersionField is the top-level field holding a config file's schema version.
+// Files written before versioning have no such field and are version 0.
+const VersionField = "schemaVersion"
+
+// Migration upgrades a decoded document from version From to From+1.
+// doc is the result of json.Unmarshal into interface{}: a map for JSON
+// objects, or whatever an unversioned file contained. A nil Apply only
+// bumps the version.
+type Migration struct {
+	From        int
+	Description string
+	Apply       func(doc interface{}) (interface{}, error)
+}
+
+// Unversioned is the first migration of files whose format did not change
+// when versioning was introduced
+var Unversioned = Migration{From: 0, Description: "add schema version"}
+
+// Schema describes the current version of a config file and how older
+// versions are upgraded to it
+type Schema struct {
+	Name       string // file name, for messages
+	Version    int
+	Migrations []Migration
+	NoBackups  bool // WriteFile keeps no backups (files that change constantly)
+}
+
+// NewerVersionError is returned for files written by a newer version of the
+// application. They are never loaded or overwritten, so no data is lost by
+// running an older version.
+type NewerVersionError struct {
+	Name      string
+	Found     int
+	Supported int
+}
+
+func (e *NewerVersionError) Error() string {
+	return fmt.Sprintf("%s was written by a newer version of the application (schema %d, this version supports %d); update the application", e.Name, e.Found, e.Supported)
+}
+
+// ReadFile reads the file at path and upgrades it to the current schema
+// (see Upgrade). Errors from reading are returned unchanged, so
+// os.IsNotExist still works on them.
+func (s *Schema) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Upgrade(path, data)
+}
+
+// Upgrade returns the content of the file at path in the current schema.
+// If the file is older, a backup is written next to it first
+// (<path>.v<version>-<timestamp>.bak) and the file is rewritten upgraded.
+// A file from a newer version returns a *NewerVersionError.
+func (s *Schema) Upgrade(path string, data []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", s.Name, err)
+	}
+	version, err := s.version(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version == s.Version {
+		return data, nil
+	}
+
+	doc, err = s.Migrate(doc, version)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, err := s.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	// A read-only instance uses the upgraded content without saving it
+	if fsutil.ReadOnly() {
+		return upgraded, nil
+	}
+
+	perm := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	backup := fmt.Sprintf("%s.v%d-%s.bak", path, version, time.Now().Format("20060102-150405"))
+	if err := fsutil.WriteFile(backup, data, perm); err != nil {
+		return nil, fmt.Errorf("%s: failed to back up before upgrading: %v", s.Name, err)
+	}
+	if err := fsutil.WriteFile(path, upgraded, perm); err != nil {
+		return nil, fmt.Errorf("%s: failed to write upgraded file: %v", s.Name, err)
+	}
+	return upgraded, nil
+}
+
+// Migrate applies the migrations from version from to the current version
+func (s *Schema) Migrate(doc interface{}, from int) (interface{}, error) {
+	if from > s.Version {
+		return nil, &NewerVersionError{Name: s.Name, Found: from, Supported: s.Version}
+	}
+
+	for v := from; v < s.Version; v++ {
+		m := s.migration(v)
+		if m == nil {
+			return nil, fmt.Errorf("%s: no migration from schema version %d", s.Name, v)
+		}
+		if m.Apply == nil {
+			continue
+		}
+		var err error
+		if doc, err = m.Apply(doc); err != nil {
+			return nil, fmt.Errorf("%s: migration %d->%d (%s): %v", s.Name, v, v+1, m.Description, err)
+		}
+	}
+	return doc, nil
+}
+
+// Marshal encodes v (which must encode to a JSON object) with the current
+// schema version as its first field
+func (s *Schema) Marshal(v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSpace(body)
+	if len(body) < 2 || body[0] != '{' {
+		return nil, fmt.Errorf("%s: document must be a JSON object", s.Name)
+	}
+
+	// Replace any version the document already carries
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields[VersionField]; ok {
+		delete(fields, VersionField)
+		if body, err = json.Marshal(fields); err != nil {
+			return nil, err
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `{%q:%d`, VersionField, s.Version)
+	if inner := strings.TrimSpace(string(body[1 : len(body)-1])); inner != "" {
+		buf.WriteByte(',')
+		buf.WriteString(inner)
+	}
+	buf.WriteByte('}')
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// WriteFile saves data to path (see fsutil.SaveFile, or fsutil.WriteFile
+// with NoBackups) unless the existing file is from a newer schema version,
+// which is never overwritten
+func (s *Schema) WriteFile(path string, data []byte, perm os.FileMode) error {
+	existing, err := os.ReadFile(path)
+	if err == nil && len(bytes.TrimSpace(existing)) > 0 {
+		var doc interface{}
+		if json.Unmarshal(existing, &doc) == nil {
+			if version, _ := s.version(doc); version > s.Version {
+				return &NewerVersionError{Name: s.Name, Found: version, Supported: s.Version}
+			}
+		}
+	}
+	if s.NoBackups {
+		return fsutil.WriteFile(path, data, perm)
+	}
+	return fsutil.SaveFile(path, data, perm)
+}
+
+// version returns the schema version of a decoded document
+func (s *Schema) version(doc interface{}) (int, error) {
+	obj, ok := doc.(map[string]interface{})
+	if !ok {
+		return 0, nil
+	}
+	raw, ok := obj[VersionField]
+	if !ok {
+		return 0, nil
+	}
+	v, ok := raw.(float64)
+	if !ok || v < 0 || v != float64(int(v)) {
+		return 0, fmt.Errorf("%s: invalid %s %v", s.Name, VersionField, raw)
+	}
+	return int(v), nil
+}
+
+// migration returns the migration starting at version from, or nil
+func (s *Schema) migration(from int) *Migration {
+	for i := range s.Migrations {
+		if s.Migrations[i].From == from {
+			return &s.Migrations[i]
+		}
+	}
+	return nil
+}
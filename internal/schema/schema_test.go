@@ -0,0 +1,206 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testSchema is at version 3: 0->1 only adds the version, 1->2 renames
+// "host" to "hostname" and 2->3 wraps the document in "config"
+var testSchema = &Schema{
+	Name:    "test.json",
+	Version: 3,
+	Migrations: []Migration{
+		Unversioned,
+		{From: 1, Description: "rename host", Apply: func(doc interface{}) (interface{}, error) {
+			m, ok := doc.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("not an object")
+			}
+			if h, ok := m["host"]; ok {
+				m["hostname"] = h
+				delete(m, "host")
+			}
+			return m, nil
+		}},
+		{From: 2, Description: "wrap in config", Apply: func(doc interface{}) (interface{}, error) {
+			// Migrations see the version field of versioned files
+			if m, ok := doc.(map[string]interface{}); ok {
+				delete(m, VersionField)
+			}
+			return map[string]interface{}{"config": doc}, nil
+		}},
+	},
+}
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var doc interface{}
+	if err := json.Unmarshal([]byte(s), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    int
+		doc     string
+		want    string
+		wantErr string
+	}{
+		{"from unversioned", 0, `{"host":"a"}`, `{"config":{"hostname":"a"}}`, ""},
+		{"from 1", 1, `{"host":"a"}`, `{"config":{"hostname":"a"}}`, ""},
+		{"from 2 keeps host", 2, `{"host":"a"}`, `{"config":{"host":"a"}}`, ""},
+		{"current", 3, `{"config":{}}`, `{"config":{}}`, ""},
+		{"migration error", 1, `[1]`, "", "migration 1->2 (rename host): not an object"},
+		{"newer", 4, `{}`, "", "newer version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testSchema.Migrate(decode(t, tt.doc), tt.from)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := decode(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("got %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMigrateMissingStep(t *testing.T) {
+	s := &Schema{Name: "gap.json", Version: 2, Migrations: []Migration{Unversioned}}
+	_, err := s.Migrate(map[string]interface{}{}, 0)
+	if err == nil || !strings.Contains(err.Error(), "no migration from schema version 1") {
+		t.Fatalf("err = %v, want a missing migration error", err)
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		want       string // decoded content returned, "" for unchanged
+		wantBackup bool
+		wantErr    string
+	}{
+		{"no version field", `{"host":"a"}`, `{"schemaVersion":3,"config":{"hostname":"a"}}`, true, ""},
+		{"older version", `{"schemaVersion":2,"host":"a"}`, `{"schemaVersion":3,"config":{"host":"a"}}`, true, ""},
+		{"current version", `{"schemaVersion":3,"config":{}}`, "", false, ""},
+		{"empty file", "  \n", "", false, ""},
+		{"newer version", `{"schemaVersion":9}`, "", false, "newer version"},
+		{"invalid version", `{"schemaVersion":"x"}`, "", false, "invalid schemaVersion"},
+		{"invalid json", `{`, "", false, "test.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "test.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := testSchema.ReadFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if onDisk, _ := os.ReadFile(path); string(onDisk) != tt.content {
+					t.Errorf("file was rewritten to %s", onDisk)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.want == "" {
+				if string(got) != tt.content {
+					t.Errorf("got %s, want the file unchanged", got)
+				}
+			} else {
+				if !reflect.DeepEqual(decode(t, string(got)), decode(t, tt.want)) {
+					t.Errorf("got %s, want %s", got, tt.want)
+				}
+				if onDisk, _ := os.ReadFile(path); string(onDisk) != string(got) {
+					t.Errorf("file on disk = %s, want the upgraded content", onDisk)
+				}
+			}
+
+			backups, _ := filepath.Glob(path + ".v*.bak")
+			if tt.wantBackup {
+				if len(backups) != 1 {
+					t.Fatalf("backups = %v, want one", backups)
+				}
+				if data, _ := os.ReadFile(backups[0]); string(data) != tt.content {
+					t.Errorf("backup = %s, want the original content", data)
+				}
+			} else if len(backups) != 0 {
+				t.Errorf("backups = %v, want none", backups)
+			}
+		})
+	}
+}
+
+func TestWriteFileRefusesNewer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.json")
+	newer := `{"schemaVersion":4,"future":true}`
+	if err := os.WriteFile(path, []byte(newer), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	err := testSchema.WriteFile(path, []byte(`{"schemaVersion":3}`), 0600)
+	var nv *NewerVersionError
+	if !errors.As(err, &nv) || nv.Found != 4 || nv.Supported != 3 {
+		t.Fatalf("err = %v, want a NewerVersionError for 4 > 3", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Errorf("file was overwritten with %s", data)
+	}
+}
+
+func TestMarshal(t *testing.T) {
+	got, err := testSchema.Marshal(map[string]interface{}{"schemaVersion": 1, "a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(got), "{\n  \"schemaVersion\": 3,") {
+		t.Errorf("got %s, want the current version first", got)
+	}
+
+	if _, err := testSchema.Marshal([]int{1}); err == nil {
+		t.Error("Marshal of an array succeeded, want an error")
+	}
+}
+
+func TestWriteFileBackups(t *testing.T) {
+	for _, noBackups := range []bool{false, true} {
+		s := &Schema{Name: "test.json", Version: 1, NoBackups: noBackups}
+		path := filepath.Join(t.TempDir(), "test.json")
+		for _, content := range []string{`{"schemaVersion":1,"a":1}`, `{"schemaVersion":1,"a":2}`} {
+			if err := s.WriteFile(path, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		backups, _ := filepath.Glob(path + ".bak*")
+		if noBackups && len(backups) != 0 {
+			t.Errorf("NoBackups: backups = %v, want none", backups)
+		} else if !noBackups && len(backups) != 1 {
+			t.Errorf("backups = %v, want one", backups)
+		}
+	}
+}
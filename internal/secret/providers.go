@@ -0,0 +1,116 @@
+package secret
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// execTimeout bounds how long an exec: command may run
+const execTimeout = 30 * time.Second
+
+// ExecNotApprovedError is returned for an exec: command that has not been
+// approved on this computer. A command runs only after the user has seen
+// and allowed it, so a reference planted in imported or synced data cannot
+// run unnoticed.
+type ExecNotApprovedError struct {
+	Command string
+}
+
+func (e *ExecNotApprovedError) Error() string {
+	return "command has not been allowed on this computer: " + e.Command
+}
+
+var (
+	approvedMu sync.RWMutex
+	approved   = map[string]bool{} // by CommandHash
+)
+
+// CommandHash returns the hash under which an exec: command is approved
+func CommandHash(command string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(command)))
+	return hex.EncodeToString(sum[:])
+}
+
+// SetApprovedCommands replaces the approved exec: commands, given by their
+// CommandHash
+func SetApprovedCommands(hashes []string) {
+	approvedMu.Lock()
+	defer approvedMu.Unlock()
+	approved = make(map[string]bool, len(hashes))
+	for _, h := range hashes {
+		approved[h] = true
+	}
+}
+
+// commandApproved reports whether the user has allowed command
+func commandApproved(command string) bool {
+	approvedMu.RLock()
+	defer approvedMu.RUnlock()
+	return approved[CommandHash(command)]
+}
+
+// resolveEnv reads an environment variable: env:NAME
+func resolveEnv(name string) (string, error) {
+	value, ok := os.LookupEnv(strings.TrimSpace(name))
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return value, nil
+}
+
+// resolvePlain returns a literal password that would otherwise be taken
+// for a reference: plain:env:abc is the password "env:abc"
+func resolvePlain(password string) (string, error) {
+	return password, nil
+}
+
+// resolveFile reads a file and strips the trailing newline: file:/path/to/secret
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// resolveExec runs a command through the system shell and uses its output,
+// without the trailing newline: exec:<command>. Only approved commands run
+// (see SetApprovedCommands).
+func resolveExec(command string) (string, error) {
+	if !commandApproved(command) {
+		return "", &ExecNotApprovedError{Command: strings.TrimSpace(command)}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), execTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		return "", fmt.Errorf("command timed out after %s", execTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%v: %s", err, msg)
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\r\n"), nil
+}
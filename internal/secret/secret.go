@@ -0,0 +1,144 @@
+package secret
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"cisco-plink/internal/cisco"
+)
+
+// Provider resolves a secret reference (the part after "<scheme>:") to its value
+type Provider interface {
+	Resolve(ref string) (string, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface
+type ProviderFunc func(ref string) (string, error)
+
+// Resolve calls f(ref)
+func (f ProviderFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Provider{
+		"env":   ProviderFunc(resolveEnv),
+		"file":  ProviderFunc(resolveFile),
+		"exec":  ProviderFunc(resolveExec),
+		"vault": NewVaultProviderFromEnv(),
+		"plain": ProviderFunc(resolvePlain),
+	}
+)
+
+// Register adds or replaces the provider for a scheme (e.g. "vault")
+func Register(scheme string, p Provider) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(scheme)] = p
+}
+
+// Schemes returns the registered scheme names
+func Schemes() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	schemes := make([]string, 0, len(registry))
+	for s := range registry {
+		schemes = append(schemes, s)
+	}
+	sort.Strings(schemes)
+	return schemes
+}
+
+// split returns the provider and reference of a value like "env:NAME".
+// ok is false if the value does not start with a registered scheme. Schemes
+// are matched exactly in lowercase, so a password such as "Vault:xyz" stays
+// a password; one that does start with a scheme is stored as "plain:<password>".
+func split(value string) (p Provider, ref string, ok bool) {
+	i := strings.Index(value, ":")
+	if i <= 0 {
+		return nil, "", false
+	}
+
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	p, ok = registry[value[:i]]
+	return p, value[i+1:], ok
+}
+
+// IsReference reports whether value is a reference to an external secret
+func IsReference(value string) bool {
+	_, _, ok := split(value)
+	return ok
+}
+
+// Resolve returns the secret a reference points to. Values that are not
+// references are returned unchanged.
+func Resolve(value string) (string, error) {
+	p, ref, ok := split(value)
+	if !ok {
+		return value, nil
+	}
+	secret, err := p.Resolve(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", shorten(value), err)
+	}
+	return secret, nil
+}
+
+// shorten truncates a reference for error messages
+// (references name secrets, they don't contain them)
+func shorten(value string) string {
+	if len(value) > 80 {
+		return value[:77] + "..."
+	}
+	return value
+}
+
+// Resolver resolves references and caches the results, so a reference shared
+// by many servers is fetched once per run. Resolved values are held in
+// memory only.
+type Resolver struct {
+	cache map[string]string
+}
+
+// NewResolver creates an empty Resolver
+func NewResolver() *Resolver {
+	return &Resolver{cache: make(map[string]string)}
+}
+
+// Resolve resolves value through the cache
+func (r *Resolver) Resolve(value string) (string, error) {
+	if !IsReference(value) {
+		return value, nil
+	}
+	if secret, ok := r.cache[value]; ok {
+		return secret, nil
+	}
+	secret, err := Resolve(value)
+	if err != nil {
+		return "", err
+	}
+	r.cache[value] = secret
+	return secret, nil
+}
+
+// Credentials returns a copy of creds with every reference resolved.
+// nil yields nil.
+func (r *Resolver) Credentials(creds *cisco.Credentials) (*cisco.Credentials, error) {
+	if creds == nil {
+		return nil, nil
+	}
+
+	resolved := *creds
+	for _, field := range []*string{&resolved.User, &resolved.Password, &resolved.EnablePassword, &resolved.PrivateKey, &resolved.Passphrase} {
+		value, err := r.Resolve(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = value
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,82 @@
+package secret
+
+import (
+	"errors"
+	"testing"
+
+	"cisco-plink/internal/cisco"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("SECRET_TEST_PASSWORD", "from-env")
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"env:SECRET_TEST_PASSWORD", "from-env"},
+		{"plain:env:SECRET_TEST_PASSWORD", "env:SECRET_TEST_PASSWORD"},
+		{"Env:SECRET_TEST_PASSWORD", "Env:SECRET_TEST_PASSWORD"},
+		{"Vault:xyz", "Vault:xyz"},
+		{"abc:def", "abc:def"},
+		{"password", "password"},
+		{":env", ":env"},
+	}
+	for _, tt := range tests {
+		if got, err := Resolve(tt.value); err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestResolverCaches(t *testing.T) {
+	calls := 0
+	Register("counter", ProviderFunc(func(ref string) (string, error) {
+		calls++
+		return "secret-" + ref, nil
+	}))
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registry, "counter")
+		registryMu.Unlock()
+	})
+
+	r := NewResolver()
+	creds, err := r.Credentials(&cisco.Credentials{User: "counter:a", Password: "counter:a", EnablePassword: "counter:b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if creds.User != "secret-a" || creds.Password != "secret-a" || creds.EnablePassword != "secret-b" {
+		t.Errorf("Credentials = %+v, want every field resolved", creds)
+	}
+	if _, err := r.Resolve("counter:a"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times, want once per reference", calls)
+	}
+
+	// A new resolver, e.g. of the next run, fetches again
+	if _, err := NewResolver().Resolve("counter:a"); err != nil || calls != 3 {
+		t.Errorf("calls = %d, %v, want the reference fetched again", calls, err)
+	}
+}
+
+func TestExecApproval(t *testing.T) {
+	t.Cleanup(func() { SetApprovedCommands(nil) })
+	const ref = "exec:echo hello"
+
+	SetApprovedCommands(nil)
+	_, err := Resolve(ref)
+	var notApproved *ExecNotApprovedError
+	if !errors.As(err, &notApproved) || notApproved.Command != "echo hello" {
+		t.Fatalf("err = %v, want an ExecNotApprovedError for echo hello", err)
+	}
+
+	SetApprovedCommands([]string{CommandHash(" echo hello ")})
+	if got, err := Resolve(ref); err != nil || got != "hello" {
+		t.Errorf("Resolve = %q, %v, want hello once approved", got, err)
+	}
+	if _, err := Resolve("exec:echo hello; id"); !errors.As(err, &notApproved) {
+		t.Errorf("err = %v, want a changed command to need approval again", err)
+	}
+}
@@ -0,0 +1,114 @@
+package secret
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultVaultField is used when a vault: reference has no "#field"
+const defaultVaultField = "password"
+
+// VaultProvider reads secrets from a HashiCorp Vault compatible KV v2 engine.
+// References have the form vault:<mount>/<path>#<field>, e.g.
+// vault:secret/network/core#password reads field "password" from
+// GET <Address>/v1/secret/data/network/core.
+type VaultProvider struct {
+	Address   string
+	Token     string
+	Namespace string // Vault Enterprise namespace (optional)
+	Client    *http.Client
+}
+
+// NewVaultProviderFromEnv creates a provider from VAULT_ADDR, VAULT_TOKEN
+// and VAULT_NAMESPACE
+func NewVaultProviderFromEnv() *VaultProvider {
+	return &VaultProvider{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+}
+
+// kvV2Response is the subset of a KV v2 read response that is used
+type kvV2Response struct {
+	Data struct {
+		Data map[string]interface{} `json:"data"`
+	} `json:"data"`
+	Errors []string `json:"errors"`
+}
+
+// Resolve reads one field of a KV v2 secret
+func (v *VaultProvider) Resolve(ref string) (string, error) {
+	if strings.TrimSpace(v.Address) == "" {
+		return "", fmt.Errorf("vault address is not configured")
+	}
+
+	path, field := ref, defaultVaultField
+	if i := strings.LastIndex(ref, "#"); i >= 0 {
+		path, field = ref[:i], ref[i+1:]
+	}
+	path = strings.Trim(path, "/")
+	mount, secretPath, ok := strings.Cut(path, "/")
+	if !ok || mount == "" || secretPath == "" || field == "" {
+		return "", fmt.Errorf("invalid vault reference, expected vault:<mount>/<path>#<field>")
+	}
+
+	endpoint, err := url.Parse(strings.TrimRight(strings.TrimSpace(v.Address), "/") + "/v1/" + mount + "/data/" + secretPath)
+	if err != nil {
+		return "", fmt.Errorf("invalid vault address: %v", err)
+	}
+
+	req, err := http.NewRequest("GET", endpoint.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if v.Token != "" {
+		req.Header.Set("X-Vault-Token", v.Token)
+	}
+	if v.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Namespace)
+	}
+
+	client := v.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+
+	var result kvV2Response
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("failed to parse vault response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		if len(result.Errors) > 0 {
+			return "", fmt.Errorf("vault returned status %d: %s", resp.StatusCode, strings.Join(result.Errors, "; "))
+		}
+		return "", fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	value, ok := result.Data.Data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found in %s", field, path)
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q in %s is not a string", field, path)
+	}
+	return str, nil
+}
@@ -0,0 +1,64 @@
+package secret
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestVaultProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		if r.Header.Get("X-Vault-Namespace") != "network" {
+			t.Errorf("X-Vault-Namespace = %q, want network", r.Header.Get("X-Vault-Namespace"))
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/network/core":
+			w.Write([]byte(`{"data":{"data":{"password":"p@ss","enable":"en@ble","port":22}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		ref     string
+		token   string
+		want    string
+		wantErr string
+	}{
+		{"secret/network/core", "s.token", "p@ss", ""},
+		{"secret/network/core#password", "s.token", "p@ss", ""},
+		{"/secret/network/core/#enable", "s.token", "en@ble", ""},
+		{"secret/network/core#missing", "s.token", "", `field "missing" not found`},
+		{"secret/network/core#port", "s.token", "", `field "port" in secret/network/core is not a string`},
+		{"secret/network/edge", "s.token", "", "vault returned status 404"},
+		{"secret/network/core", "wrong", "", "vault returned status 403: permission denied"},
+		{"secret#password", "s.token", "", "invalid vault reference"},
+	}
+	for _, tt := range tests {
+		v := &VaultProvider{Address: srv.URL + "/", Token: tt.token, Namespace: "network"}
+		got, err := v.Resolve(tt.ref)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Resolve(%q) = %q, %v, want an error containing %q", tt.ref, got, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v, want %q", tt.ref, got, err, tt.want)
+		}
+	}
+}
+
+func TestVaultProviderWithoutAddress(t *testing.T) {
+	if _, err := (&VaultProvider{}).Resolve("secret/network/core"); err == nil {
+		t.Error("Resolve without an address succeeded, want an error")
+	}
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"embed"
+	"os"
+
+	"cisco-plink/internal/datadir"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -13,11 +16,21 @@ import (
 var assets embed.FS
 
 func main() {
+	// Resolve the data directory before anything reads configuration
+	dir, err := datadir.Resolve(os.Args[1:])
+	if err == nil {
+		err = datadir.Init(dir)
+	}
+	if err != nil {
+		println("Error: data directory:", err.Error())
+		os.Exit(1)
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
 	// Create application with options
-	err := wails.Run(&options.App{
+	err = wails.Run(&options.App{
 		Title:  "AutoLogCollector",
 		Width:  1024,
 		Height: 768,